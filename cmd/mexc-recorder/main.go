@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command mexc-recorder records MEXC market streams and REST snapshots to rotating
+// archive files until it is interrupted, see recorder.Config for the configuration.
+//
+//	mexc-recorder -config recorder.yaml
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jl1/nexapi/mexc/recorder"
+)
+
+func main() {
+	path := flag.String("config", "recorder.yaml", "path of the YAML configuration")
+	debug := flag.Bool("debug", false, "log debug messages and the raw stream messages")
+	flag.Parse()
+
+	level := slog.LevelInfo
+	if *debug {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	if err := run(*path, *debug, logger); err != nil {
+		logger.Error("recorder failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(path string, debug bool, logger *slog.Logger) error {
+	cfg, err := recorder.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	cfg.Debug = cfg.Debug || debug
+
+	recorderCfg, err := cfg.RecorderCfg(logger)
+	if err != nil {
+		return err
+	}
+
+	r, err := recorder.NewRecorder(recorderCfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Info("recorder started", "config", path, "dir", cfg.Output.Dir)
+
+	return r.Run(ctx)
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var d = decimal.MustParse
+
+func TestSchema(t *testing.T) {
+	columns, err := schemaOf(reflect.TypeOf(spottypes.AggTrade{}))
+	assert.Nil(t, err)
+
+	var names []string
+	for _, v := range columns {
+		names = append(names, v.name)
+	}
+	assert.Equal(t, []string{"a", "f", "l", "p", "q", "T", "m", "Ma"}, names)
+
+	// the embedded Response is inlined
+	columns, err = schemaOf(reflect.TypeOf(contracttypes.GetDepthResp{}))
+	assert.Nil(t, err)
+	assert.Equal(t, "success", columns[0].name)
+	assert.Equal(t, kindJSON, columns[len(columns)-1].kind)
+
+	_, err = schemaOf(reflect.TypeOf(1))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestRoundTrip(t *testing.T) {
+	trades := []*spottypes.AggTrade{
+		{A: 1, F: 10, L: 12, P: d("60000.10"), Q: d("0.0001"), T: 1700000000000, M: true, Ma: true},
+		{A: 2, F: 13, L: 13, P: d("60000.2"), Q: d("1.5"), T: 1700000000001},
+	}
+
+	var depth contracttypes.Depth
+	err := json.Unmarshal([]byte(`{"asks":[[60001.5,10,2]],"bids":[[60000,3,1]],"version":7,"timestamp":1700000000000}`), &depth)
+	assert.Nil(t, err)
+
+	for _, format := range []Format{CSV, JSONL, Parquet} {
+		for _, compression := range []Compression{NoCompression, Gzip, Zstd} {
+			name := string(format) + "-" + string(compression)
+			dir := t.TempDir()
+
+			w, err := NewWriter[spottypes.AggTrade](&WriterCfg{Dir: dir, Prefix: "trades", Format: format, Compression: compression})
+			assert.Nil(t, err, name)
+			assert.Nil(t, w.Write(trades...), name)
+			assert.Nil(t, w.Close(), name)
+			assert.ErrorIs(t, w.Write(trades[0]), ErrClosed)
+
+			files := w.Files()
+			assert.Len(t, files, 1, name)
+
+			got, err := ReadFile[spottypes.AggTrade](files[0])
+			assert.Nil(t, err, name)
+			if assert.Len(t, got, 2, name) {
+				for i, v := range got {
+					assert.Equal(t, trades[i].A, v.A, name)
+					assert.Equal(t, trades[i].P.String(), v.P.String(), name)
+					assert.Equal(t, trades[i].Q.String(), v.Q.String(), name)
+					assert.Equal(t, trades[i].T, v.T, name)
+					assert.Equal(t, trades[i].M, v.M, name)
+					assert.Equal(t, trades[i].Ma, v.Ma, name)
+				}
+			}
+
+			// nested values
+			dw, err := NewWriter[contracttypes.Depth](&WriterCfg{Dir: dir, Prefix: "depth", Format: format, Compression: compression})
+			assert.Nil(t, err, name)
+			assert.Nil(t, dw.Write(&depth), name)
+			assert.Nil(t, dw.Close(), name)
+
+			depths, err := ReadFile[contracttypes.Depth](dw.Files()[0])
+			assert.Nil(t, err, name)
+			if assert.Len(t, depths, 1, name) {
+				assert.Equal(t, int64(7), depths[0].Version, name)
+				assert.Equal(t, "60001.5", depths[0].Asks[0].Price.String(), name)
+				assert.Equal(t, 1, depths[0].Bids[0].OrderCount, name)
+			}
+		}
+	}
+}
+
+func TestRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewWriter[BookLevel](&WriterCfg{Dir: dir, Prefix: "book", Format: CSV, MaxBytes: 80})
+	assert.Nil(t, err)
+
+	var ob spottypes.Orderbook
+	err = json.Unmarshal([]byte(`{"lastUpdateId":1,"bids":[["100","1"],["99","2"]],"asks":[["101","1"],["102","2"]]}`), &ob)
+	assert.Nil(t, err)
+
+	levels := FlattenSpotOrderbook("BTCUSDT", 1700000000000, &ob)
+	assert.Len(t, levels, 4)
+	assert.Equal(t, "SELL", levels[3].Side)
+	assert.Equal(t, 1, levels[3].Level)
+
+	// the header and one level exceed MaxBytes, every level gets its own file
+	assert.Nil(t, w.Write(levels...))
+	assert.Nil(t, w.Close())
+
+	files := w.Files()
+	assert.Len(t, files, 4)
+
+	var got []*BookLevel
+	for i, v := range files {
+		assert.True(t, strings.HasPrefix(filepath.Base(v), "book-"))
+		assert.True(t, strings.HasSuffix(v, fmt.Sprintf("-%04d.csv", i+1)))
+
+		levels, err := ReadFile[BookLevel](v)
+		assert.Nil(t, err)
+		got = append(got, levels...)
+	}
+	assert.Len(t, got, 4)
+	assert.Equal(t, "102", got[3].Price.String())
+
+	// rotation by hand, no empty file is created
+	w, err = NewWriter[BookLevel](&WriterCfg{Dir: dir, Prefix: "manual", Format: JSONL})
+	assert.Nil(t, err)
+	assert.Nil(t, w.Rotate())
+	assert.Nil(t, w.Write(levels[0]))
+	assert.Nil(t, w.Rotate())
+	assert.Nil(t, w.Write(levels[1]))
+	assert.Nil(t, w.Close())
+	assert.Len(t, w.Files(), 2)
+
+	_, err = os.Stat(w.Files()[1])
+	assert.Nil(t, err)
+}
+
+func TestDetect(t *testing.T) {
+	format, compression, err := detect("a/klines-1.jsonl.zst")
+	assert.Nil(t, err)
+	assert.Equal(t, JSONL, format)
+	assert.Equal(t, Zstd, compression)
+
+	_, _, err = detect("klines.txt")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package archive
+
+import (
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// BookLevel is a row of an order book snapshot. Snapshots can be archived as they
+// are, with the levels as JSON text, or flattened to one row per level which is
+// easier to query.
+type BookLevel struct {
+	// Time of the snapshot in milliseconds
+	Time    int64  `json:"time"`
+	Symbol  string `json:"symbol"`
+	Version int64  `json:"version"`
+	// Side is BUY for bids and SELL for asks
+	Side string `json:"side"`
+	// Level is the rank of the price from the best one, starting at 0
+	Level int             `json:"level"`
+	Price decimal.Decimal `json:"price"`
+	Qty   decimal.Decimal `json:"qty"`
+	// Orders is the number of orders at the price, zero when unknown
+	Orders int `json:"orders"`
+}
+
+// FlattenSpotOrderbook returns the levels of a snapshot returned by GetOrderbook,
+// bids first. Malformed levels are skipped.
+func FlattenSpotOrderbook(symbol string, ts int64, ob *spottypes.Orderbook) []*BookLevel {
+	ret := make([]*BookLevel, 0, len(ob.Bids)+len(ob.Asks))
+
+	add := func(side string, levels [][]decimal.Decimal) {
+		for i, v := range levels {
+			if len(v) < 2 {
+				continue
+			}
+
+			ret = append(ret, &BookLevel{
+				Time:    ts,
+				Symbol:  symbol,
+				Version: ob.LastUpdateID,
+				Side:    side,
+				Level:   i,
+				Price:   v[0],
+				Qty:     v[1],
+			})
+		}
+	}
+
+	add("BUY", ob.Bids)
+	add("SELL", ob.Asks)
+
+	return ret
+}
+
+// FlattenContractDepth returns the levels of a snapshot returned by GetDepth, bids
+// first, quantities are in contracts.
+func FlattenContractDepth(symbol string, depth *contracttypes.Depth) []*BookLevel {
+	ret := make([]*BookLevel, 0, len(depth.Bids)+len(depth.Asks))
+
+	add := func(side string, levels []*contracttypes.DepthLevel) {
+		for i, v := range levels {
+			ret = append(ret, &BookLevel{
+				Time:    depth.Timestamp,
+				Symbol:  symbol,
+				Version: depth.Version,
+				Side:    side,
+				Level:   i,
+				Price:   v.Price,
+				Qty:     v.Vol,
+				Orders:  v.OrderCount,
+			})
+		}
+	}
+
+	add("BUY", depth.Bids)
+	add("SELL", depth.Asks)
+
+	return ret
+}
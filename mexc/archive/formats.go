@@ -0,0 +1,416 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package archive
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	parquetgzip "github.com/parquet-go/parquet-go/compress/gzip"
+	"github.com/parquet-go/parquet-go/compress/snappy"
+	parquetzstd "github.com/parquet-go/parquet-go/compress/zstd"
+)
+
+type Format string
+
+var (
+	CSV     Format = "csv"
+	JSONL   Format = "jsonl"
+	Parquet Format = "parquet"
+)
+
+// Compression compresses whole CSV and JSON Lines files, and the pages of Parquet
+// files, which use snappy when it is empty.
+type Compression string
+
+var (
+	NoCompression Compression = ""
+	Gzip          Compression = "gzip"
+	Zstd          Compression = "zstd"
+)
+
+// parquetRowGroupSize bounds the rows buffered in memory by a Parquet writer, so that
+// files grow while they are written and can be rotated by size.
+const parquetRowGroupSize = 10000
+
+// ext returns the file extension of format and compression.
+func ext(format Format, compression Compression) string {
+	if format == Parquet {
+		return ".parquet"
+	}
+
+	switch compression {
+	case Gzip:
+		return "." + string(format) + ".gz"
+	case Zstd:
+		return "." + string(format) + ".zst"
+	default:
+		return "." + string(format)
+	}
+}
+
+// detect returns the format and compression of a file from its extension.
+func detect(path string) (Format, Compression, error) {
+	compression := NoCompression
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		compression, path = Gzip, strings.TrimSuffix(path, ".gz")
+	case strings.HasSuffix(path, ".zst"):
+		compression, path = Zstd, strings.TrimSuffix(path, ".zst")
+	}
+
+	for _, v := range []Format{CSV, JSONL, Parquet} {
+		if strings.HasSuffix(path, "."+string(v)) {
+			return v, compression, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+}
+
+// encoder writes records, CSV and JSON Lines encoders hand every record to the
+// underlying writer at once so that the size of a file is known while it is written.
+type encoder interface {
+	encode(v reflect.Value) error
+	// close flushes the encoder, it does not close the underlying writer
+	close() error
+}
+
+type decoder interface {
+	// decode returns io.EOF after the last record
+	decode(v reflect.Value) error
+}
+
+func newEncoder(w io.Writer, format Format, compression Compression, columns []*column) (encoder, error) {
+	switch format {
+	case CSV:
+		return newCSVEncoder(w, columns)
+	case JSONL:
+		return &jsonlEncoder{enc: json.NewEncoder(w)}, nil
+	case Parquet:
+		return newParquetEncoder(w, compression, columns), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// compressWriter wraps w with the stream compression of CSV and JSON Lines files.
+func compressWriter(w io.Writer, format Format, compression Compression) (io.WriteCloser, error) {
+	if format == Parquet {
+		return nopWriteCloser{w}, nil
+	}
+
+	switch compression {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	default:
+		return nopWriteCloser{w}, nil
+	}
+}
+
+func decompressReader(r io.Reader, compression Compression) (io.ReadCloser, error) {
+	switch compression {
+	case Gzip:
+		return gzip.NewReader(r)
+	case Zstd:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	default:
+		return io.NopCloser(r), nil
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+type csvEncoder struct {
+	w       *csv.Writer
+	columns []*column
+	record  []string
+}
+
+func newCSVEncoder(w io.Writer, columns []*column) (*csvEncoder, error) {
+	e := &csvEncoder{w: csv.NewWriter(w), columns: columns, record: make([]string, len(columns))}
+
+	for i, c := range columns {
+		e.record[i] = c.name
+	}
+
+	return e, e.w.Write(e.record)
+}
+
+func (e *csvEncoder) encode(v reflect.Value) error {
+	for i, c := range e.columns {
+		s, err := c.format(v)
+		if err != nil {
+			return err
+		}
+		e.record[i] = s
+	}
+
+	if err := e.w.Write(e.record); err != nil {
+		return err
+	}
+
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type csvDecoder struct {
+	r *csv.Reader
+	// columns in the order of the header, nil for unknown columns
+	columns []*column
+}
+
+func newCSVDecoder(r io.Reader, columns []*column) (*csvDecoder, error) {
+	d := &csvDecoder{r: csv.NewReader(r)}
+	d.r.ReuseRecord = true
+
+	header, err := d.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*column, len(columns))
+	for _, c := range columns {
+		byName[c.name] = c
+	}
+
+	d.columns = make([]*column, len(header))
+	for i, v := range header {
+		d.columns[i] = byName[v]
+	}
+
+	return d, nil
+}
+
+func (d *csvDecoder) decode(v reflect.Value) error {
+	record, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+
+	for i, s := range record {
+		if d.columns[i] == nil {
+			continue
+		}
+
+		if err := d.columns[i].parse(v, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type jsonlEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *jsonlEncoder) encode(v reflect.Value) error {
+	return e.enc.Encode(v.Addr().Interface())
+}
+
+func (e *jsonlEncoder) close() error {
+	return nil
+}
+
+type jsonlDecoder struct {
+	dec *json.Decoder
+}
+
+func (d *jsonlDecoder) decode(v reflect.Value) error {
+	return d.dec.Decode(v.Addr().Interface())
+}
+
+type parquetEncoder struct {
+	w       *parquet.Writer
+	columns []*column
+	// leaf index of every column, parquet sorts columns by name
+	leaves []int
+}
+
+func parquetSchema(columns []*column) (*parquet.Schema, []int) {
+	group := make(parquet.Group, len(columns))
+	for _, c := range columns {
+		switch c.kind {
+		case kindInt, kindUint:
+			group[c.name] = parquet.Int(64)
+		case kindFloat:
+			group[c.name] = parquet.Leaf(parquet.DoubleType)
+		case kindBool:
+			group[c.name] = parquet.Leaf(parquet.BooleanType)
+		default:
+			group[c.name] = parquet.String()
+		}
+	}
+
+	schema := parquet.NewSchema("record", group)
+
+	leaves := make([]int, len(columns))
+	index := make(map[string]int, len(columns))
+	for i, path := range schema.Columns() {
+		index[path[0]] = i
+	}
+	for i, c := range columns {
+		leaves[i] = index[c.name]
+	}
+
+	return schema, leaves
+}
+
+func newParquetEncoder(w io.Writer, compression Compression, columns []*column) *parquetEncoder {
+	var codec compress.Codec = &snappy.Codec{}
+	switch compression {
+	case Gzip:
+		codec = &parquetgzip.Codec{}
+	case Zstd:
+		codec = &parquetzstd.Codec{}
+	}
+
+	schema, leaves := parquetSchema(columns)
+
+	return &parquetEncoder{
+		w:       parquet.NewWriter(w, schema, parquet.Compression(codec), parquet.MaxRowsPerRowGroup(parquetRowGroupSize)),
+		columns: columns,
+		leaves:  leaves,
+	}
+}
+
+func (e *parquetEncoder) encode(v reflect.Value) error {
+	row := make(parquet.Row, len(e.columns))
+
+	for i, c := range e.columns {
+		var value parquet.Value
+
+		f := v.FieldByIndex(c.index)
+		switch c.kind {
+		case kindInt:
+			value = parquet.Int64Value(f.Int())
+		case kindUint:
+			value = parquet.Int64Value(int64(f.Uint()))
+		case kindFloat:
+			value = parquet.DoubleValue(f.Float())
+		case kindBool:
+			value = parquet.BooleanValue(f.Bool())
+		default:
+			s, err := c.format(v)
+			if err != nil {
+				return err
+			}
+			value = parquet.ByteArrayValue([]byte(s))
+		}
+
+		row[e.leaves[i]] = value.Level(0, 0, e.leaves[i])
+	}
+
+	_, err := e.w.WriteRows([]parquet.Row{row})
+	return err
+}
+
+func (e *parquetEncoder) close() error {
+	return e.w.Close()
+}
+
+type parquetDecoder struct {
+	r *parquet.Reader
+	// columns by leaf index, nil for unknown columns
+	columns []*column
+	rows    []parquet.Row
+}
+
+func newParquetDecoder(f *os.File, columns []*column) (*parquetDecoder, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := parquet.OpenFile(f, stat.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*column, len(columns))
+	for _, c := range columns {
+		byName[c.name] = c
+	}
+
+	d := &parquetDecoder{r: parquet.NewReader(file), rows: make([]parquet.Row, 1)}
+	for _, path := range file.Schema().Columns() {
+		d.columns = append(d.columns, byName[path[0]])
+	}
+
+	return d, nil
+}
+
+func (d *parquetDecoder) decode(v reflect.Value) error {
+	n, err := d.r.ReadRows(d.rows)
+	if n == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return err
+	}
+
+	for _, value := range d.rows[0] {
+		c := d.columns[value.Column()]
+		if c == nil {
+			continue
+		}
+
+		f := v.FieldByIndex(c.index)
+		switch c.kind {
+		case kindInt:
+			f.SetInt(value.Int64())
+		case kindUint:
+			f.SetUint(uint64(value.Int64()))
+		case kindFloat:
+			f.SetFloat(value.Double())
+		case kindBool:
+			f.SetBool(value.Boolean())
+		default:
+			if err := c.parse(v, string(value.ByteArray())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
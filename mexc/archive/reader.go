@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+)
+
+// Reader reads records of type T back from a file written by Writer, the format
+// and compression are detected from the file extension. Columns unknown to T are
+// ignored and fields missing from the file keep their zero value.
+type Reader[T any] struct {
+	file   *os.File
+	stream io.ReadCloser
+	dec    decoder
+}
+
+func OpenReader[T any](path string) (*Reader[T], error) {
+	format, compression, err := detect(path)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := schemaOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader[T]{file: f}
+
+	if format == Parquet {
+		r.stream = io.NopCloser(f)
+		r.dec, err = newParquetDecoder(f, columns)
+	} else if r.stream, err = decompressReader(f, compression); err == nil {
+		if format == CSV {
+			r.dec, err = newCSVDecoder(r.stream, columns)
+		} else {
+			r.dec = &jsonlDecoder{dec: json.NewDecoder(r.stream)}
+		}
+	}
+
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Read returns the next record, or io.EOF after the last one.
+func (r *Reader[T]) Read() (*T, error) {
+	v := new(T)
+	if err := r.dec.decode(reflect.ValueOf(v).Elem()); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (r *Reader[T]) Close() error {
+	var err error
+	if r.stream != nil {
+		err = r.stream.Close()
+	}
+
+	return errors.Join(err, r.file.Close())
+}
+
+// ReadFile returns all the records of a file.
+func ReadFile[T any](path string) ([]*T, error) {
+	r, err := OpenReader[T](path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var ret []*T
+	for {
+		v, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, v)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+var ErrUnsupportedType = errors.New("records must be structs")
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindUint
+	kindFloat
+	kindBool
+	// decimals are stored as text to stay exact
+	kindDecimal
+	// slices, maps, structs and pointers are stored as JSON text
+	kindJSON
+)
+
+var decimalType = reflect.TypeOf(decimal.Decimal{})
+
+// column is a field of a record, named after its json tag.
+type column struct {
+	name  string
+	index []int
+	kind  kind
+}
+
+// schemaOf returns the columns of the struct type t in field order, the fields of
+// embedded structs are inlined like encoding/json does.
+func schemaOf(t reflect.Type) ([]*column, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+
+	var ret []*column
+	seen := make(map[string]bool)
+
+	var walk func(t reflect.Type, prefix []int)
+	walk = func(t reflect.Type, prefix []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			index := append(append([]int{}, prefix...), i)
+
+			if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Type != decimalType {
+				walk(f.Type, index)
+				continue
+			}
+
+			if !f.IsExported() {
+				continue
+			}
+
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+
+			// names differing only by case, like m and M of AggTrade, collide in case
+			// insensitive tools, the later field is named after its Go name instead
+			if seen[strings.ToLower(name)] {
+				name = f.Name
+			}
+			seen[strings.ToLower(name)] = true
+
+			ret = append(ret, &column{name: name, index: index, kind: kindOf(f.Type)})
+		}
+	}
+	walk(t, nil)
+
+	return ret, nil
+}
+
+func kindOf(t reflect.Type) kind {
+	if t == decimalType {
+		return kindDecimal
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return kindString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return kindInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return kindUint
+	case reflect.Float32, reflect.Float64:
+		return kindFloat
+	case reflect.Bool:
+		return kindBool
+	default:
+		return kindJSON
+	}
+}
+
+// format returns the text of the column of record v.
+func (c *column) format(v reflect.Value) (string, error) {
+	f := v.FieldByIndex(c.index)
+
+	switch c.kind {
+	case kindString:
+		return f.String(), nil
+	case kindInt:
+		return strconv.FormatInt(f.Int(), 10), nil
+	case kindUint:
+		return strconv.FormatUint(f.Uint(), 10), nil
+	case kindFloat:
+		return strconv.FormatFloat(f.Float(), 'f', -1, 64), nil
+	case kindBool:
+		return strconv.FormatBool(f.Bool()), nil
+	case kindDecimal:
+		return f.Interface().(decimal.Decimal).String(), nil
+	default:
+		data, err := json.Marshal(f.Interface())
+		return string(data), err
+	}
+}
+
+// parse sets the column of record v from its text.
+func (c *column) parse(v reflect.Value, s string) error {
+	f := v.FieldByIndex(c.index)
+
+	var err error
+	switch c.kind {
+	case kindString:
+		f.SetString(s)
+	case kindInt:
+		var n int64
+		if n, err = strconv.ParseInt(s, 10, 64); err == nil {
+			f.SetInt(n)
+		}
+	case kindUint:
+		var n uint64
+		if n, err = strconv.ParseUint(s, 10, 64); err == nil {
+			f.SetUint(n)
+		}
+	case kindFloat:
+		var n float64
+		if n, err = strconv.ParseFloat(s, 64); err == nil {
+			f.SetFloat(n)
+		}
+	case kindBool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			f.SetBool(b)
+		}
+	case kindDecimal:
+		var d decimal.Decimal
+		if d, err = decimal.Parse(s); err == nil {
+			f.Set(reflect.ValueOf(d))
+		}
+	default:
+		err = json.Unmarshal([]byte(s), f.Addr().Interface())
+	}
+
+	if err != nil {
+		return fmt.Errorf("column %s: %w", c.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package archive writes market data records to CSV, JSON Lines and Parquet files
+// and reads them back. Records are the structs of the marketdata types packages, or
+// any struct with json tags: columns are named after the json tags in field order,
+// decimals are stored as text to stay exact and nested values as JSON text, so the
+// schema of a type only changes when the type does.
+package archive
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown archive format")
+	ErrClosed        = errors.New("archive writer is closed")
+)
+
+type WriterCfg struct {
+	// Dir is the directory of the files, it is created if needed
+	Dir string `validate:"required"`
+	// Prefix starts the name of every file, followed by the time the file was
+	// opened and a sequence number, like klines-20240102T150405Z-0001.csv.gz
+	Prefix      string      `validate:"required"`
+	Format      Format      `validate:"required,oneof=csv jsonl parquet"`
+	Compression Compression `validate:"omitempty,oneof=gzip zstd"`
+	// MaxBytes rotates the file once this many bytes were written to it, zero
+	// disables rotation by size. Compressed and Parquet files grow by blocks so a
+	// file may exceed MaxBytes by a block.
+	MaxBytes int64 `validate:"gte=0"`
+	// MaxAge rotates the file once it was opened for this long, zero disables
+	// rotation by time
+	MaxAge time.Duration `validate:"gte=0"`
+}
+
+// Writer appends records of type T to rotated files. Writer is safe for concurrent
+// use.
+type Writer[T any] struct {
+	cfg     WriterCfg
+	columns []*column
+
+	mu     sync.Mutex
+	file   *os.File
+	buf    *bufio.Writer
+	count  *countingWriter
+	stream io.WriteCloser
+	enc    encoder
+	opened time.Time
+	seq    int
+	files  []string
+	closed bool
+}
+
+func NewWriter[T any](cfg *WriterCfg) (*Writer[T], error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := schemaOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Writer[T]{cfg: *cfg, columns: columns}, nil
+}
+
+// Write appends records to the current file, rotating it first if needed. Files are
+// only opened by Write, so that no empty file is left behind.
+func (w *Writer[T]) Write(records ...*T) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+
+	for _, v := range records {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+
+		if err := w.enc.encode(reflect.ValueOf(v).Elem()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rotate closes the current file, the next Write opens a new one.
+func (w *Writer[T]) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.closeFile()
+}
+
+// Close closes the current file, Write fails afterwards.
+func (w *Writer[T]) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	return w.closeFile()
+}
+
+// Files returns the paths of the files opened so far, in order.
+func (w *Writer[T]) Files() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]string{}, w.files...)
+}
+
+// rotate opens a file if none is open, or if the open one reached its size or age.
+func (w *Writer[T]) rotate() error {
+	if w.file != nil {
+		full := w.cfg.MaxBytes > 0 && w.count.n >= w.cfg.MaxBytes
+		old := w.cfg.MaxAge > 0 && time.Since(w.opened) >= w.cfg.MaxAge
+		if !full && !old {
+			return nil
+		}
+
+		if err := w.closeFile(); err != nil {
+			return err
+		}
+	}
+
+	w.seq++
+	w.opened = time.Now()
+	name := fmt.Sprintf("%s-%s-%04d%s", w.cfg.Prefix, w.opened.UTC().Format("20060102T150405Z"), w.seq, ext(w.cfg.Format, w.cfg.Compression))
+	path := filepath.Join(w.cfg.Dir, name)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w.file = f
+	w.buf = bufio.NewWriter(f)
+	w.count = &countingWriter{w: w.buf}
+	w.files = append(w.files, path)
+
+	w.stream, err = compressWriter(w.count, w.cfg.Format, w.cfg.Compression)
+	if err == nil {
+		w.enc, err = newEncoder(w.stream, w.cfg.Format, w.cfg.Compression, w.columns)
+	}
+	if err != nil {
+		w.enc = nil
+		_ = w.closeFile()
+		return err
+	}
+
+	return nil
+}
+
+func (w *Writer[T]) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+
+	var err error
+	if w.enc != nil {
+		err = w.enc.close()
+	}
+	if w.stream != nil {
+		err = errors.Join(err, w.stream.Close())
+	}
+	err = errors.Join(err, w.buf.Flush(), w.file.Close())
+	w.file, w.buf, w.count, w.stream, w.enc = nil, nil, nil, nil, nil
+
+	return err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
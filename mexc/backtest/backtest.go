@@ -0,0 +1,220 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package backtest runs trading strategies against historical klines and aggregate
+// trades. Strategies place orders through OrderClient, which SpotAccountClient
+// implements too, and a simulated Exchange fills them with the fee rates, latency and
+// slippage configured. A run returns the trade log, the equity curve and the metrics
+// of the strategy.
+package backtest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+	"github.com/jl1/nexapi/utils/candle"
+)
+
+const (
+	DefaultEquityAsset    = "USDT"
+	DefaultEquityInterval = time.Hour
+)
+
+var ErrNoData = errors.New("no market data")
+
+// Event is a market data event, either a closed candle or an aggregate trade.
+type Event struct {
+	Symbol string
+	// Time in milliseconds, the close time of candles
+	Time   int64
+	Candle *candle.Candle
+	Trade  *spottypes.AggTrade
+}
+
+type Strategy interface {
+	// OnEvent is called for every event, after the event was matched against the open
+	// orders. An error stops the backtest.
+	OnEvent(ctx context.Context, cli OrderClient, e *Event) error
+}
+
+// StrategyFunc adapts a function to Strategy.
+type StrategyFunc func(ctx context.Context, cli OrderClient, e *Event) error
+
+func (f StrategyFunc) OnEvent(ctx context.Context, cli OrderClient, e *Event) error {
+	return f(ctx, cli, e)
+}
+
+type EngineCfg struct {
+	// Logger
+	Logger *slog.Logger
+
+	Exchange *Exchange `validate:"required"`
+	Strategy Strategy  `validate:"required"`
+	// Candles and Trades are the market data by symbol, see LoadKlines and
+	// LoadAggTrades. A symbol should have either candles or trades, not both.
+	Candles map[string][]*candle.Candle
+	Trades  map[string][]*spottypes.AggTrade
+	// EquityAsset is the asset the equity is valued in, defaults to DefaultEquityAsset
+	EquityAsset string
+	// EquityInterval is the sampling interval of the equity curve, defaults to
+	// DefaultEquityInterval
+	EquityInterval time.Duration `validate:"gte=0"`
+}
+
+type Result struct {
+	Fills   []*Fill
+	Equity  []*EquityPoint
+	Metrics Metrics
+}
+
+// Engine replays the market data in time order to a strategy and the exchange.
+type Engine struct {
+	logger         *slog.Logger
+	exchange       *Exchange
+	strategy       Strategy
+	events         []*Event
+	equityAsset    string
+	equityInterval time.Duration
+}
+
+func NewEngine(cfg *EngineCfg) (*Engine, error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		logger:         cfg.Logger,
+		exchange:       cfg.Exchange,
+		strategy:       cfg.Strategy,
+		equityAsset:    cfg.EquityAsset,
+		equityInterval: cfg.EquityInterval,
+	}
+
+	if e.logger == nil {
+		e.logger = slog.Default()
+	}
+
+	if e.equityAsset == "" {
+		e.equityAsset = DefaultEquityAsset
+	}
+
+	if e.equityInterval == 0 {
+		e.equityInterval = DefaultEquityInterval
+	}
+
+	e.events = mergeEvents(cfg.Candles, cfg.Trades)
+	if len(e.events) == 0 {
+		return nil, ErrNoData
+	}
+
+	return e, nil
+}
+
+// mergeEvents returns the events of all the symbols sorted by time, events at the
+// same time are ordered by symbol.
+func mergeEvents(candles map[string][]*candle.Candle, trades map[string][]*spottypes.AggTrade) []*Event {
+	var ret []*Event
+
+	for k, v := range candles {
+		for _, c := range v {
+			ret = append(ret, &Event{Symbol: k, Time: c.CloseTime, Candle: c})
+		}
+	}
+
+	for k, v := range trades {
+		for _, t := range v {
+			ret = append(ret, &Event{Symbol: k, Time: t.T, Trade: t})
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Time != ret[j].Time {
+			return ret[i].Time < ret[j].Time
+		}
+		return ret[i].Symbol < ret[j].Symbol
+	})
+
+	return ret
+}
+
+// Run replays the events once, the exchange keeps its state afterwards. The equity
+// is sampled at every interval boundary, the last equity is carried forward through
+// intervals without events, and a final partial interval ends with a Partial point.
+func (e *Engine) Run(ctx context.Context) (*Result, error) {
+	var equity []*EquityPoint
+	interval := max(e.equityInterval.Milliseconds(), 1)
+	bucket := e.events[0].Time / interval
+
+	for i, v := range e.events {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		for b := v.Time / interval; bucket < b; bucket++ {
+			equity = append(equity, &EquityPoint{Time: (bucket + 1) * interval, Equity: e.exchange.Equity(e.equityAsset)})
+		}
+
+		if v.Candle != nil {
+			e.exchange.ApplyCandle(v.Symbol, v.Candle)
+		} else {
+			e.exchange.ApplyTrade(v.Symbol, v.Trade)
+		}
+
+		// the initial equity is valued at the first prices
+		if i == 0 {
+			equity = append(equity, &EquityPoint{Time: bucket * interval, Equity: e.exchange.Equity(e.equityAsset)})
+		}
+
+		if err := e.strategy.OnEvent(ctx, e.exchange, v); err != nil {
+			return nil, err
+		}
+	}
+
+	last := &EquityPoint{Time: (bucket + 1) * interval, Equity: e.exchange.Equity(e.equityAsset)}
+	if end := e.events[len(e.events)-1].Time; end < last.Time-1 {
+		last.Time, last.Partial = end, true
+	}
+	equity = append(equity, last)
+
+	ret := &Result{
+		Fills:   e.exchange.Fills(),
+		Equity:  equity,
+		Metrics: ComputeMetrics(equity, e.equityInterval),
+	}
+
+	ret.Metrics.Fills = len(ret.Fills)
+	for _, f := range ret.Fills {
+		if f.FeeAsset == e.equityAsset {
+			ret.Metrics.Fees = ret.Metrics.Fees.Add(f.Fee)
+		} else if f.Symbol == f.FeeAsset+e.equityAsset {
+			ret.Metrics.Fees = ret.Metrics.Fees.Add(f.Fee.Mul(f.Price))
+		}
+	}
+
+	e.logger.Info("backtest done", "events", len(e.events), "fills", ret.Metrics.Fills,
+		"return", ret.Metrics.Return, "sharpe", ret.Metrics.Sharpe, "max_drawdown", ret.Metrics.MaxDrawdown)
+
+	return ret, nil
+}
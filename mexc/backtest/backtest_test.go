@@ -0,0 +1,341 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jl1/nexapi/mexc/orderbook"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	"github.com/jl1/nexapi/mexc/spot/spotaccount"
+	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
+	spotwstypes "github.com/jl1/nexapi/mexc/spot/websocketmarket/types"
+	"github.com/jl1/nexapi/utils/candle"
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ OrderClient = (*spotaccount.SpotAccountClient)(nil)
+
+var d = decimal.MustParse
+
+func ptr(s string) *decimal.Decimal {
+	v := d(s)
+	return &v
+}
+
+func testFee(maker, taker string) *types.TradeFee {
+	var ret types.TradeFee
+	ret.Data.MakerCommission, ret.Data.TakerCommission = d(maker), d(taker)
+	return &ret
+}
+
+func trade(ts int64, price, qty string) *spottypes.AggTrade {
+	return &spottypes.AggTrade{T: ts, P: d(price), Q: d(qty)}
+}
+
+func balances(t *testing.T, x *Exchange) map[string][2]string {
+	info, err := x.GetAccountInfo(context.TODO())
+	assert.Nil(t, err)
+
+	ret := make(map[string][2]string)
+	for _, v := range info.Balances {
+		ret[v.Asset] = [2]string{v.Free.String(), v.Locked.String()}
+	}
+	return ret
+}
+
+func TestExchange(t *testing.T) {
+	ctx := context.TODO()
+
+	x, err := NewExchange(&ExchangeCfg{
+		Balances: map[string]decimal.Decimal{"USDT": d("1000")},
+		Fee:      testFee("0.001", "0.002"),
+		Latency:  FixedLatency(10 * time.Millisecond),
+		Slippage: FixedSlippage{Bps: d("10")},
+	})
+	assert.Nil(t, err)
+
+	x.ApplyTrade("BTCUSDT", trade(1000, "100", "1"))
+
+	// a resting buy locks its funds and fills as a maker, up to the traded quantity
+	resp, err := x.CreateOrder(ctx, types.CreateOrderParam{Symbol: "BTCUSDT", Side: types.BuySide, Type: types.LimitOrder, Price: ptr("99"), Quantity: ptr("2")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][2]string{"USDT": {"802", "198"}}, balances(t, x))
+
+	// the order did not reach the exchange yet
+	x.ApplyTrade("BTCUSDT", trade(1005, "98", "5"))
+	x.ApplyTrade("BTCUSDT", trade(1010, "99.5", "5"))
+	x.ApplyTrade("BTCUSDT", trade(1020, "98.5", "1.5"))
+
+	o, err := x.QueryOrder(ctx, types.QueryOrderParam{Symbol: "BTCUSDT", OrderID: resp.OrderID})
+	assert.Nil(t, err)
+	assert.Equal(t, types.PartiallyFilledStatus, o.Status)
+	assert.Equal(t, "1.5", o.ExecutedQty.String())
+
+	x.ApplyTrade("BTCUSDT", trade(1030, "98", "3"))
+	o, _ = x.QueryOrder(ctx, types.QueryOrderParam{Symbol: "BTCUSDT", OrderID: resp.OrderID})
+	assert.Equal(t, types.FilledStatus, o.Status)
+	assert.False(t, o.IsWorking)
+
+	fills := x.Fills()
+	if assert.Len(t, fills, 2) {
+		assert.True(t, fills[0].Maker)
+		assert.True(t, fills[0].Price.Equal(d("99")))
+		assert.True(t, fills[0].Fee.Equal(d("0.0015")))
+		assert.Equal(t, "BTC", fills[0].FeeAsset)
+	}
+	b := balances(t, x)
+	assert.True(t, d(b["BTC"][0]).Equal(d("1.998")))
+	assert.True(t, d(b["USDT"][0]).Equal(d("802")))
+
+	// a market sell takes the trade price moved by the slippage
+	_, err = x.CreateOrder(ctx, types.CreateOrderParam{Symbol: "BTCUSDT", Side: types.SellSide, Type: types.MarketOrder, Quantity: ptr("1")})
+	assert.Nil(t, err)
+	x.ApplyTrade("BTCUSDT", trade(1040, "100", "1"))
+
+	fills = x.Fills()
+	if assert.Len(t, fills, 3) {
+		assert.False(t, fills[2].Maker)
+		assert.True(t, fills[2].Price.Equal(d("99.9")))
+		assert.True(t, fills[2].Fee.Equal(d("0.1998")))
+	}
+	assert.True(t, x.Equity("USDT").Equal(d("802").Add(d("99.9").Sub(d("0.1998"))).Add(d("0.998").Mul(d("100")))))
+
+	// maker-only orders which would take liquidity are canceled
+	resp, err = x.CreateOrder(ctx, types.CreateOrderParam{Symbol: "BTCUSDT", Side: types.BuySide, Type: types.LimitMakerOrder, Price: ptr("101"), Quantity: ptr("1")})
+	assert.Nil(t, err)
+	x.ApplyTrade("BTCUSDT", trade(1050, "100", "1"))
+	o, _ = x.QueryOrder(ctx, types.QueryOrderParam{Symbol: "BTCUSDT", OrderID: resp.OrderID})
+	assert.Equal(t, types.CanceledStatus, o.Status)
+	assert.Len(t, x.Fills(), 3)
+
+	// cancellations take effect after the latency
+	resp, err = x.CreateOrder(ctx, types.CreateOrderParam{Symbol: "BTCUSDT", Side: types.SellSide, Type: types.LimitOrder, Price: ptr("105"), Quantity: ptr("0.5")})
+	assert.Nil(t, err)
+	x.ApplyTrade("BTCUSDT", trade(1060, "100", "1"))
+	o, err = x.CancelOrder(ctx, types.CancelOrderParam{Symbol: "BTCUSDT", OrderID: resp.OrderID})
+	assert.Nil(t, err)
+	assert.Equal(t, types.NewStatus, o.Status)
+	x.ApplyTrade("BTCUSDT", trade(1080, "100", "1"))
+	o, _ = x.QueryOrder(ctx, types.QueryOrderParam{Symbol: "BTCUSDT", OrderID: resp.OrderID})
+	assert.Equal(t, types.CanceledStatus, o.Status)
+	assert.True(t, d(balances(t, x)["BTC"][1]).IsZero())
+
+	_, err = x.CreateOrder(ctx, types.CreateOrderParam{Symbol: "BTCUSDT", Side: types.BuySide, Type: types.LimitOrder, Price: ptr("100"), Quantity: ptr("100")})
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+
+	_, err = x.CreateOrder(ctx, types.CreateOrderParam{Symbol: "BTCUSDT", Side: types.BuySide, Type: types.MarketOrder})
+	assert.ErrorIs(t, err, ErrInvalidOrder)
+
+	_, err = x.QueryOrder(ctx, types.QueryOrderParam{Symbol: "BTCUSDT", OrderID: "nope"})
+	assert.ErrorIs(t, err, ErrUnknownOrder)
+
+	fee, err := x.GetTradeFee(ctx, types.GetTradeFeeParam{Symbol: "ETHUSDT"})
+	assert.Nil(t, err)
+	assert.Equal(t, "0.002", fee.Data.TakerCommission.String())
+}
+
+func TestExchangeBook(t *testing.T) {
+	ctx := context.TODO()
+
+	x, err := NewExchange(&ExchangeCfg{Balances: map[string]decimal.Decimal{"USDT": d("1000")}})
+	assert.Nil(t, err)
+
+	book := &orderbook.Book{}
+	book.SetBid(d("99"), d("1"))
+	book.SetAsk(d("100"), d("1"))
+	book.SetAsk(d("101"), d("2"))
+
+	// takers walk the levels
+	_, err = x.CreateOrder(ctx, types.CreateOrderParam{Symbol: "BTCUSDT", Side: types.BuySide, Type: types.MarketOrder, QuoteOrderQty: ptr("201")})
+	assert.Nil(t, err)
+	x.ApplyBook("BTCUSDT", 1000, book)
+
+	fills := x.Fills()
+	if assert.Len(t, fills, 1) {
+		assert.True(t, fills[0].Qty.Equal(d("2")))
+		assert.True(t, fills[0].Price.Equal(d("100.5")))
+	}
+
+	// a marketable limit takes the levels up to its price and rests for the rest
+	resp, err := x.CreateOrder(ctx, types.CreateOrderParam{Symbol: "BTCUSDT", Side: types.BuySide, Type: types.LimitOrder, Price: ptr("100.5"), Quantity: ptr("3")})
+	assert.Nil(t, err)
+	x.ApplyBook("BTCUSDT", 1010, book)
+
+	o, _ := x.QueryOrder(ctx, types.QueryOrderParam{Symbol: "BTCUSDT", OrderID: resp.OrderID})
+	assert.Equal(t, types.PartiallyFilledStatus, o.Status)
+	assert.True(t, o.ExecutedQty.Equal(d("1")))
+
+	x.ApplyBookTicker(&spotwstypes.BookTicker{Symbol: "BTCUSDT", EventTime: 1020, BidPrice: d("100"), BidQty: d("1"), AskPrice: d("100.4"), AskQty: d("5")})
+
+	o, _ = x.QueryOrder(ctx, types.QueryOrderParam{Symbol: "BTCUSDT", OrderID: resp.OrderID})
+	assert.Equal(t, types.FilledStatus, o.Status)
+	fills = x.Fills()
+	if assert.Len(t, fills, 3) {
+		assert.True(t, fills[2].Maker)
+		assert.True(t, fills[2].Qty.Equal(d("2")))
+	}
+
+	// the state survives a restart
+	state, err := x.MarshalState()
+	assert.Nil(t, err)
+
+	restored, err := NewExchange(&ExchangeCfg{State: state})
+	assert.Nil(t, err)
+	assert.Equal(t, balances(t, x), balances(t, restored))
+	assert.Len(t, restored.Fills(), 3)
+
+	resp, err = restored.CreateOrder(ctx, types.CreateOrderParam{Symbol: "BTCUSDT", Side: types.SellSide, Type: types.LimitOrder, Price: ptr("110"), Quantity: ptr("1")})
+	assert.Nil(t, err)
+	assert.Equal(t, "3", resp.OrderID)
+}
+
+func TestEngine(t *testing.T) {
+	hour := time.Hour.Milliseconds()
+	closes := []string{"100", "110", "99", "121"}
+
+	var candles []*candle.Candle
+	for i, v := range closes {
+		open := d("100")
+		if i > 0 {
+			open = d(closes[i-1])
+		}
+		candles = append(candles, &candle.Candle{
+			OpenTime:  int64(i) * hour,
+			CloseTime: int64(i+1)*hour - 1,
+			Open:      open,
+			High:      decimal.Max(open, d(v)),
+			Low:       decimal.Min(open, d(v)),
+			Close:     d(v),
+		})
+	}
+
+	x, err := NewExchange(&ExchangeCfg{Balances: map[string]decimal.Decimal{"USDT": d("100")}})
+	assert.Nil(t, err)
+
+	// buys with everything on the first candle
+	var placed bool
+	strategy := StrategyFunc(func(ctx context.Context, cli OrderClient, e *Event) error {
+		if placed {
+			return nil
+		}
+		placed = true
+
+		_, err := cli.CreateOrder(ctx, types.CreateOrderParam{Symbol: e.Symbol, Side: types.BuySide, Type: types.MarketOrder, QuoteOrderQty: ptr("100")})
+		return err
+	})
+
+	engine, err := NewEngine(&EngineCfg{
+		Exchange: x,
+		Strategy: strategy,
+		Candles:  map[string][]*candle.Candle{"BTCUSDT": candles},
+	})
+	assert.Nil(t, err)
+
+	result, err := engine.Run(context.TODO())
+	assert.Nil(t, err)
+
+	// the market order fills at the open of the second candle
+	if assert.Len(t, result.Fills, 1) {
+		assert.True(t, result.Fills[0].Price.Equal(d("100")))
+		assert.True(t, result.Fills[0].Qty.Equal(d("1")))
+	}
+
+	// sampled at the hours, the last candle closes at the end of its hour
+	var equity []float64
+	for i, v := range result.Equity {
+		assert.Equal(t, int64(i)*hour, v.Time)
+		assert.False(t, v.Partial)
+		equity = append(equity, v.Equity.Float64())
+	}
+	assert.Equal(t, []float64{100, 100, 110, 99, 121}, equity)
+
+	m := result.Metrics
+	assert.InDelta(t, 0.21, m.Return, 1e-9)
+	assert.InDelta(t, 0.1, m.MaxDrawdown, 1e-9)
+	assert.Greater(t, m.Sharpe, 0.0)
+	assert.Equal(t, 1, m.Fills)
+
+	// without the third candle the equity is carried forward over its hour, with
+	// three hour intervals the last hour is a partial interval
+	candles = append(candles[:2], candles[3])
+	run := func(interval time.Duration) []*EquityPoint {
+		x, err := NewExchange(&ExchangeCfg{Balances: map[string]decimal.Decimal{"USDT": d("100")}})
+		assert.Nil(t, err)
+		placed = false
+
+		engine, err := NewEngine(&EngineCfg{
+			Exchange:       x,
+			Strategy:       strategy,
+			Candles:        map[string][]*candle.Candle{"BTCUSDT": candles},
+			EquityInterval: interval,
+		})
+		assert.Nil(t, err)
+
+		result, err := engine.Run(context.TODO())
+		assert.Nil(t, err)
+		return result.Equity
+	}
+
+	equity = nil
+	for _, v := range run(time.Hour) {
+		equity = append(equity, v.Equity.Float64())
+	}
+	assert.Equal(t, []float64{100, 100, 110, 110, 121}, equity)
+
+	if points := run(3 * time.Hour); assert.Len(t, points, 3) {
+		assert.Equal(t, 3*hour, points[1].Time)
+		assert.True(t, points[1].Equity.Equal(d("110")))
+		assert.Equal(t, 4*hour-1, points[2].Time)
+		assert.True(t, points[2].Partial)
+	}
+
+	_, err = NewEngine(&EngineCfg{Exchange: x, Strategy: strategy})
+	assert.ErrorIs(t, err, ErrNoData)
+}
+
+func TestComputeMetrics(t *testing.T) {
+	m := ComputeMetrics([]*EquityPoint{
+		{Time: 0, Equity: d("100")},
+		{Time: 1, Equity: d("120")},
+		{Time: 2, Equity: d("90")},
+		{Time: 3, Equity: d("108")},
+	}, 24*time.Hour)
+
+	assert.InDelta(t, 0.08, m.Return, 1e-9)
+	assert.InDelta(t, 0.25, m.MaxDrawdown, 1e-9)
+	// returns 0.2, -0.25 and 0.2
+	assert.InDelta(t, 0.05/0.2598076211*19.104973174, m.Sharpe, 1e-6)
+
+	// a partial last point changes the return but not the Sharpe ratio
+	partial := ComputeMetrics([]*EquityPoint{
+		{Time: 0, Equity: d("100")},
+		{Time: 1, Equity: d("120")},
+		{Time: 2, Equity: d("90")},
+		{Time: 3, Equity: d("108")},
+		{Time: 4, Equity: d("54"), Partial: true},
+	}, 24*time.Hour)
+	assert.InDelta(t, -0.46, partial.Return, 1e-9)
+	assert.InDelta(t, 0.55, partial.MaxDrawdown, 1e-9)
+	assert.InDelta(t, m.Sharpe, partial.Sharpe, 1e-9)
+
+	assert.Equal(t, Metrics{}, ComputeMetrics(nil, time.Hour))
+}
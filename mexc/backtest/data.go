@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backtest
+
+import (
+	"context"
+
+	"github.com/jl1/nexapi/mexc/history"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
+	"github.com/jl1/nexapi/utils/candle"
+)
+
+// LoadKlines returns the klines opened between start and end in milliseconds, see
+// history.NewSpotKlineFetcher. The gaps of the history are left as they are, the
+// exchange does not match orders during them.
+func LoadKlines(ctx context.Context, b *history.KlineBackfiller, start, end int64) ([]*candle.Candle, error) {
+	var ret []*candle.Candle
+	err := b.Backfill(ctx, start, end, func(chunk *history.KlineChunk) error {
+		ret = append(ret, chunk.Klines...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// LoadAggTrades returns the aggregate trades of symbol between start and end in
+// milliseconds.
+func LoadAggTrades(ctx context.Context, d *history.AggTradeDownloader, symbol string, start, end int64) ([]*spottypes.AggTrade, error) {
+	var ret []*spottypes.AggTrade
+	err := d.Download(ctx, symbol, start, end, func(chunk *history.AggTradeChunk) error {
+		ret = append(ret, chunk.Trades...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// FetchTradeFees returns the fee rates of the account for symbols, to backtest with
+// the fees paid live, see ExchangeCfg.Fees.
+func FetchTradeFees(ctx context.Context, cli OrderClient, symbols ...string) (map[string]*types.TradeFee, error) {
+	ret := make(map[string]*types.TradeFee, len(symbols))
+	for _, v := range symbols {
+		fee, err := cli.GetTradeFee(ctx, types.GetTradeFeeParam{Symbol: v})
+		if err != nil {
+			return nil, err
+		}
+		ret[v] = fee
+	}
+
+	return ret, nil
+}
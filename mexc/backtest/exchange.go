@@ -0,0 +1,597 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jl1/nexapi/mexc/instruments"
+	"github.com/jl1/nexapi/mexc/rules"
+	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+const (
+	// qtyPlaces is the precision of the quantities derived from a quote quantity or a
+	// balance, when the symbol has no trading rules
+	qtyPlaces = 8
+	// divPlaces is the precision of the other divisions
+	divPlaces = 12
+)
+
+var (
+	ErrInvalidOrder        = errors.New("invalid order")
+	ErrUnknownOrder        = errors.New("unknown order")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrNoPrice             = errors.New("no market price for symbol")
+)
+
+// OrderClient is the order interface of spotaccount.SpotAccountClient, a strategy
+// written against it runs unchanged in a backtest and live.
+type OrderClient interface {
+	CreateOrder(ctx context.Context, param types.CreateOrderParam) (*types.CreateOrderResp, error)
+	CancelOrder(ctx context.Context, param types.CancelOrderParam) (*types.Order, error)
+	QueryOrder(ctx context.Context, param types.QueryOrderParam) (*types.Order, error)
+	GetTradeFee(ctx context.Context, param types.GetTradeFeeParam) (*types.TradeFee, error)
+	GetAccountInfo(ctx context.Context) (*types.AccountInfo, error)
+}
+
+// Fill is an execution of an order, the trade log of a backtest.
+type Fill struct {
+	// Time in milliseconds
+	Time     int64           `json:"time"`
+	Symbol   string          `json:"symbol"`
+	OrderID  string          `json:"orderId"`
+	Side     string          `json:"side"`
+	Type     string          `json:"type"`
+	Price    decimal.Decimal `json:"price"`
+	Qty      decimal.Decimal `json:"qty"`
+	QuoteQty decimal.Decimal `json:"quoteQty"`
+	// Fee is paid in the received asset, the base asset for buys and the quote asset
+	// for sells
+	Fee      decimal.Decimal `json:"fee"`
+	FeeAsset string          `json:"feeAsset"`
+	Maker    bool            `json:"maker"`
+}
+
+type ExchangeCfg struct {
+	// Logger
+	Logger *slog.Logger
+
+	// Balances are the initial free balances by asset
+	Balances map[string]decimal.Decimal
+	// State restores the state returned by MarshalState, Balances is ignored then
+	State []byte
+	// Fee is the fee of the symbols missing from Fees, as returned by GetTradeFee.
+	// Orders are free when both are unset.
+	Fee  *types.TradeFee
+	Fees map[string]*types.TradeFee
+	// Latency defaults to no latency
+	Latency LatencyModel
+	// Slippage defaults to NoSlippage, it does not apply to the orders matched
+	// against a book, which fill at the prices of the levels they take
+	Slippage SlippageModel
+	// Rules rejects the orders violating the trading rules like SpotAccountClient
+	// does, and rounds the derived quantities to the step sizes
+	Rules rules.Provider
+}
+
+type balance struct {
+	Free   decimal.Decimal `json:"free"`
+	Locked decimal.Decimal `json:"locked"`
+}
+
+type order struct {
+	types.Order
+
+	Base  string `json:"base"`
+	Quote string `json:"quote"`
+	// Arrival is the time the order reaches the exchange, CancelAt the time its
+	// cancellation does, zero when not canceled
+	Arrival  int64 `json:"arrival"`
+	CancelAt int64 `json:"cancelAt,omitempty"`
+	// Active is set once the order was matched on arrival, it rests on the book after
+	Active bool `json:"active"`
+	// Locked is the part of the balance of LockAsset reserved by the order
+	Locked    decimal.Decimal `json:"locked"`
+	LockAsset string          `json:"lockAsset"`
+}
+
+func (o *order) isOpen() bool {
+	return o.Status == types.NewStatus || o.Status == types.PartiallyFilledStatus
+}
+
+// exchangeState is the persisted state of an Exchange.
+type exchangeState struct {
+	Now      int64                      `json:"now"`
+	Seq      int64                      `json:"seq"`
+	Balances map[string]*balance        `json:"balances"`
+	Orders   []*order                   `json:"orders"`
+	Last     map[string]decimal.Decimal `json:"last"`
+	Fills    []*Fill                    `json:"fills"`
+}
+
+// Exchange simulates the spot order API against market data. Orders are matched when
+// the market data is applied: takers fill at once at the market price moved by the
+// slippage model, or walk the levels of a book, and limit orders rest until the
+// market trades through their price and fill at it as makers. Exchange is safe for
+// concurrent use.
+type Exchange struct {
+	logger   *slog.Logger
+	fee      *types.TradeFee
+	fees     map[string]*types.TradeFee
+	latency  LatencyModel
+	slippage SlippageModel
+	rules    rules.Provider
+
+	mu       sync.Mutex
+	now      int64
+	seq      int64
+	revision int64
+	balances map[string]*balance
+	orders   map[string]*order
+	// every order and the open ones by symbol, in the order they were created
+	history []*order
+	open    map[string][]*order
+	last    map[string]decimal.Decimal
+	fills   []*Fill
+	onFill  []func(*Fill)
+}
+
+func NewExchange(cfg *ExchangeCfg) (*Exchange, error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &Exchange{
+		logger:   cfg.Logger,
+		fee:      cfg.Fee,
+		fees:     cfg.Fees,
+		latency:  cfg.Latency,
+		slippage: cfg.Slippage,
+		rules:    cfg.Rules,
+		balances: make(map[string]*balance),
+		orders:   make(map[string]*order),
+		open:     make(map[string][]*order),
+		last:     make(map[string]decimal.Decimal),
+	}
+
+	if x.logger == nil {
+		x.logger = slog.Default()
+	}
+
+	if x.latency == nil {
+		x.latency = FixedLatency(0)
+	}
+
+	if x.slippage == nil {
+		x.slippage = NoSlippage{}
+	}
+
+	if cfg.State != nil {
+		if err := x.restore(cfg.State); err != nil {
+			return nil, err
+		}
+		return x, nil
+	}
+
+	for k, v := range cfg.Balances {
+		if v.IsNegative() {
+			return nil, fmt.Errorf("%w: negative %s balance", ErrInsufficientBalance, k)
+		}
+		x.balances[k] = &balance{Free: v}
+	}
+
+	return x, nil
+}
+
+func (x *Exchange) restore(data []byte) error {
+	var state exchangeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	x.now, x.seq, x.fills = state.Now, state.Seq, state.Fills
+
+	for k, v := range state.Balances {
+		x.balances[k] = v
+	}
+
+	for k, v := range state.Last {
+		x.last[k] = v
+	}
+
+	for _, v := range state.Orders {
+		x.orders[v.OrderID] = v
+		x.history = append(x.history, v)
+		if v.isOpen() {
+			x.open[v.Symbol] = append(x.open[v.Symbol], v)
+		}
+	}
+
+	return nil
+}
+
+// MarshalState returns the balances, orders and fills of the exchange, to restore
+// them with ExchangeCfg.State.
+func (x *Exchange) MarshalState() ([]byte, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	return json.Marshal(&exchangeState{
+		Now:      x.now,
+		Seq:      x.seq,
+		Balances: x.balances,
+		Orders:   x.history,
+		Last:     x.last,
+		Fills:    x.fills,
+	})
+}
+
+// Revision is incremented by every change of the orders or balances, so that the
+// state only needs to be saved when it changed.
+func (x *Exchange) Revision() int64 {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	return x.revision
+}
+
+// Now returns the time of the last market data applied, so that the exchange can be
+// used as the clock of a strategy.
+func (x *Exchange) Now() time.Time {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	return time.UnixMilli(x.now)
+}
+
+// OnFill registers fn to be called after every fill. fn must not call the Exchange.
+func (x *Exchange) OnFill(fn func(*Fill)) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.onFill = append(x.onFill, fn)
+}
+
+// Fills returns the fills so far, in order.
+func (x *Exchange) Fills() []*Fill {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	return append([]*Fill{}, x.fills...)
+}
+
+// LastPrice returns the last price of symbol.
+func (x *Exchange) LastPrice(symbol string) (decimal.Decimal, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	v, ok := x.last[symbol]
+	return v, ok
+}
+
+// Equity returns the value of the balances in asset, locked funds included. Assets
+// are valued at the last price of their symbol against asset, the assets without
+// such a price are left out.
+func (x *Exchange) Equity(asset string) decimal.Decimal {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	ret := decimal.Decimal{}
+	for k, v := range x.balances {
+		total := v.Free.Add(v.Locked)
+		if k == asset {
+			ret = ret.Add(total)
+		} else if price, ok := x.last[k+asset]; ok {
+			ret = ret.Add(total.Mul(price))
+		}
+	}
+
+	return ret
+}
+
+func (x *Exchange) balance(asset string) *balance {
+	b, ok := x.balances[asset]
+	if !ok {
+		b = &balance{}
+		x.balances[asset] = b
+	}
+
+	return b
+}
+
+func (x *Exchange) feeRates(symbol string) (maker, taker decimal.Decimal) {
+	fee := x.fees[symbol]
+	if fee == nil {
+		fee = x.fee
+	}
+	if fee == nil {
+		return
+	}
+
+	return fee.Data.MakerCommission, fee.Data.TakerCommission
+}
+
+func (x *Exchange) floorQty(symbol string, qty decimal.Decimal) decimal.Decimal {
+	if x.rules != nil {
+		if r, ok := x.rules.GetSymbolRules(symbol); ok {
+			return r.FloorQty(qty)
+		}
+	}
+
+	return qty.Truncate(qtyPlaces)
+}
+
+// CreateOrder places an order, it reaches the exchange after the latency of the
+// latency model and is matched against the market data applied from then on. The
+// funds of the order are locked at once, market orders sized by the other asset lock
+// an estimate at the last price.
+func (x *Exchange) CreateOrder(_ context.Context, param types.CreateOrderParam) (*types.CreateOrderResp, error) {
+	id, err := instruments.ParseSpotSymbol(param.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkOrder(param); err != nil {
+		return nil, err
+	}
+
+	if x.rules != nil {
+		if err := checkOrderRules(x.rules, param); err != nil {
+			return nil, err
+		}
+	}
+
+	latency := x.latency.Latency().Milliseconds()
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	o := &order{
+		Base:    id.Base,
+		Quote:   id.Quote,
+		Arrival: x.now + latency,
+	}
+	o.Symbol = param.Symbol
+	o.Side = param.Side
+	o.Type = param.Type
+	o.Status = types.NewStatus
+	o.TimeInForce = "GTC"
+	o.Time = x.now
+	o.UpdateTime = x.now
+	if param.Price != nil {
+		o.Price = *param.Price
+	}
+	if param.Quantity != nil {
+		o.OrigQty = *param.Quantity
+	}
+	if param.QuoteOrderQty != nil {
+		o.OrigQuoteOrderQty = *param.QuoteOrderQty
+	}
+
+	lock, err := x.lockAmount(o)
+	if err != nil {
+		return nil, err
+	}
+
+	o.LockAsset = o.Quote
+	if o.Side == types.SellSide {
+		o.LockAsset = o.Base
+	}
+
+	b := x.balance(o.LockAsset)
+	if b.Free.LessThan(lock) {
+		return nil, fmt.Errorf("%w: %s %s needed, %s free", ErrInsufficientBalance, lock, o.LockAsset, b.Free)
+	}
+	b.Free = b.Free.Sub(lock)
+	b.Locked = b.Locked.Add(lock)
+	o.Locked = lock
+
+	x.seq++
+	x.revision++
+	o.OrderID = strconv.FormatInt(x.seq, 10)
+	o.ClientOrderID = o.OrderID
+	x.orders[o.OrderID] = o
+	x.history = append(x.history, o)
+	x.open[o.Symbol] = append(x.open[o.Symbol], o)
+
+	return &types.CreateOrderResp{
+		Symbol:       o.Symbol,
+		OrderID:      o.OrderID,
+		OrderListId:  -1,
+		Price:        o.Price,
+		OrigQty:      o.OrigQty,
+		Type:         o.Type,
+		Side:         o.Side,
+		TransactTime: o.Time,
+	}, nil
+}
+
+// lockAmount returns the funds o needs, in the quote asset for buys and in the base
+// asset for sells.
+func (x *Exchange) lockAmount(o *order) (decimal.Decimal, error) {
+	if o.Type != types.MarketOrder {
+		if o.Side == types.BuySide {
+			return o.Price.Mul(o.OrigQty), nil
+		}
+		return o.OrigQty, nil
+	}
+
+	switch {
+	case o.Side == types.BuySide && o.OrigQuoteOrderQty.IsPositive():
+		return o.OrigQuoteOrderQty, nil
+	case o.Side == types.SellSide && o.OrigQty.IsPositive():
+		return o.OrigQty, nil
+	}
+
+	last, ok := x.last[o.Symbol]
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("%w %s", ErrNoPrice, o.Symbol)
+	}
+
+	if o.Side == types.BuySide {
+		return x.slippage.Price(o.Symbol, o.Side, last, o.OrigQty).Mul(o.OrigQty), nil
+	}
+
+	return x.floorQty(o.Symbol, o.OrigQuoteOrderQty.Div(last, divPlaces)), nil
+}
+
+func checkOrder(param types.CreateOrderParam) error {
+	if param.Side != types.BuySide && param.Side != types.SellSide {
+		return fmt.Errorf("%w: side %q", ErrInvalidOrder, param.Side)
+	}
+
+	positive := func(v *decimal.Decimal) bool {
+		return v != nil && v.IsPositive()
+	}
+
+	switch param.Type {
+	case types.MarketOrder:
+		if positive(param.Quantity) == positive(param.QuoteOrderQty) {
+			return fmt.Errorf("%w: market orders need one of quantity and quote quantity", ErrInvalidOrder)
+		}
+	case types.LimitOrder, types.LimitMakerOrder, types.ImmediateOrCancel, types.FillOrKill:
+		if !positive(param.Price) || !positive(param.Quantity) {
+			return fmt.Errorf("%w: %s orders need a price and a quantity", ErrInvalidOrder, param.Type)
+		}
+	default:
+		return fmt.Errorf("%w: type %q", ErrInvalidOrder, param.Type)
+	}
+
+	return nil
+}
+
+// checkOrderRules rejects an order violating the trading rules of its symbol, like
+// SpotAccountClient.CreateOrder does.
+func checkOrderRules(provider rules.Provider, param types.CreateOrderParam) error {
+	r, ok := provider.GetSymbolRules(param.Symbol)
+	if !ok {
+		return fmt.Errorf("%w %s", rules.ErrUnknownRule, param.Symbol)
+	}
+
+	if param.Price != nil {
+		if err := r.ValidatePrice(*param.Price); err != nil {
+			return err
+		}
+	}
+
+	if param.Quantity != nil {
+		if err := r.ValidateQty(*param.Quantity); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case param.Price != nil && param.Quantity != nil:
+		return r.ValidateNotional(param.Price.Mul(*param.Quantity))
+	case param.QuoteOrderQty != nil:
+		return r.ValidateNotional(*param.QuoteOrderQty)
+	}
+
+	return nil
+}
+
+// CancelOrder cancels an open order once the cancellation reached the exchange, after
+// the latency of the latency model. The order returned is the order at the time of
+// the request, query it later to tell whether it filled in between.
+func (x *Exchange) CancelOrder(_ context.Context, param types.CancelOrderParam) (*types.Order, error) {
+	latency := x.latency.Latency().Milliseconds()
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	o, ok := x.orders[param.OrderID]
+	if !ok || o.Symbol != param.Symbol {
+		return nil, fmt.Errorf("%w %s", ErrUnknownOrder, param.OrderID)
+	}
+
+	if o.isOpen() && o.CancelAt == 0 {
+		o.CancelAt = max(x.now+latency, o.Arrival)
+		x.revision++
+	}
+
+	ret := o.Order
+	return &ret, nil
+}
+
+func (x *Exchange) QueryOrder(_ context.Context, param types.QueryOrderParam) (*types.Order, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	o, ok := x.orders[param.OrderID]
+	if !ok || o.Symbol != param.Symbol {
+		return nil, fmt.Errorf("%w %s", ErrUnknownOrder, param.OrderID)
+	}
+
+	ret := o.Order
+	return &ret, nil
+}
+
+func (x *Exchange) GetTradeFee(_ context.Context, param types.GetTradeFeeParam) (*types.TradeFee, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	var ret types.TradeFee
+	ret.Data.MakerCommission, ret.Data.TakerCommission = x.feeRates(param.Symbol)
+	ret.Msg = "success"
+	ret.Timestamp = x.now
+
+	return &ret, nil
+}
+
+// GetAccountInfo returns the balances sorted by asset.
+func (x *Exchange) GetAccountInfo(_ context.Context) (*types.AccountInfo, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	assets := make([]string, 0, len(x.balances))
+	for k := range x.balances {
+		assets = append(assets, k)
+	}
+	sort.Strings(assets)
+
+	ret := &types.AccountInfo{
+		CanTrade:    true,
+		UpdateTime:  int(x.now),
+		AccountType: "SPOT",
+		Permissions: []string{"SPOT"},
+	}
+
+	ret.Balances = slices.Grow(ret.Balances, len(assets))[:len(assets)]
+	for i, v := range assets {
+		ret.Balances[i].Asset = v
+		ret.Balances[i].Free = x.balances[v].Free
+		ret.Balances[i].Locked = x.balances[v].Locked
+	}
+
+	return ret, nil
+}
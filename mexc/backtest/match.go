@@ -0,0 +1,439 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backtest
+
+import (
+	"github.com/jl1/nexapi/mexc/orderbook"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
+	spotwstypes "github.com/jl1/nexapi/mexc/spot/websocketmarket/types"
+	"github.com/jl1/nexapi/utils/candle"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+var two = decimal.NewFromInt(2)
+
+// tick is a market update orders are matched against.
+type tick struct {
+	time int64
+	// buy and sell are the prices takers buy and sell at
+	buy  decimal.Decimal
+	sell decimal.Decimal
+	// resting buys fill when the market trades below their price, resting sells
+	// when it trades above
+	low  decimal.Decimal
+	high decimal.Decimal
+	// buyQty and sellQty cap the quantity filled by resting buys and sells, nil
+	// when unknown
+	buyQty  *decimal.Decimal
+	sellQty *decimal.Decimal
+	// book is walked by takers when set
+	book *orderbook.Book
+	last decimal.Decimal
+}
+
+// ApplyCandle matches the open orders of symbol against a closed candle. The orders
+// which reached the exchange before the candle closed take liquidity at its open
+// price, and rest until its low or high trades through their price.
+func (x *Exchange) ApplyCandle(symbol string, c *candle.Candle) {
+	x.apply(symbol, &tick{
+		time: c.CloseTime,
+		buy:  c.Open,
+		sell: c.Open,
+		low:  c.Low,
+		high: c.High,
+		last: c.Close,
+	})
+}
+
+// ApplyTrade matches the open orders of symbol against an aggregate trade, resting
+// orders fill up to the quantity of the trade.
+func (x *Exchange) ApplyTrade(symbol string, t *spottypes.AggTrade) {
+	qty := t.Q
+	x.apply(symbol, &tick{
+		time:    t.T,
+		buy:     t.P,
+		sell:    t.P,
+		low:     t.P,
+		high:    t.P,
+		buyQty:  &qty,
+		sellQty: &qty,
+		last:    t.P,
+	})
+}
+
+// ApplyBookTicker matches the open orders against the best bid and ask of their
+// symbol. Takers buy at the ask and sell at the bid, resting orders fill when the
+// other side crosses their price, up to the quantity of the best level.
+func (x *Exchange) ApplyBookTicker(bt *spotwstypes.BookTicker) {
+	if !bt.BidPrice.IsPositive() || !bt.AskPrice.IsPositive() {
+		return
+	}
+
+	askQty, bidQty := bt.AskQty, bt.BidQty
+	x.apply(bt.Symbol, &tick{
+		time:    bt.EventTime,
+		buy:     bt.AskPrice,
+		sell:    bt.BidPrice,
+		low:     bt.AskPrice,
+		high:    bt.BidPrice,
+		buyQty:  &askQty,
+		sellQty: &bidQty,
+		last:    bt.BidPrice.Add(bt.AskPrice).Div(two, divPlaces),
+	})
+}
+
+// ApplyBook matches the open orders of symbol against an order book at ts in
+// milliseconds. Takers walk the levels and fill at their average price, resting
+// orders fill when the other side crosses their price, up to the quantity of the
+// levels crossing it. The book is not retained.
+func (x *Exchange) ApplyBook(symbol string, ts int64, b *orderbook.Book) {
+	bid, okBid := b.BestBid()
+	ask, okAsk := b.BestAsk()
+	if !okBid || !okAsk {
+		return
+	}
+
+	x.apply(symbol, &tick{
+		time: ts,
+		buy:  ask.Price,
+		sell: bid.Price,
+		low:  ask.Price,
+		high: bid.Price,
+		book: b,
+		last: bid.Price.Add(ask.Price).Div(two, divPlaces),
+	})
+}
+
+func (x *Exchange) apply(symbol string, t *tick) {
+	x.mu.Lock()
+	n := len(x.fills)
+
+	x.now = max(x.now, t.time)
+
+	open := x.open[symbol][:0]
+	for _, o := range x.open[symbol] {
+		if x.match(o, t) {
+			open = append(open, o)
+		}
+	}
+	x.open[symbol] = open
+	x.last[symbol] = t.last
+
+	fills := x.fills[n:]
+	listeners := x.onFill
+	x.mu.Unlock()
+
+	for _, f := range fills {
+		for _, fn := range listeners {
+			fn(f)
+		}
+	}
+}
+
+// match updates o with t and reports whether it is still open.
+func (x *Exchange) match(o *order, t *tick) bool {
+	if o.CancelAt > 0 && o.CancelAt <= t.time {
+		x.close(o, t.time)
+		return false
+	}
+
+	if o.Arrival > t.time {
+		return true
+	}
+
+	if !o.Active {
+		o.Active = true
+		o.IsWorking = true
+
+		switch o.Type {
+		case types.MarketOrder:
+			x.take(o, t, nil)
+			return false
+		case types.LimitOrder, types.ImmediateOrCancel, types.FillOrKill:
+			if x.marketable(o, t) {
+				// the rest of a limit order rests from the next update, the
+				// liquidity of this one was taken
+				return x.take(o, t, &o.Price)
+			}
+			if o.Type != types.LimitOrder {
+				x.close(o, t.time)
+				return false
+			}
+		case types.LimitMakerOrder:
+			if x.marketable(o, t) {
+				x.logger.Debug("maker order would take liquidity", "symbol", o.Symbol, "order_id", o.OrderID)
+				x.close(o, t.time)
+				return false
+			}
+		}
+	}
+
+	if !x.through(o, t) {
+		return true
+	}
+
+	qty := o.OrigQty.Sub(o.ExecutedQty)
+	if limit := x.restingQty(o, t); limit != nil {
+		qty = decimal.Min(qty, *limit)
+	}
+	if qty.IsPositive() {
+		x.fill(o, o.Price, qty, true, t.time)
+	}
+
+	if o.Status == types.FilledStatus {
+		x.close(o, t.time)
+		return false
+	}
+
+	return true
+}
+
+func (x *Exchange) marketable(o *order, t *tick) bool {
+	if o.Side == types.BuySide {
+		return t.buy.LessThanOrEqual(o.Price)
+	}
+
+	return t.sell.GreaterThanOrEqual(o.Price)
+}
+
+func (x *Exchange) through(o *order, t *tick) bool {
+	if o.Side == types.BuySide {
+		return t.low.LessThan(o.Price)
+	}
+
+	return t.high.GreaterThan(o.Price)
+}
+
+// restingQty returns the quantity available to the resting order o, nil when
+// unknown.
+func (x *Exchange) restingQty(o *order, t *tick) *decimal.Decimal {
+	if t.book == nil {
+		if o.Side == types.BuySide {
+			return t.buyQty
+		}
+		return t.sellQty
+	}
+
+	var ret decimal.Decimal
+	if o.Side == types.BuySide {
+		for _, l := range t.book.Asks {
+			if l.Price.GreaterThanOrEqual(o.Price) {
+				break
+			}
+			ret = ret.Add(l.Qty)
+		}
+	} else {
+		for _, l := range t.book.Bids {
+			if l.Price.LessThanOrEqual(o.Price) {
+				break
+			}
+			ret = ret.Add(l.Qty)
+		}
+	}
+
+	return &ret
+}
+
+// take fills o at once as a taker, not beyond limit when set, and reports whether o
+// is still open. The part of a limit order a book could not fill rests on the book,
+// the part of other orders is canceled, and so is the part the balance does not
+// cover. Fill-or-kill orders are canceled whole when they can not fill whole.
+func (x *Exchange) take(o *order, t *tick, limit *decimal.Decimal) bool {
+	buy := o.Side == types.BuySide
+	market := t.buy
+	if !buy {
+		market = t.sell
+	}
+
+	byQuote := o.OrigQuoteOrderQty.IsPositive()
+	remaining := o.OrigQty.Sub(o.ExecutedQty)
+
+	var qty, price decimal.Decimal
+	if t.book != nil {
+		levels := t.book.Asks
+		if !buy {
+			levels = t.book.Bids
+		}
+
+		var quote decimal.Decimal
+		qty, quote = walk(levels, buy, remaining, o.OrigQuoteOrderQty, limit)
+		if byQuote {
+			qty = x.floorQty(o.Symbol, qty)
+		}
+		if qty.IsPositive() {
+			price = quote.Div(qty, divPlaces)
+		}
+	} else {
+		qty = remaining
+		if byQuote {
+			qty = x.floorQty(o.Symbol, o.OrigQuoteOrderQty.Div(market, divPlaces))
+		}
+
+		price = x.slippage.Price(o.Symbol, o.Side, market, qty)
+		if limit != nil {
+			if buy {
+				price = decimal.Min(price, *limit)
+			} else {
+				price = decimal.Max(price, *limit)
+			}
+		}
+
+		if byQuote && buy {
+			// the slipped price may not buy the whole quantity with the quote quantity
+			qty = decimal.Min(qty, x.floorQty(o.Symbol, o.OrigQuoteOrderQty.Div(price, divPlaces)))
+		}
+	}
+
+	if byQuote {
+		// orders sized by the quote quantity are complete once it is spent
+		o.OrigQty = qty
+	}
+
+	affordable := true
+	available := o.Locked.Add(x.balance(o.LockAsset).Free)
+	if buy && price.IsPositive() {
+		available = x.floorQty(o.Symbol, available.Div(price, divPlaces))
+	}
+	if available.LessThan(qty) {
+		qty, affordable = available, false
+	}
+
+	if o.Type == types.FillOrKill && qty.LessThan(remaining) {
+		qty = decimal.Decimal{}
+	}
+
+	if qty.IsPositive() {
+		x.fill(o, price, qty, false, t.time)
+	}
+
+	if affordable && o.Type == types.LimitOrder && o.Status != types.FilledStatus {
+		return true
+	}
+
+	x.close(o, t.time)
+	return false
+}
+
+// walk takes liquidity from levels up to qty, or up to quote when qty is zero, and
+// not beyond limit when set. It returns the quantity and quote amount taken.
+func walk(levels []orderbook.Level, buy bool, qty, quote decimal.Decimal, limit *decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	var filled, spent decimal.Decimal
+
+	for _, l := range levels {
+		if limit != nil && (buy && l.Price.GreaterThan(*limit) || !buy && l.Price.LessThan(*limit)) {
+			break
+		}
+
+		var take decimal.Decimal
+		if qty.IsPositive() {
+			take = decimal.Min(l.Qty, qty.Sub(filled))
+		} else {
+			take = decimal.Min(l.Qty, quote.Sub(spent).Div(l.Price, divPlaces))
+		}
+
+		filled = filled.Add(take)
+		spent = spent.Add(take.Mul(l.Price))
+
+		if take.LessThan(l.Qty) {
+			break
+		}
+	}
+
+	return filled, spent
+}
+
+// fill executes qty of o at price and moves the funds.
+func (x *Exchange) fill(o *order, price, qty decimal.Decimal, maker bool, ts int64) {
+	makerFee, takerFee := x.feeRates(o.Symbol)
+	rate := takerFee
+	if maker {
+		rate = makerFee
+	}
+
+	quoteQty := price.Mul(qty)
+	f := &Fill{
+		Time:     ts,
+		Symbol:   o.Symbol,
+		OrderID:  o.OrderID,
+		Side:     o.Side,
+		Type:     o.Type,
+		Price:    price,
+		Qty:      qty,
+		QuoteQty: quoteQty,
+		Maker:    maker,
+	}
+
+	if o.Side == types.BuySide {
+		x.spend(o, o.Quote, quoteQty)
+		f.Fee, f.FeeAsset = qty.Mul(rate), o.Base
+		b := x.balance(o.Base)
+		b.Free = b.Free.Add(qty.Sub(f.Fee))
+	} else {
+		x.spend(o, o.Base, qty)
+		f.Fee, f.FeeAsset = quoteQty.Mul(rate), o.Quote
+		b := x.balance(o.Quote)
+		b.Free = b.Free.Add(quoteQty.Sub(f.Fee))
+	}
+
+	o.ExecutedQty = o.ExecutedQty.Add(qty)
+	o.CummulativeQuoteQty = o.CummulativeQuoteQty.Add(quoteQty)
+	o.UpdateTime = ts
+	if o.ExecutedQty.GreaterThanOrEqual(o.OrigQty) {
+		o.Status = types.FilledStatus
+	} else {
+		o.Status = types.PartiallyFilledStatus
+	}
+
+	x.fills = append(x.fills, f)
+	x.revision++
+}
+
+// spend takes amount of asset from the funds locked by o first, then from the free
+// balance.
+func (x *Exchange) spend(o *order, asset string, amount decimal.Decimal) {
+	b := x.balance(asset)
+
+	if asset == o.LockAsset {
+		used := decimal.Min(amount, o.Locked)
+		o.Locked = o.Locked.Sub(used)
+		b.Locked = b.Locked.Sub(used)
+		amount = amount.Sub(used)
+	}
+
+	b.Free = b.Free.Sub(amount)
+}
+
+// close ends o and releases its locked funds, the unfilled part of o is canceled.
+func (x *Exchange) close(o *order, ts int64) {
+	b := x.balance(o.LockAsset)
+	b.Locked = b.Locked.Sub(o.Locked)
+	b.Free = b.Free.Add(o.Locked)
+	o.Locked = decimal.Decimal{}
+
+	o.IsWorking = false
+	o.UpdateTime = ts
+	switch {
+	case o.Status == types.FilledStatus:
+	case o.ExecutedQty.IsPositive():
+		o.Status = types.PartiallyCanceledStatus
+	default:
+		o.Status = types.CanceledStatus
+	}
+	x.revision++
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backtest
+
+import (
+	"math"
+	"time"
+
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// year is the period Sharpe ratios are annualized to, markets trade every day.
+const year = 365 * 24 * time.Hour
+
+type EquityPoint struct {
+	// Time in milliseconds
+	Time   int64           `json:"time"`
+	Equity decimal.Decimal `json:"equity"`
+	// Partial is set on a last point that is less than an interval after the previous
+	// one, it is left out of the Sharpe ratio
+	Partial bool `json:"partial,omitempty"`
+}
+
+type Metrics struct {
+	// Start and End are the times of the first and last equity points
+	Start         int64           `json:"start"`
+	End           int64           `json:"end"`
+	InitialEquity decimal.Decimal `json:"initialEquity"`
+	FinalEquity   decimal.Decimal `json:"finalEquity"`
+	// Return is the total return, 0.1 for 10%
+	Return float64 `json:"return"`
+	// Sharpe is the annualized Sharpe ratio of the returns between equity points,
+	// partial points excluded, with a zero risk-free rate
+	Sharpe float64 `json:"sharpe"`
+	// MaxDrawdown is the largest fall of the equity from a previous peak, 0.2 for 20%
+	MaxDrawdown float64 `json:"maxDrawdown"`
+	Fills       int     `json:"fills"`
+	// Fees is the value of the fees paid in the equity asset, at the fill prices
+	Fees decimal.Decimal `json:"fees"`
+}
+
+// ComputeMetrics returns the return, Sharpe ratio and maximum drawdown of an equity
+// curve sampled every interval.
+func ComputeMetrics(equity []*EquityPoint, interval time.Duration) Metrics {
+	var ret Metrics
+	if len(equity) == 0 {
+		return ret
+	}
+
+	first, last := equity[0], equity[len(equity)-1]
+	ret.Start, ret.End = first.Time, last.Time
+	ret.InitialEquity, ret.FinalEquity = first.Equity, last.Equity
+
+	if first.Equity.IsPositive() {
+		ret.Return = last.Equity.Float64()/first.Equity.Float64() - 1
+	}
+
+	var returns []float64
+	peak := first.Equity.Float64()
+	for i, v := range equity {
+		e := v.Equity.Float64()
+
+		if i > 0 && !v.Partial {
+			if prev := equity[i-1].Equity.Float64(); prev > 0 {
+				returns = append(returns, e/prev-1)
+			}
+		}
+
+		peak = math.Max(peak, e)
+		if peak > 0 {
+			ret.MaxDrawdown = math.Max(ret.MaxDrawdown, 1-e/peak)
+		}
+	}
+
+	if len(returns) > 1 && interval > 0 {
+		var mean float64
+		for _, v := range returns {
+			mean += v
+		}
+		mean /= float64(len(returns))
+
+		var variance float64
+		for _, v := range returns {
+			variance += (v - mean) * (v - mean)
+		}
+		stddev := math.Sqrt(variance / float64(len(returns)-1))
+
+		if stddev > 0 {
+			ret.Sharpe = mean / stddev * math.Sqrt(float64(year)/float64(interval))
+		}
+	}
+
+	return ret
+}
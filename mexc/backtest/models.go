@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backtest
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// LatencyModel delays the requests sent to the simulated exchange, an order can only
+// fill, and a cancellation only takes effect, once its request reached the exchange.
+type LatencyModel interface {
+	Latency() time.Duration
+}
+
+// FixedLatency delays every request by the same duration.
+type FixedLatency time.Duration
+
+func (l FixedLatency) Latency() time.Duration {
+	return time.Duration(l)
+}
+
+// UniformLatency delays requests by a random duration between Min and Max. Rand
+// defaults to a source seeded with 1, so that runs are reproducible.
+type UniformLatency struct {
+	Min  time.Duration
+	Max  time.Duration
+	Rand *rand.Rand
+
+	once sync.Once
+}
+
+func (l *UniformLatency) Latency() time.Duration {
+	l.once.Do(func() {
+		if l.Rand == nil {
+			l.Rand = rand.New(rand.NewSource(1))
+		}
+	})
+
+	if l.Max <= l.Min {
+		return l.Min
+	}
+
+	return l.Min + time.Duration(l.Rand.Int63n(int64(l.Max-l.Min)+1))
+}
+
+// SlippageModel moves the price taker orders fill at away from the market price.
+type SlippageModel interface {
+	// Price returns the fill price of a taker order of qty on side of symbol, price
+	// is the best price of the market
+	Price(symbol string, side types.OrderSide, price, qty decimal.Decimal) decimal.Decimal
+}
+
+// NoSlippage fills taker orders at the market price.
+type NoSlippage struct{}
+
+func (NoSlippage) Price(_ string, _ types.OrderSide, price, _ decimal.Decimal) decimal.Decimal {
+	return price
+}
+
+// FixedSlippage moves the price of taker orders by Bps basis points, buys fill higher
+// and sells lower.
+type FixedSlippage struct {
+	Bps decimal.Decimal
+}
+
+var tenThousand = decimal.NewFromInt(10000)
+
+func (s FixedSlippage) Price(_ string, side types.OrderSide, price, _ decimal.Decimal) decimal.Decimal {
+	delta := price.Mul(s.Bps).Div(tenThousand, divPlaces)
+	if side == types.SellSide {
+		return price.Sub(delta)
+	}
+
+	return price.Add(delta)
+}
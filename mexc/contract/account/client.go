@@ -20,15 +20,23 @@ package account
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator"
 	"github.com/jl1/nexapi/mexc/contract/account/types"
 	"github.com/jl1/nexapi/mexc/contract/utils"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
 )
 
+// ErrPositionsOpen is returned when the position mode is changed while positions are open.
+var ErrPositionsOpen = errors.New("position mode can not be changed while positions are open")
+
 type ContractAccountClient struct {
 	*utils.ContractClient
 
@@ -49,7 +57,7 @@ type ContractAccountClientCfg struct {
 }
 
 func NewContractAccountClient(cfg *utils.ContractClientCfg) (*ContractAccountClient, error) {
-	validator := validator.New()
+	validator := mexcutils.NewValidator()
 
 	err := validator.Struct(cfg)
 	if err != nil {
@@ -232,3 +240,1125 @@ func (c *ContractAccountClient) SetPositionLeverage(ctx context.Context, param t
 
 	return &ret, nil
 }
+
+func (c *ContractAccountClient) SubmitOrder(ctx context.Context, param types.NewOrderParam) (*types.SubmitOrderResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/order/submit",
+		Method:  http.MethodPost,
+		Body:    param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.SubmitOrderResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// SubmitBatchOrders places up to 50 orders at once.
+func (c *ContractAccountClient) SubmitBatchOrders(ctx context.Context, params []types.NewOrderParam) (*types.SubmitBatchOrdersResp, error) {
+	if len(params) == 0 || len(params) > 50 {
+		return nil, fmt.Errorf("the number of orders must be between 1 and 50, got %d", len(params))
+	}
+
+	for _, param := range params {
+		err := c.validate.Struct(param)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/order/submit_batch",
+		Method:  http.MethodPost,
+		Body:    params,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.SubmitBatchOrdersResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// CancelOrders cancels up to 50 orders by their order ids.
+func (c *ContractAccountClient) CancelOrders(ctx context.Context, orderIds []int64) (*types.CancelOrdersResp, error) {
+	if len(orderIds) == 0 || len(orderIds) > 50 {
+		return nil, fmt.Errorf("the number of order ids must be between 1 and 50, got %d", len(orderIds))
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/order/cancel",
+		Method:  http.MethodPost,
+		Body:    orderIds,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.CancelOrdersResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) CancelOrderWithExternalOid(ctx context.Context, param types.CancelOrderWithExternalOidParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/order/cancel_with_external",
+		Method:  http.MethodPost,
+		Body:    param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.Response
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// CancelAllOrders cancels all open orders, or only those of param.Symbol when it is set.
+func (c *ContractAccountClient) CancelAllOrders(ctx context.Context, param types.CancelAllOrdersParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/order/cancel_all",
+		Method:  http.MethodPost,
+		Body:    param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.Response
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetOrder(ctx context.Context, orderId int64) (*types.GetOrderResp, error) {
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    fmt.Sprintf("/api/v1/private/order/get/%d", orderId),
+		Method:  http.MethodGet,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetOrderResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetOrderByExternalOid(ctx context.Context, param types.GetOrderByExternalOidParams) (*types.GetOrderResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    fmt.Sprintf("/api/v1/private/order/external/%s/%s", param.Symbol, url.PathEscape(param.ExternalOid)),
+		Method:  http.MethodGet,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetOrderResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// GetOrdersByIds queries up to 50 orders by their order ids.
+func (c *ContractAccountClient) GetOrdersByIds(ctx context.Context, orderIds []int64) (*types.GetOrdersResp, error) {
+	if len(orderIds) == 0 || len(orderIds) > 50 {
+		return nil, fmt.Errorf("the number of order ids must be between 1 and 50, got %d", len(orderIds))
+	}
+
+	ids := make([]string, 0, len(orderIds))
+	for _, v := range orderIds {
+		ids = append(ids, strconv.FormatInt(v, 10))
+	}
+
+	param := types.BatchQueryOrdersParams{
+		OrderIds: strings.Join(ids, ","),
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/order/batch_query",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetOrdersResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// GetOpenOrders queries the open orders of all symbols, or only those of param.Symbol when it is set.
+func (c *ContractAccountClient) GetOpenOrders(ctx context.Context, param types.GetOpenOrdersParams) (*types.GetOrdersResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	path := "/api/v1/private/order/list/open_orders"
+	if param.Symbol != "" {
+		path += "/" + param.Symbol
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    path,
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetOrdersResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetHistoryOrders(ctx context.Context, param types.GetHistoryOrdersParams) (*types.GetOrdersResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/order/list/history_orders",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetOrdersResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetOrderDealDetails(ctx context.Context, orderId int64) (*types.GetDealsResp, error) {
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    fmt.Sprintf("/api/v1/private/order/deal_details/%d", orderId),
+		Method:  http.MethodGet,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetDealsResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetOrderDeals(ctx context.Context, param types.GetOrderDealsParams) (*types.GetDealsResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/order/list/order_deals",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetDealsResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) PlacePlanOrder(ctx context.Context, param types.PlacePlanOrderParams) (*types.PlacePlanOrderResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/planorder/place",
+		Method:  http.MethodPost,
+		Body:    param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.PlacePlanOrderResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// CancelPlanOrders cancels up to 50 plan orders.
+func (c *ContractAccountClient) CancelPlanOrders(ctx context.Context, params []types.CancelPlanOrderParam) (*types.Response, error) {
+	if len(params) == 0 || len(params) > 50 {
+		return nil, fmt.Errorf("the number of plan orders must be between 1 and 50, got %d", len(params))
+	}
+
+	for _, param := range params {
+		err := c.validate.Struct(param)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/planorder/cancel",
+		Method:  http.MethodPost,
+		Body:    params,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.Response
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// CancelAllPlanOrders cancels all plan orders, or only those of param.Symbol when it is set.
+func (c *ContractAccountClient) CancelAllPlanOrders(ctx context.Context, param types.CancelAllPlanOrdersParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/planorder/cancel_all",
+		Method:  http.MethodPost,
+		Body:    param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.Response
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetPlanOrders(ctx context.Context, param types.GetPlanOrdersParams) (*types.GetPlanOrdersResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/planorder/list/orders",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetPlanOrdersResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetStopOrders(ctx context.Context, param types.GetStopOrdersParams) (*types.GetStopOrdersResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/stoporder/list/orders",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetStopOrdersResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// CancelStopOrders cancels up to 50 stop-limit orders.
+func (c *ContractAccountClient) CancelStopOrders(ctx context.Context, params []types.CancelStopOrderParam) (*types.Response, error) {
+	if len(params) == 0 || len(params) > 50 {
+		return nil, fmt.Errorf("the number of stop orders must be between 1 and 50, got %d", len(params))
+	}
+
+	for _, param := range params {
+		err := c.validate.Struct(param)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/stoporder/cancel",
+		Method:  http.MethodPost,
+		Body:    params,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.Response
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// CancelAllStopOrders cancels all stop-limit orders of a position or a symbol, or all of them when neither is set.
+func (c *ContractAccountClient) CancelAllStopOrders(ctx context.Context, param types.CancelAllStopOrdersParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/stoporder/cancel_all",
+		Method:  http.MethodPost,
+		Body:    param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.Response
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) ChangeStopPrice(ctx context.Context, param types.ChangeStopPriceParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/stoporder/change_price",
+		Method:  http.MethodPost,
+		Body:    param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.Response
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) ChangeStopPlanPrice(ctx context.Context, param types.ChangeStopPlanPriceParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/stoporder/change_plan_price",
+		Method:  http.MethodPost,
+		Body:    param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.Response
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetHistoryPositions(ctx context.Context, param types.GetHistoryPositionsParams) (*types.GetHistoryPositions, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/position/list/history_positions",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetHistoryPositions
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetFundingRecords(ctx context.Context, param types.GetFundingRecordsParams) (*types.GetFundingRecords, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/position/funding_records",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetFundingRecords
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// ChangeMargin adds margin to or reduces margin from an isolated position.
+func (c *ContractAccountClient) ChangeMargin(ctx context.Context, param types.ChangeMarginParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/position/change_margin",
+		Method:  http.MethodPost,
+		Body:    param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.Response
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) ChangeAutoAddMargin(ctx context.Context, param types.ChangeAutoAddMarginParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/position/change_auto_add_im",
+		Method:  http.MethodPost,
+		Body:    param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.Response
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetPositionMode(ctx context.Context) (*types.GetPositionModeResp, error) {
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/position/position_mode",
+		Method:  http.MethodGet,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetPositionModeResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// ChangePositionMode switches between hedge and one-way mode. It is refused with
+// ErrPositionsOpen while any position is open.
+func (c *ContractAccountClient) ChangePositionMode(ctx context.Context, param types.ChangePositionModeParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	positions, err := c.GetOpenPositions(ctx, types.GetOpenPositionsParams{})
+	if err != nil {
+		return nil, err
+	}
+	if !positions.Success {
+		return nil, fmt.Errorf("get open positions failed, code=%d message=%s", positions.Code, positions.Message)
+	}
+	if len(positions.Data) > 0 {
+		return nil, ErrPositionsOpen
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/position/change_position_mode",
+		Method:  http.MethodPost,
+		Body:    param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.Response
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetTieredFeeRate(ctx context.Context, param types.GetTieredFeeRateParams) (*types.GetTieredFeeRateResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/account/tiered_fee_rate",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetTieredFeeRateResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetRiskLimit(ctx context.Context, param types.GetRiskLimitParams) (*types.GetRiskLimitResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/account/risk_limit",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetRiskLimitResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// GetProfitRate queries the daily or the total profit rate of the account.
+func (c *ContractAccountClient) GetProfitRate(ctx context.Context, rateType types.ProfitRateType) (*types.GetProfitRateResp, error) {
+	err := c.validate.Var(rateType, "oneof=1 2")
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    fmt.Sprintf("/api/v1/private/account/profit_rate/%d", rateType),
+		Method:  http.MethodGet,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetProfitRateResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetFeeDetails(ctx context.Context, param types.GetFeeDetailsParams) (*types.GetFeeDetailsResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/account/fee_details",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetFeeDetailsResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (c *ContractAccountClient) GetTransferRecords(ctx context.Context, param types.GetTransferRecordsParams) (*types.GetTransferRecordsResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: c.GetBaseURL(),
+		Path:    "/api/v1/private/account/transfer_record",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	{
+		headers, err := c.GenAuthHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers = headers
+	}
+
+	resp, err := c.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetTransferRecordsResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
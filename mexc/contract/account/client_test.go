@@ -19,10 +19,21 @@ package account
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 
+	"github.com/jl1/nexapi/mexc/contract/account/types"
 	"github.com/jl1/nexapi/mexc/contract/utils"
+	"github.com/jl1/nexapi/utils/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -47,3 +58,251 @@ func TestGetAccountAsset(t *testing.T) {
 	_, err := cli.GetAccountAsset(context.TODO(), "BTC")
 	assert.Nil(t, err)
 }
+
+func TestSubmitOrderSendsSignedJSONBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.Nil(t, err)
+
+		var param types.NewOrderParam
+		assert.Nil(t, json.Unmarshal(body, &param))
+		assert.Equal(t, "BTC_USDT", param.Symbol)
+		assert.Equal(t, "my-oid", param.ExternalOid)
+		assert.True(t, param.ReduceOnly)
+
+		h := hmac.New(sha256.New, []byte("secret"))
+		h.Write([]byte("key" + r.Header.Get("Request-Time") + string(body)))
+		assert.Equal(t, hex.EncodeToString(h.Sum(nil)), r.Header.Get("Signature"))
+
+		w.Write([]byte(`{"success":true,"code":0,"data":102057569836905984}`))
+	}))
+	defer srv.Close()
+
+	cli, err := NewContractAccountClient(&utils.ContractClientCfg{
+		BaseURL:    srv.URL,
+		Key:        "key",
+		Secret:     "secret",
+		HTTPClient: srv.Client(),
+	})
+	assert.Nil(t, err)
+
+	price := decimal.NewFromInt(60000)
+	resp, err := cli.SubmitOrder(context.TODO(), types.NewOrderParam{
+		Symbol:      "BTC_USDT",
+		Price:       &price,
+		Vol:         decimal.NewFromInt(1),
+		Side:        types.CloseLong,
+		Type:        types.LimitOrder,
+		OpenType:    types.CrossMargin,
+		ExternalOid: "my-oid",
+		ReduceOnly:  true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(102057569836905984), resp.Data)
+}
+
+func TestGetOpenOrders(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetOpenOrders(context.TODO(), types.GetOpenOrdersParams{
+		Symbol: "BTC_USDT",
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetOpenOrdersPath(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"success":true,"code":0,"data":[]}`))
+	}))
+	defer srv.Close()
+
+	cli, err := NewContractAccountClient(&utils.ContractClientCfg{
+		BaseURL:    srv.URL,
+		Key:        "key",
+		Secret:     "secret",
+		HTTPClient: srv.Client(),
+	})
+	assert.Nil(t, err)
+
+	_, err = cli.GetOpenOrders(context.TODO(), types.GetOpenOrdersParams{})
+	assert.Nil(t, err)
+
+	_, err = cli.GetOpenOrders(context.TODO(), types.GetOpenOrdersParams{Symbol: "BTC_USDT"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{
+		"/api/v1/private/order/list/open_orders",
+		"/api/v1/private/order/list/open_orders/BTC_USDT",
+	}, paths)
+}
+
+func TestGetOrderByExternalOidEscapesPath(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.Write([]byte(`{"success":true,"code":0,"data":{}}`))
+	}))
+	defer srv.Close()
+
+	cli, err := NewContractAccountClient(&utils.ContractClientCfg{
+		BaseURL:    srv.URL,
+		Key:        "key",
+		Secret:     "secret",
+		HTTPClient: srv.Client(),
+	})
+	assert.Nil(t, err)
+
+	_, err = cli.GetOrderByExternalOid(context.TODO(), types.GetOrderByExternalOidParams{Symbol: "BTC_USDT", ExternalOid: "a/b?c%d"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"/api/v1/private/order/external/BTC_USDT/a%2Fb%3Fc%25d"}, paths)
+}
+
+func TestGetHistoryOrders(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetHistoryOrders(context.TODO(), types.GetHistoryOrdersParams{
+		Symbol:   "BTC_USDT",
+		PageSize: 10,
+	})
+	assert.Nil(t, err)
+}
+
+func TestWalkHistoryOrders(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pageNum := r.URL.Query().Get("page_num")
+		pages = append(pages, pageNum)
+
+		switch pageNum {
+		case "1":
+			w.Write([]byte(`{"success":true,"code":0,"data":[{"orderId":1},{"orderId":2}]}`))
+		default:
+			w.Write([]byte(`{"success":true,"code":0,"data":[{"orderId":3}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	cli, err := NewContractAccountClient(&utils.ContractClientCfg{
+		BaseURL:    srv.URL,
+		Key:        "key",
+		Secret:     "secret",
+		HTTPClient: srv.Client(),
+	})
+	assert.Nil(t, err)
+
+	var ids []string
+	err = cli.WalkHistoryOrders(context.TODO(), types.GetHistoryOrdersParams{
+		Symbol:   "BTC_USDT",
+		PageSize: 2,
+	}, func(orders []*types.Order) error {
+		for _, v := range orders {
+			ids = append(ids, strconv.FormatInt(v.OrderId, 10))
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2"}, pages)
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+
+	// the error of fn stops the walk and is returned wrapped
+	errStop := errors.New("stop")
+	err = cli.WalkHistoryOrders(context.TODO(), types.GetHistoryOrdersParams{Symbol: "BTC_USDT", PageSize: 2}, func(orders []*types.Order) error {
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.NotEqual(t, errStop, err)
+}
+
+func TestGetPlanOrders(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetPlanOrders(context.TODO(), types.GetPlanOrdersParams{
+		Symbol:   "BTC_USDT",
+		PageSize: 10,
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetStopOrders(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetStopOrders(context.TODO(), types.GetStopOrdersParams{
+		Symbol:   "BTC_USDT",
+		PageSize: 10,
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetPositionMode(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetPositionMode(context.TODO())
+	assert.Nil(t, err)
+}
+
+func TestChangePositionModeWithOpenPositions(t *testing.T) {
+	var changed bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/private/position/open_positions":
+			w.Write([]byte(`{"success":true,"code":0,"data":[{"positionId":1,"symbol":"BTC_USDT"}]}`))
+		default:
+			changed = true
+			w.Write([]byte(`{"success":true,"code":0}`))
+		}
+	}))
+	defer srv.Close()
+
+	cli, err := NewContractAccountClient(&utils.ContractClientCfg{
+		BaseURL:    srv.URL,
+		Key:        "key",
+		Secret:     "secret",
+		HTTPClient: srv.Client(),
+	})
+	assert.Nil(t, err)
+
+	_, err = cli.ChangePositionMode(context.TODO(), types.ChangePositionModeParams{
+		PositionMode: types.OneWayMode,
+	})
+	assert.ErrorIs(t, err, ErrPositionsOpen)
+	assert.False(t, changed)
+}
+
+func TestGetTieredFeeRate(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetTieredFeeRate(context.TODO(), types.GetTieredFeeRateParams{
+		Symbol: "BTC_USDT",
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetRiskLimit(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetRiskLimit(context.TODO(), types.GetRiskLimitParams{
+		Symbol: "BTC_USDT",
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetProfitRateRejectsUnknownType(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	for _, v := range []types.ProfitRateType{0, 3} {
+		_, err := cli.GetProfitRate(context.TODO(), v)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestGetTransferRecords(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetTransferRecords(context.TODO(), types.GetTransferRecordsParams{
+		PageSize: 10,
+	})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package account
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jl1/nexapi/mexc/contract/account/types"
+)
+
+// default and maximum page size of the paginated private endpoints
+const maxPageSize = 100
+
+// WalkHistoryOrders walks all pages of the historical orders matching param, starting at
+// param.PageNum, and calls fn with each non-empty page. Walking stops at the first error
+// returned by fn, wrapped with the page number, or by the exchange.
+func (c *ContractAccountClient) WalkHistoryOrders(ctx context.Context, param types.GetHistoryOrdersParams, fn func([]*types.Order) error) error {
+	if param.PageNum == 0 {
+		param.PageNum = 1
+	}
+	if param.PageSize == 0 {
+		param.PageSize = maxPageSize
+	}
+
+	for {
+		resp, err := c.GetHistoryOrders(ctx, param)
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("get history orders failed, code=%d message=%s", resp.Code, resp.Message)
+		}
+
+		if len(resp.Data) > 0 {
+			if err := fn(resp.Data); err != nil {
+				return fmt.Errorf("page %d: %w", param.PageNum, err)
+			}
+		}
+
+		if len(resp.Data) < param.PageSize {
+			return nil
+		}
+
+		param.PageNum++
+	}
+}
+
+// WalkOrderDeals walks all pages of the deals matching param, starting at param.PageNum,
+// and calls fn with each non-empty page. Walking stops at the first error returned by fn,
+// wrapped with the page number, or by the exchange.
+func (c *ContractAccountClient) WalkOrderDeals(ctx context.Context, param types.GetOrderDealsParams, fn func([]*types.Deal) error) error {
+	if param.PageNum == 0 {
+		param.PageNum = 1
+	}
+	if param.PageSize == 0 {
+		param.PageSize = maxPageSize
+	}
+
+	for {
+		resp, err := c.GetOrderDeals(ctx, param)
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("get order deals failed, code=%d message=%s", resp.Code, resp.Message)
+		}
+
+		if len(resp.Data) > 0 {
+			if err := fn(resp.Data); err != nil {
+				return fmt.Errorf("page %d: %w", param.PageNum, err)
+			}
+		}
+
+		if len(resp.Data) < param.PageSize {
+			return nil
+		}
+
+		param.PageNum++
+	}
+}
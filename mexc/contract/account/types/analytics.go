@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import "github.com/jl1/nexapi/utils/decimal"
+
+type GetTieredFeeRateParams struct {
+	Symbol string `url:"symbol,omitempty" validate:"omitempty"`
+}
+
+type GetTieredFeeRateResp struct {
+	Response
+	Data struct {
+		Level            int             `json:"level"`
+		DealAmount       decimal.Decimal `json:"dealAmount"`
+		WalletBalance    decimal.Decimal `json:"walletBalance"`
+		MakerFee         decimal.Decimal `json:"makerFee"`
+		TakerFee         decimal.Decimal `json:"takerFee"`
+		MakerFeeDiscount decimal.Decimal `json:"makerFeeDiscount"`
+		TakerFeeDiscount decimal.Decimal `json:"takerFeeDiscount"`
+	} `json:"data"`
+}
+
+type GetRiskLimitParams struct {
+	Symbol string `url:"symbol,omitempty" validate:"omitempty"`
+}
+
+// risk limits are keyed by symbol
+type GetRiskLimitResp struct {
+	Response
+	Data map[string][]*RiskLimit `json:"data"`
+}
+
+type RiskLimit struct {
+	Symbol       string          `json:"symbol"`
+	Level        int             `json:"level"`
+	MaxVol       decimal.Decimal `json:"maxVol"`
+	Mmr          decimal.Decimal `json:"mmr"`
+	Imr          decimal.Decimal `json:"imr"`
+	MaxLeverage  int             `json:"maxLeverage"`
+	PositionType PositionType    `json:"positionType"`
+	OpenType     OpenType        `json:"openType"`
+	Leverage     int             `json:"leverage"`
+	LimitBySys   bool            `json:"limitBySys"`
+	CurrentMmr   decimal.Decimal `json:"currentMmr"`
+}
+
+type ProfitRateType = int
+
+var (
+	DailyProfitRate ProfitRateType = 1
+	TotalProfitRate ProfitRateType = 2
+)
+
+type GetProfitRateResp struct {
+	Response
+	Data struct {
+		ProfitRate decimal.Decimal `json:"profitRate"`
+		Profit     decimal.Decimal `json:"profit"`
+		StartTime  int64           `json:"startTime"`
+		EndTime    int64           `json:"endTime"`
+	} `json:"data"`
+}
+
+type GetFeeDetailsParams struct {
+	Symbol    string `url:"symbol,omitempty" validate:"omitempty"`
+	StartTime int64  `url:"start_time,omitempty" validate:"omitempty"`
+	EndTime   int64  `url:"end_time,omitempty" validate:"omitempty"`
+	PageNum   int    `url:"page_num,omitempty" validate:"omitempty"`
+	PageSize  int    `url:"page_size,omitempty" validate:"omitempty,max=100"`
+}
+
+type GetFeeDetailsResp struct {
+	Response
+	Data struct {
+		Page
+		ResultList []*FeeDetail `json:"resultList"`
+	} `json:"data"`
+}
+
+type FeeDetail struct {
+	Id          int64           `json:"id"`
+	Symbol      string          `json:"symbol"`
+	OrderId     int64           `json:"orderId"`
+	Fee         decimal.Decimal `json:"fee"`
+	FeeCurrency string          `json:"feeCurrency"`
+	IsTaker     bool            `json:"taker"`
+	CreateTime  int64           `json:"createTime"`
+}
+
+type TransferType = string
+
+var (
+	TransferIn  TransferType = "IN"
+	TransferOut TransferType = "OUT"
+)
+
+type TransferState = string
+
+var (
+	TransferWaiting TransferState = "WAIT"
+	TransferSuccess TransferState = "SUCCESS"
+	TransferFailed  TransferState = "FAILED"
+)
+
+type GetTransferRecordsParams struct {
+	Currency string        `url:"currency,omitempty" validate:"omitempty"`
+	State    TransferState `url:"state,omitempty" validate:"omitempty,oneof=WAIT SUCCESS FAILED"`
+	Type     TransferType  `url:"type,omitempty" validate:"omitempty,oneof=IN OUT"`
+	PageNum  int           `url:"page_num,omitempty" validate:"omitempty"`
+	PageSize int           `url:"page_size,omitempty" validate:"omitempty,max=100"`
+}
+
+type GetTransferRecordsResp struct {
+	Response
+	Data struct {
+		Page
+		ResultList []*TransferRecord `json:"resultList"`
+	} `json:"data"`
+}
+
+type TransferRecord struct {
+	Id         int64           `json:"id"`
+	Txid       string          `json:"txid"`
+	Currency   string          `json:"currency"`
+	Amount     decimal.Decimal `json:"amount"`
+	Type       TransferType    `json:"type"`
+	State      TransferState   `json:"state"`
+	CreateTime int64           `json:"createTime"`
+	UpdateTime int64           `json:"updateTime"`
+}
@@ -17,6 +17,8 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type GetAccountAssets struct {
 	Response
 	Data []*ContractAsset `json:"data"`
@@ -28,12 +30,12 @@ type GetAccountAsset struct {
 }
 
 type ContractAsset struct {
-	Currency         string  `json:"currency"`
-	PositionMargin   float64 `json:"positionMargin"`
-	AvailableBalance float64 `json:"availableBalance"`
-	CashBalance      float64 `json:"cachBalance"`
-	FrozenBalance    float64 `json:"frozenBalance"`
-	Equity           float64 `json:"equity"`
-	Unrealized       float64 `json:"unrealized"`
-	Bonus            float64 `json:"bonus"`
+	Currency         string          `json:"currency"`
+	PositionMargin   decimal.Decimal `json:"positionMargin"`
+	AvailableBalance decimal.Decimal `json:"availableBalance"`
+	CashBalance      decimal.Decimal `json:"cachBalance"`
+	FrozenBalance    decimal.Decimal `json:"frozenBalance"`
+	Equity           decimal.Decimal `json:"equity"`
+	Unrealized       decimal.Decimal `json:"unrealized"`
+	Bonus            decimal.Decimal `json:"bonus"`
 }
@@ -17,6 +17,8 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type GetLeverageParams struct {
 	Symbol string `url:"symbol,omitempty" validate:"required"`
 }
@@ -24,20 +26,20 @@ type GetLeverageParams struct {
 type GetLeverageResp struct {
 	Response
 	Data struct {
-		PositionType int     `json:"positionType"`
-		Level        int     `json:"level"`
-		Imr          float64 `json:"imr"`
-		Mmr          float64 `json:"mmr"`
-		Leverage     int     `json:"leverage"`
+		PositionType int             `json:"positionType"`
+		Level        int             `json:"level"`
+		Imr          decimal.Decimal `json:"imr"`
+		Mmr          decimal.Decimal `json:"mmr"`
+		Leverage     int             `json:"leverage"`
 	} `json:"data"`
 }
 
 type SetLeverageParams struct {
-	PositionId   int64  `url:"positionId,omitempty" validate:"omitempty"`
-	Leverage     int    `url:"positionId,omitempty" validate:"required"`
-	OpenType     int    `url:"openType,omitempty" validate:"omitempty"`
-	Symbol       string `url:"symbol,omitempty" validate:"omitempty"`
-	PositionType int    `url:"positionType,omitempty" validate:"omitempty"`
+	PositionId   int64  `json:"positionId,omitempty" url:"positionId,omitempty" validate:"omitempty"`
+	Leverage     int    `json:"leverage" url:"leverage" validate:"required"`
+	OpenType     int    `json:"openType,omitempty" url:"openType,omitempty" validate:"omitempty"`
+	Symbol       string `json:"symbol,omitempty" url:"symbol,omitempty" validate:"omitempty"`
+	PositionType int    `json:"positionType,omitempty" url:"positionType,omitempty" validate:"omitempty"`
 }
 
 type SetLeverageResp struct {
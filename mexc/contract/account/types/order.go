@@ -17,19 +17,26 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type NewOrderParam struct {
-	Symbol   string    `url:"symbol" validate:"required"`
-	Price    float64   `url:"price,omitempty" validate:"omitempty"`
-	Vol      float64   `url:"vol,omitempty" validate:"required"`
-	Leverage int       `url:"leverage,omitempty" validate:"omitempty"`
-	Side     OrderSide `url:"side" validate:"required,oneof=1 2 3 4"`
-	Type     OrderType `url:"type" validate:"required,oneof=1 2 3 4 5 6"`
-	OpenType OpenType  `url:"openType" validate:"required,oneof=1 2"`
-
-	PositionId      int64   `url:"positionId,omitempty" validate:"omitempty"`
-	StopLossPrice   float64 `url:"stopLossPrice,omitempty" validate:"omitempty"`
-	TakeProfitPrice float64 `url:"takeProfitPrice,omitempty" validate:"omitempty"`
-	PositionMode    int     `url:"positionMode,omitempty" validate:"omitempty"`
+	Symbol      string           `json:"symbol" url:"symbol" validate:"required"`
+	Price       *decimal.Decimal `json:"price,omitempty" url:"price,omitempty" validate:"omitempty"`
+	Vol         decimal.Decimal  `json:"vol" url:"vol,omitempty" validate:"required"`
+	Leverage    int              `json:"leverage,omitempty" url:"leverage,omitempty" validate:"omitempty"`
+	Side        OrderSide        `json:"side" url:"side" validate:"required,oneof=1 2 3 4"`
+	Type        OrderType        `json:"type" url:"type" validate:"required,oneof=1 2 3 4 5 6"`
+	OpenType    OpenType         `json:"openType" url:"openType" validate:"required,oneof=1 2"`
+	ExternalOid string           `json:"externalOid,omitempty" url:"externalOid,omitempty" validate:"omitempty,max=32"`
+
+	PositionId      int64            `json:"positionId,omitempty" url:"positionId,omitempty" validate:"omitempty"`
+	StopLossPrice   *decimal.Decimal `json:"stopLossPrice,omitempty" url:"stopLossPrice,omitempty" validate:"omitempty"`
+	TakeProfitPrice *decimal.Decimal `json:"takeProfitPrice,omitempty" url:"takeProfitPrice,omitempty" validate:"omitempty"`
+	LossTrend       TriggerType      `json:"lossTrend,omitempty" url:"lossTrend,omitempty" validate:"omitempty,oneof=1 2 3"`
+	ProfitTrend     TriggerType      `json:"profitTrend,omitempty" url:"profitTrend,omitempty" validate:"omitempty,oneof=1 2 3"`
+	PositionMode    PositionMode     `json:"positionMode,omitempty" url:"positionMode,omitempty" validate:"omitempty,oneof=1 2"`
+	// only for one-way positions
+	ReduceOnly bool `json:"reduceOnly,omitempty" url:"reduceOnly,omitempty" validate:"omitempty"`
 }
 
 type OrderSide = int
@@ -53,3 +60,58 @@ var (
 )
 
 type OpenType = int
+
+var (
+	IsolatedMargin OpenType = 1
+	CrossMargin    OpenType = 2
+)
+
+type PositionMode = int
+
+var (
+	HedgeMode  PositionMode = 1
+	OneWayMode PositionMode = 2
+)
+
+// TriggerType is the price a stop-loss, take-profit or trigger order watches.
+type TriggerType = int
+
+var (
+	LastPrice  TriggerType = 1
+	FairPrice  TriggerType = 2
+	IndexPrice TriggerType = 3
+)
+
+// {"success":true,"code":0,"data":102057569836905984}
+type SubmitOrderResp struct {
+	Response
+	Data int64 `json:"data"`
+}
+
+type SubmitBatchOrdersResp struct {
+	Response
+	Data []struct {
+		OrderId     int64  `json:"orderId"`
+		ExternalOid string `json:"externalOid"`
+		ErrorCode   int    `json:"errorCode"`
+		ErrorMsg    string `json:"errorMsg"`
+	} `json:"data"`
+}
+
+type CancelOrdersResp struct {
+	Response
+	Data []struct {
+		OrderId   int64  `json:"orderId"`
+		ErrorCode int    `json:"errorCode"`
+		ErrorMsg  string `json:"errorMsg"`
+	} `json:"data"`
+}
+
+type CancelOrderWithExternalOidParams struct {
+	Symbol      string `json:"symbol" url:"symbol" validate:"required"`
+	ExternalOid string `json:"externalOid" url:"externalOid" validate:"required"`
+}
+
+type CancelAllOrdersParams struct {
+	Symbol string `json:"symbol,omitempty" url:"symbol,omitempty" validate:"omitempty"`
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import "github.com/jl1/nexapi/utils/decimal"
+
+type OrderState = int
+
+var (
+	OrderPending     OrderState = 1
+	OrderUncompleted OrderState = 2
+	OrderCompleted   OrderState = 3
+	OrderCancelled   OrderState = 4
+	OrderInvalid     OrderState = 5
+)
+
+type Order struct {
+	OrderId         int64           `json:"orderId"`
+	Symbol          string          `json:"symbol"`
+	PositionId      int64           `json:"positionId"`
+	Price           decimal.Decimal `json:"price"`
+	Vol             decimal.Decimal `json:"vol"`
+	Leverage        int             `json:"leverage"`
+	Side            OrderSide       `json:"side"`
+	Category        int             `json:"category"`
+	OrderType       OrderType       `json:"orderType"`
+	DealAvgPrice    decimal.Decimal `json:"dealAvgPrice"`
+	DealVol         decimal.Decimal `json:"dealVol"`
+	OrderMargin     decimal.Decimal `json:"orderMargin"`
+	TakerFee        decimal.Decimal `json:"takerFee"`
+	MakerFee        decimal.Decimal `json:"makerFee"`
+	Profit          decimal.Decimal `json:"profit"`
+	FeeCurrency     string          `json:"feeCurrency"`
+	OpenType        OpenType        `json:"openType"`
+	State           OrderState      `json:"state"`
+	ExternalOid     string          `json:"externalOid"`
+	ErrorCode       int             `json:"errorCode"`
+	UsedMargin      decimal.Decimal `json:"usedMargin"`
+	CreateTime      int64           `json:"createTime"`
+	UpdateTime      int64           `json:"updateTime"`
+	StopLossPrice   decimal.Decimal `json:"stopLossPrice"`
+	TakeProfitPrice decimal.Decimal `json:"takeProfitPrice"`
+}
+
+type GetOrderResp struct {
+	Response
+	Data *Order `json:"data"`
+}
+
+type GetOrdersResp struct {
+	Response
+	Data []*Order `json:"data"`
+}
+
+type GetOrderByExternalOidParams struct {
+	Symbol      string `url:"-" validate:"required"`
+	ExternalOid string `url:"-" validate:"required"`
+}
+
+type BatchQueryOrdersParams struct {
+	OrderIds string `url:"order_ids" validate:"required"` // separated by commas, max 50 orders
+}
+
+type GetOpenOrdersParams struct {
+	Symbol   string `url:"-" validate:"omitempty"`
+	PageNum  int    `url:"page_num,omitempty" validate:"omitempty"`
+	PageSize int    `url:"page_size,omitempty" validate:"omitempty,max=100"`
+}
+
+type GetHistoryOrdersParams struct {
+	Symbol    string    `url:"symbol,omitempty" validate:"omitempty"`
+	States    string    `url:"states,omitempty" validate:"omitempty"` // separated by commas
+	Category  int       `url:"category,omitempty" validate:"omitempty,oneof=1 2 3 4"`
+	StartTime int64     `url:"start_time,omitempty" validate:"omitempty"`
+	EndTime   int64     `url:"end_time,omitempty" validate:"omitempty"`
+	Side      OrderSide `url:"side,omitempty" validate:"omitempty,oneof=1 2 3 4"`
+	PageNum   int       `url:"page_num,omitempty" validate:"omitempty"`
+	PageSize  int       `url:"page_size,omitempty" validate:"omitempty,max=100"`
+}
+
+type Deal struct {
+	Id          int64           `json:"id"`
+	Symbol      string          `json:"symbol"`
+	Side        OrderSide       `json:"side"`
+	Vol         decimal.Decimal `json:"vol"`
+	Price       decimal.Decimal `json:"price"`
+	FeeCurrency string          `json:"feeCurrency"`
+	Fee         decimal.Decimal `json:"fee"`
+	Timestamp   int64           `json:"timestamp"`
+	Profit      decimal.Decimal `json:"profit"`
+	Category    int             `json:"category"`
+	OrderId     int64           `json:"orderId"`
+	IsTaker     bool            `json:"taker"`
+}
+
+type GetDealsResp struct {
+	Response
+	Data []*Deal `json:"data"`
+}
+
+type GetOrderDealsParams struct {
+	Symbol    string `url:"symbol" validate:"required"`
+	StartTime int64  `url:"start_time,omitempty" validate:"omitempty"`
+	EndTime   int64  `url:"end_time,omitempty" validate:"omitempty"`
+	PageNum   int    `url:"page_num,omitempty" validate:"omitempty"`
+	PageSize  int    `url:"page_size,omitempty" validate:"omitempty,max=100"`
+}
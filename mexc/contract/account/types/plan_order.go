@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import "github.com/jl1/nexapi/utils/decimal"
+
+// TriggerDirection decides whether a plan order triggers when the price rises to or falls
+// to the trigger price.
+type TriggerDirection = int
+
+var (
+	GreaterOrEqual TriggerDirection = 1
+	LessOrEqual    TriggerDirection = 2
+)
+
+// ExecuteCycle is how long a plan order stays effective.
+type ExecuteCycle = int
+
+var (
+	Cycle24Hours ExecuteCycle = 1
+	Cycle7Days   ExecuteCycle = 2
+)
+
+type PlanOrderType = int
+
+var (
+	PlanLimitOrder        PlanOrderType = 1
+	PlanPostOnlyMaker     PlanOrderType = 2
+	PlanTransactOrCancel  PlanOrderType = 3
+	PlanTransactAllOrNone PlanOrderType = 4
+	PlanMarketOrder       PlanOrderType = 5
+)
+
+type PlanOrderState = int
+
+var (
+	PlanUntriggered     PlanOrderState = 1
+	PlanCancelled       PlanOrderState = 2
+	PlanExecuted        PlanOrderState = 3
+	PlanInvalidated     PlanOrderState = 4
+	PlanExecutionFailed PlanOrderState = 5
+)
+
+type PlacePlanOrderParams struct {
+	Symbol       string           `json:"symbol" validate:"required"`
+	Price        *decimal.Decimal `json:"price,omitempty" validate:"omitempty"` // execute price, required by limit orders
+	Vol          decimal.Decimal  `json:"vol" validate:"required"`
+	Leverage     int              `json:"leverage,omitempty" validate:"omitempty"`
+	Side         OrderSide        `json:"side" validate:"required,oneof=1 2 3 4"`
+	OpenType     OpenType         `json:"openType" validate:"required,oneof=1 2"`
+	TriggerPrice decimal.Decimal  `json:"triggerPrice" validate:"required"`
+	TriggerType  TriggerDirection `json:"triggerType" validate:"required,oneof=1 2"`
+	ExecuteCycle ExecuteCycle     `json:"executeCycle" validate:"required,oneof=1 2"`
+	OrderType    PlanOrderType    `json:"orderType" validate:"required,oneof=1 2 3 4 5"`
+	Trend        TriggerType      `json:"trend" validate:"required,oneof=1 2 3"`
+	PositionMode PositionMode     `json:"positionMode,omitempty" validate:"omitempty,oneof=1 2"`
+	ReduceOnly   bool             `json:"reduceOnly,omitempty" validate:"omitempty"`
+}
+
+type PlacePlanOrderResp struct {
+	Response
+	Data int64 `json:"data"`
+}
+
+type CancelPlanOrderParam struct {
+	Symbol  string `json:"symbol" validate:"required"`
+	OrderId int64  `json:"orderId" validate:"required"`
+}
+
+type CancelAllPlanOrdersParams struct {
+	Symbol string `json:"symbol,omitempty" validate:"omitempty"`
+}
+
+type GetPlanOrdersParams struct {
+	Symbol    string `url:"symbol,omitempty" validate:"omitempty"`
+	States    string `url:"states,omitempty" validate:"omitempty"` // separated by commas
+	StartTime int64  `url:"start_time,omitempty" validate:"omitempty"`
+	EndTime   int64  `url:"end_time,omitempty" validate:"omitempty"`
+	PageNum   int    `url:"page_num,omitempty" validate:"omitempty"`
+	PageSize  int    `url:"page_size,omitempty" validate:"omitempty,max=100"`
+}
+
+type GetPlanOrdersResp struct {
+	Response
+	Data []*PlanOrder `json:"data"`
+}
+
+type PlanOrder struct {
+	Id           int64            `json:"id"`
+	Symbol       string           `json:"symbol"`
+	Leverage     int              `json:"leverage"`
+	Side         OrderSide        `json:"side"`
+	TriggerPrice decimal.Decimal  `json:"triggerPrice"`
+	Price        decimal.Decimal  `json:"price"`
+	Vol          decimal.Decimal  `json:"vol"`
+	OpenType     OpenType         `json:"openType"`
+	TriggerType  TriggerDirection `json:"triggerType"`
+	State        PlanOrderState   `json:"state"`
+	ExecuteCycle ExecuteCycle     `json:"executeCycle"`
+	Trend        TriggerType      `json:"trend"`
+	OrderType    PlanOrderType    `json:"orderType"`
+	OrderId      int64            `json:"orderId"`
+	ErrorCode    int              `json:"errorCode"`
+	CreateTime   int64            `json:"createTime"`
+	UpdateTime   int64            `json:"updateTime"`
+}
+
+type StopOrderState = int
+
+var (
+	StopUntriggered     StopOrderState = 1
+	StopCancelled       StopOrderState = 2
+	StopExecuted        StopOrderState = 3
+	StopInvalidated     StopOrderState = 4
+	StopExecutionFailed StopOrderState = 5
+)
+
+type GetStopOrdersParams struct {
+	Symbol     string `url:"symbol,omitempty" validate:"omitempty"`
+	IsFinished int    `url:"is_finished,omitempty" validate:"omitempty,oneof=0 1"`
+	StartTime  int64  `url:"start_time,omitempty" validate:"omitempty"`
+	EndTime    int64  `url:"end_time,omitempty" validate:"omitempty"`
+	PageNum    int    `url:"page_num,omitempty" validate:"omitempty"`
+	PageSize   int    `url:"page_size,omitempty" validate:"omitempty,max=100"`
+}
+
+type GetStopOrdersResp struct {
+	Response
+	Data []*StopOrder `json:"data"`
+}
+
+type StopOrder struct {
+	Id              int64           `json:"id"`
+	OrderId         int64           `json:"orderId"`
+	Symbol          string          `json:"symbol"`
+	PositionId      int64           `json:"positionId"`
+	StopLossPrice   decimal.Decimal `json:"stopLossPrice"`
+	TakeProfitPrice decimal.Decimal `json:"takeProfitPrice"`
+	State           StopOrderState  `json:"state"`
+	TriggerSide     int             `json:"triggerSide"`
+	PositionType    int             `json:"positionType"`
+	Vol             decimal.Decimal `json:"vol"`
+	RealityVol      decimal.Decimal `json:"realityVol"`
+	PlaceOrderId    int64           `json:"placeOrderId"`
+	ErrorCode       int             `json:"errorCode"`
+	Version         int             `json:"version"`
+	IsFinished      int             `json:"isFinished"`
+	CreateTime      int64           `json:"createTime"`
+	UpdateTime      int64           `json:"updateTime"`
+}
+
+type CancelStopOrderParam struct {
+	StopPlanOrderId int64 `json:"stopPlanOrderId" validate:"required"`
+}
+
+type CancelAllStopOrdersParams struct {
+	PositionId int64  `json:"positionId,omitempty" validate:"omitempty"`
+	Symbol     string `json:"symbol,omitempty" validate:"omitempty"`
+}
+
+// ChangeStopPriceParams changes the take-profit and stop-loss prices attached to a limit order.
+type ChangeStopPriceParams struct {
+	OrderId         int64            `json:"orderId" validate:"required"`
+	StopLossPrice   *decimal.Decimal `json:"stopLossPrice,omitempty" validate:"omitempty"`
+	TakeProfitPrice *decimal.Decimal `json:"takeProfitPrice,omitempty" validate:"omitempty"`
+}
+
+// ChangeStopPlanPriceParams changes the take-profit and stop-loss prices of a stop order.
+type ChangeStopPlanPriceParams struct {
+	StopPlanOrderId int64            `json:"stopPlanOrderId" validate:"required"`
+	StopLossPrice   *decimal.Decimal `json:"stopLossPrice,omitempty" validate:"omitempty"`
+	TakeProfitPrice *decimal.Decimal `json:"takeProfitPrice,omitempty" validate:"omitempty"`
+}
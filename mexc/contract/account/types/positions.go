@@ -17,6 +17,8 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type GetOpenPositionsParams struct {
 	Symbol string `url:"symbol,omitempty" validate:"omitempty"`
 }
@@ -33,20 +35,92 @@ type OpenPosition struct {
 	OpenType     int    `json:"openType"`
 	State        int    `json:"state"`
 
-	FrozenVol      float64 `json:"frozenVol"`
-	CloseVol       float64 `json:"closeVol"`
-	HoldAvgPrice   float64 `json:"holdAvgPrice"`
-	CloseAvgPrice  float64 `json:"closeAvgPrice"`
-	OpenAvgPrice   float64 `json:"openAvgPrice"`
-	LiquidatePrice float64 `json:"liquidatePrice"`
-	Oim            float64 `json:"oim"`
-	Im             float64 `json:"im"`
-	HoldFee        float64 `json:"holdFee"`
-	Realised       float64 `json:"realised"`
-
-	HoldVol    float64 `json:"holdVol"`
-	Leverage   int     `json:"leverage"`
-	CreateTime int64   `json:"createTime"`
-	UpdateTime int64   `json:"updateTime"`
-	AutoAddIm  bool    `json:"autoAddIm"`
+	FrozenVol      decimal.Decimal `json:"frozenVol"`
+	CloseVol       decimal.Decimal `json:"closeVol"`
+	HoldAvgPrice   decimal.Decimal `json:"holdAvgPrice"`
+	CloseAvgPrice  decimal.Decimal `json:"closeAvgPrice"`
+	OpenAvgPrice   decimal.Decimal `json:"openAvgPrice"`
+	LiquidatePrice decimal.Decimal `json:"liquidatePrice"`
+	Oim            decimal.Decimal `json:"oim"`
+	Im             decimal.Decimal `json:"im"`
+	HoldFee        decimal.Decimal `json:"holdFee"`
+	Realised       decimal.Decimal `json:"realised"`
+
+	HoldVol    decimal.Decimal `json:"holdVol"`
+	Leverage   int             `json:"leverage"`
+	CreateTime int64           `json:"createTime"`
+	UpdateTime int64           `json:"updateTime"`
+	AutoAddIm  bool            `json:"autoAddIm"`
+}
+
+type PositionType = int
+
+var (
+	LongPosition  PositionType = 1
+	ShortPosition PositionType = 2
+)
+
+type GetHistoryPositionsParams struct {
+	Symbol   string       `url:"symbol,omitempty" validate:"omitempty"`
+	Type     PositionType `url:"type,omitempty" validate:"omitempty,oneof=1 2"`
+	PageNum  int          `url:"page_num,omitempty" validate:"omitempty"`
+	PageSize int          `url:"page_size,omitempty" validate:"omitempty,max=100"`
+}
+
+// history positions share the fields of open positions
+type GetHistoryPositions struct {
+	Response
+	Data []*OpenPosition `json:"data"`
+}
+
+type GetFundingRecordsParams struct {
+	Symbol     string `url:"symbol,omitempty" validate:"omitempty"`
+	PositionId int64  `url:"position_id,omitempty" validate:"omitempty"`
+	PageNum    int    `url:"page_num,omitempty" validate:"omitempty"`
+	PageSize   int    `url:"page_size,omitempty" validate:"omitempty,max=100"`
+}
+
+type GetFundingRecords struct {
+	Response
+	Data struct {
+		Page
+		ResultList []*FundingRecord `json:"resultList"`
+	} `json:"data"`
+}
+
+type FundingRecord struct {
+	Id            int64           `json:"id"`
+	Symbol        string          `json:"symbol"`
+	PositionType  PositionType    `json:"positionType"`
+	PositionValue decimal.Decimal `json:"positionValue"`
+	Funding       decimal.Decimal `json:"funding"`
+	Rate          decimal.Decimal `json:"rate"`
+	SettleTime    int64           `json:"settleTime"`
+}
+
+type MarginChangeType = string
+
+var (
+	AddMargin    MarginChangeType = "ADD"
+	ReduceMargin MarginChangeType = "SUB"
+)
+
+type ChangeMarginParams struct {
+	PositionId int64            `json:"positionId" validate:"required"`
+	Amount     decimal.Decimal  `json:"amount" validate:"required,gt=0"`
+	Type       MarginChangeType `json:"type" validate:"required,oneof=ADD SUB"`
+}
+
+type ChangeAutoAddMarginParams struct {
+	PositionId int64 `json:"positionId" validate:"required"`
+	IsEnabled  bool  `json:"isEnabled"`
+}
+
+type GetPositionModeResp struct {
+	Response
+	Data PositionMode `json:"data"`
+}
+
+type ChangePositionModeParams struct {
+	PositionMode PositionMode `json:"positionMode" validate:"required,oneof=1 2"`
 }
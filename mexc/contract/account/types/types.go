@@ -18,6 +18,14 @@
 package types
 
 type Response struct {
-	Success bool `json:"success"`
-	Code    int  `json:"code"`
+	Success bool   `json:"success"`
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type Page struct {
+	PageSize    int `json:"pageSize"`
+	TotalCount  int `json:"totalCount"`
+	TotalPage   int `json:"totalPage"`
+	CurrentPage int `json:"currentPage"`
 }
@@ -20,11 +20,15 @@ package marketdata
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/go-playground/validator"
 	"github.com/jl1/nexapi/mexc/contract/marketdata/types"
 	"github.com/jl1/nexapi/mexc/contract/utils"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/valyala/fastjson"
 )
 
 type ContractMarketDataClient struct {
@@ -40,7 +44,7 @@ func NewContractMarketDataClient(cfg *utils.ContractClientCfg) (*ContractMarketD
 		return nil, err
 	}
 
-	validator := validator.New()
+	validator := mexcutils.NewValidator()
 
 	return &ContractMarketDataClient{
 		ContractClient: cli,
@@ -165,3 +169,370 @@ func (s *ContractMarketDataClient) GetTickerForAllSymbols(ctx context.Context) (
 
 	return &ret, nil
 }
+
+func (s *ContractMarketDataClient) GetDepth(ctx context.Context, param types.GetDepthParams) (*types.GetDepthResp, error) {
+	err := s.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    fmt.Sprintf("/api/v1/contract/depth/%s", param.Symbol),
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	headers, err := s.GenPubHeaders()
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetDepthResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// GetDepthCommits returns the latest param.Limit depth snapshots, ordered by version.
+func (s *ContractMarketDataClient) GetDepthCommits(ctx context.Context, param types.GetDepthCommitsParams) (*types.GetDepthCommitsResp, error) {
+	err := s.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    fmt.Sprintf("/api/v1/contract/depth_commits/%s/%d", param.Symbol, param.Limit),
+		Method:  http.MethodGet,
+	}
+
+	headers, err := s.GenPubHeaders()
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetDepthCommitsResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *ContractMarketDataClient) GetDeals(ctx context.Context, param types.GetDealsParams) (*types.GetDealsResp, error) {
+	err := s.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    fmt.Sprintf("/api/v1/contract/deals/%s", param.Symbol),
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	headers, err := s.GenPubHeaders()
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetDealsResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *ContractMarketDataClient) GetKlines(ctx context.Context, param types.GetKlineParam) ([]*types.Kline, error) {
+	return s.getKlines(ctx, "/api/v1/contract/kline/", param)
+}
+
+func (s *ContractMarketDataClient) GetIndexPriceKlines(ctx context.Context, param types.GetKlineParam) ([]*types.Kline, error) {
+	return s.getKlines(ctx, "/api/v1/contract/kline/index_price/", param)
+}
+
+func (s *ContractMarketDataClient) GetFairPriceKlines(ctx context.Context, param types.GetKlineParam) ([]*types.Kline, error) {
+	return s.getKlines(ctx, "/api/v1/contract/kline/fair_price/", param)
+}
+
+func (s *ContractMarketDataClient) getKlines(ctx context.Context, path string, param types.GetKlineParam) ([]*types.Kline, error) {
+	err := s.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    path + param.Symbol,
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	headers, err := s.GenPubHeaders()
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseKlines(resp, param.Interval)
+}
+
+// parseKlines converts the columnar kline response of MEXC into a slice of klines.
+func parseKlines(resp []byte, interval utils.KlineInterval) ([]*types.Kline, error) {
+	var p fastjson.Parser
+	js, err := p.ParseBytes(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if !js.GetBool("success") {
+		return nil, fmt.Errorf("get klines failed: %s", string(resp))
+	}
+
+	data := js.Get("data")
+	if data == nil {
+		return nil, fmt.Errorf("unknown kline response: %s", string(resp))
+	}
+
+	columns := make(map[string][]*fastjson.Value)
+	for _, name := range []string{"time", "open", "high", "low", "close", "vol", "amount"} {
+		column := data.GetArray(name)
+		if len(column) != len(data.GetArray("time")) {
+			return nil, fmt.Errorf("kline column %s has an unexpected length: %s", name, string(resp))
+		}
+		columns[name] = column
+	}
+
+	ret := make([]*types.Kline, 0, len(columns["time"]))
+	for i, v := range columns["time"] {
+		values := make(map[string]decimal.Decimal, len(columns))
+		for name, column := range columns {
+			if name == "time" {
+				continue
+			}
+
+			d, err := mexcutils.ParseDecimal(column[i])
+			if err != nil {
+				return nil, err
+			}
+			values[name] = d
+		}
+
+		openTime := v.GetInt64() * 1000
+
+		ret = append(ret, &types.Kline{
+			OpenTime:         openTime,
+			OpenPrice:        values["open"],
+			HighPrice:        values["high"],
+			LowPrice:         values["low"],
+			ClosePrice:       values["close"],
+			Volume:           values["vol"],
+			CloseTime:        interval.CloseTime(openTime),
+			QuoteAssetVolume: values["amount"],
+		})
+	}
+
+	return ret, nil
+}
+
+func (s *ContractMarketDataClient) GetFundingRate(ctx context.Context, param types.GetFundingRateParams) (*types.GetFundingRateResp, error) {
+	err := s.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    fmt.Sprintf("/api/v1/contract/funding_rate/%s", param.Symbol),
+		Method:  http.MethodGet,
+	}
+
+	headers, err := s.GenPubHeaders()
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetFundingRateResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *ContractMarketDataClient) GetFundingRateHistory(ctx context.Context, param types.GetFundingRateHistoryParams) (*types.GetFundingRateHistoryResp, error) {
+	err := s.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    "/api/v1/contract/funding_rate/history",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	headers, err := s.GenPubHeaders()
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetFundingRateHistoryResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *ContractMarketDataClient) GetIndexPrice(ctx context.Context, symbol string) (*types.GetIndexPriceResp, error) {
+	req := utils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    fmt.Sprintf("/api/v1/contract/index_price/%s", symbol),
+		Method:  http.MethodGet,
+	}
+
+	headers, err := s.GenPubHeaders()
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetIndexPriceResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *ContractMarketDataClient) GetFairPrice(ctx context.Context, symbol string) (*types.GetFairPriceResp, error) {
+	req := utils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    fmt.Sprintf("/api/v1/contract/fair_price/%s", symbol),
+		Method:  http.MethodGet,
+	}
+
+	headers, err := s.GenPubHeaders()
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetFairPriceResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// GetRiskReverse returns the current insurance fund balance of every contract.
+func (s *ContractMarketDataClient) GetRiskReverse(ctx context.Context) (*types.GetRiskReverseResp, error) {
+	req := utils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    "/api/v1/contract/risk_reverse",
+		Method:  http.MethodGet,
+	}
+
+	headers, err := s.GenPubHeaders()
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetRiskReverseResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *ContractMarketDataClient) GetRiskReverseHistory(ctx context.Context, param types.GetRiskReverseHistoryParams) (*types.GetRiskReverseHistoryResp, error) {
+	err := s.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	req := utils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    "/api/v1/contract/risk_reverse/history",
+		Method:  http.MethodGet,
+		Query:   param,
+	}
+
+	headers, err := s.GenPubHeaders()
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.GetRiskReverseHistoryResp
+	if err := json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -19,6 +19,9 @@ package marketdata
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/jl1/nexapi/mexc/contract/marketdata/types"
@@ -68,3 +71,199 @@ func TestGetTickerForAllSymbols(t *testing.T) {
 	_, err := cli.GetTickerForAllSymbols(context.TODO())
 	assert.Nil(t, err)
 }
+
+func TestGetDepth(t *testing.T) {
+	cli := testNewContractMarketDataClient(t)
+
+	_, err := cli.GetDepth(context.TODO(), types.GetDepthParams{
+		Symbol: "BTC_USDT",
+		Limit:  5,
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetDepthCommits(t *testing.T) {
+	cli := testNewContractMarketDataClient(t)
+
+	_, err := cli.GetDepthCommits(context.TODO(), types.GetDepthCommitsParams{
+		Symbol: "BTC_USDT",
+		Limit:  5,
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetDeals(t *testing.T) {
+	cli := testNewContractMarketDataClient(t)
+
+	_, err := cli.GetDeals(context.TODO(), types.GetDealsParams{
+		Symbol: "BTC_USDT",
+		Limit:  5,
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetKlines(t *testing.T) {
+	cli := testNewContractMarketDataClient(t)
+
+	_, err := cli.GetKlines(context.TODO(), types.GetKlineParam{
+		Symbol:   "BTC_USDT",
+		Interval: utils.Minute1,
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetFairPriceKlines(t *testing.T) {
+	cli := testNewContractMarketDataClient(t)
+
+	_, err := cli.GetFairPriceKlines(context.TODO(), types.GetKlineParam{
+		Symbol:   "BTC_USDT",
+		Interval: utils.Minute1,
+	})
+	assert.Nil(t, err)
+}
+
+func TestParseKlines(t *testing.T) {
+	klines, err := parseKlines([]byte(`{"success":true,"code":0,"data":{
+		"time":[1609740600,1609740660],
+		"open":[33016.5,33040.5],
+		"close":[33040.5,33000],
+		"high":[33094.0,33050],
+		"low":[32995.0,32990.5],
+		"vol":[67332.0,100],
+		"amount":[222515.85925,330000.1]
+	}}`), utils.Minute1)
+	assert.Nil(t, err)
+	assert.Len(t, klines, 2)
+	assert.Equal(t, int64(1609740600000), klines[0].OpenTime)
+	assert.Equal(t, int64(1609740659999), klines[0].CloseTime)
+	assert.Equal(t, "33016.5", klines[0].OpenPrice.String())
+	assert.Equal(t, "33094.0", klines[0].HighPrice.String())
+	assert.Equal(t, "32995.0", klines[0].LowPrice.String())
+	assert.Equal(t, "33040.5", klines[0].ClosePrice.String())
+	assert.Equal(t, "67332.0", klines[0].Volume.String())
+	assert.Equal(t, "222515.85925", klines[0].QuoteAssetVolume.String())
+	assert.Equal(t, int64(1609740660000), klines[1].OpenTime)
+	assert.Equal(t, int64(1609740719999), klines[1].CloseTime)
+	assert.Equal(t, "33000", klines[1].ClosePrice.String())
+	assert.Equal(t, "330000.1", klines[1].QuoteAssetVolume.String())
+
+	_, err = parseKlines([]byte(`{"success":true,"code":0,"data":{"time":[1609740600],"open":[]}}`), utils.Minute1)
+	assert.NotNil(t, err)
+}
+
+func TestGetFundingRate(t *testing.T) {
+	cli := testNewContractMarketDataClient(t)
+
+	_, err := cli.GetFundingRate(context.TODO(), types.GetFundingRateParams{
+		Symbol: "BTC_USDT",
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetFundingRateHistory(t *testing.T) {
+	cli := testNewContractMarketDataClient(t)
+
+	_, err := cli.GetFundingRateHistory(context.TODO(), types.GetFundingRateHistoryParams{
+		Symbol:   "BTC_USDT",
+		PageSize: 10,
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetIndexPrice(t *testing.T) {
+	cli := testNewContractMarketDataClient(t)
+
+	_, err := cli.GetIndexPrice(context.TODO(), "BTC_USDT")
+	assert.Nil(t, err)
+}
+
+func TestGetFairPrice(t *testing.T) {
+	cli := testNewContractMarketDataClient(t)
+
+	_, err := cli.GetFairPrice(context.TODO(), "BTC_USDT")
+	assert.Nil(t, err)
+}
+
+func TestGetRiskReverse(t *testing.T) {
+	cli := testNewContractMarketDataClient(t)
+
+	_, err := cli.GetRiskReverse(context.TODO())
+	assert.Nil(t, err)
+}
+
+func TestWalkRiskReverseHistory(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+
+		switch r.URL.Query().Get("page_num") {
+		case "1":
+			w.Write([]byte(`{"success":true,"code":0,"data":{"pageSize":2,"totalCount":3,"totalPage":2,"currentPage":1,"resultList":[{"symbol":"BTC_USDT","snapshotTime":1},{"symbol":"BTC_USDT","snapshotTime":2}]}}`))
+		default:
+			w.Write([]byte(`{"success":true,"code":0,"data":{"pageSize":2,"totalCount":3,"totalPage":2,"currentPage":2,"resultList":[{"symbol":"BTC_USDT","snapshotTime":3}]}}`))
+		}
+	}))
+	defer srv.Close()
+
+	cli, err := NewContractMarketDataClient(&utils.ContractClientCfg{
+		BaseURL:    srv.URL,
+		HTTPClient: srv.Client(),
+	})
+	assert.Nil(t, err)
+
+	param := types.GetRiskReverseHistoryParams{Symbol: "BTC_USDT", PageSize: 2}
+
+	var times []int64
+	err = cli.WalkRiskReverseHistory(context.TODO(), param, func(snapshots []*types.RiskReverseSnapshot) error {
+		for _, v := range snapshots {
+			times = append(times, v.SnapshotTime)
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, times)
+	assert.Equal(t, []string{
+		"page_num=1&page_size=2&symbol=BTC_USDT",
+		"page_num=2&page_size=2&symbol=BTC_USDT",
+	}, queries)
+
+	// the error of fn stops the walk and is returned wrapped
+	queries = nil
+	errStop := errors.New("stop")
+	var pages int
+	err = cli.WalkRiskReverseHistory(context.TODO(), param, func(snapshots []*types.RiskReverseSnapshot) error {
+		pages++
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.NotEqual(t, errStop, err)
+	assert.Equal(t, 1, pages)
+	assert.Len(t, queries, 1)
+}
+
+func TestWalkFundingRateHistoryWithoutCurrentPage(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		w.Write([]byte(`{"success":true,"code":0,"data":{"pageSize":1,"totalCount":2,"totalPage":2,"currentPage":0,"resultList":[{"symbol":"BTC_USDT","settleTime":1}]}}`))
+	}))
+	defer srv.Close()
+
+	cli, err := NewContractMarketDataClient(&utils.ContractClientCfg{
+		BaseURL:    srv.URL,
+		HTTPClient: srv.Client(),
+	})
+	assert.Nil(t, err)
+
+	var pages int
+	err = cli.WalkFundingRateHistory(context.TODO(), types.GetFundingRateHistoryParams{Symbol: "BTC_USDT", PageSize: 1}, func(records []*types.FundingRateRecord) error {
+		pages++
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, []string{
+		"page_num=1&page_size=1&symbol=BTC_USDT",
+		"page_num=2&page_size=1&symbol=BTC_USDT",
+	}, queries)
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package marketdata
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jl1/nexapi/mexc/contract/marketdata/types"
+)
+
+// WalkFundingRateHistory walks all pages of the funding rate history matching param,
+// starting at param.PageNum, and calls fn with each non-empty page. Walking stops at the
+// first error returned by fn, wrapped with the page number, or by the exchange.
+func (s *ContractMarketDataClient) WalkFundingRateHistory(ctx context.Context, param types.GetFundingRateHistoryParams, fn func([]*types.FundingRateRecord) error) error {
+	if param.PageNum == 0 {
+		param.PageNum = 1
+	}
+	if param.PageSize == 0 {
+		param.PageSize = 100
+	}
+
+	for {
+		resp, err := s.GetFundingRateHistory(ctx, param)
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("get funding rate history failed, code=%d message=%s", resp.Code, resp.Message)
+		}
+
+		if len(resp.Data.ResultList) > 0 {
+			if err := fn(resp.Data.ResultList); err != nil {
+				return fmt.Errorf("page %d: %w", param.PageNum, err)
+			}
+		}
+
+		if param.PageNum >= resp.Data.TotalPage || len(resp.Data.ResultList) == 0 {
+			return nil
+		}
+
+		param.PageNum++
+	}
+}
+
+// WalkRiskReverseHistory walks all pages of the insurance fund history matching param,
+// starting at param.PageNum, and calls fn with each non-empty page. Walking stops at the
+// first error returned by fn, wrapped with the page number, or by the exchange.
+func (s *ContractMarketDataClient) WalkRiskReverseHistory(ctx context.Context, param types.GetRiskReverseHistoryParams, fn func([]*types.RiskReverseSnapshot) error) error {
+	if param.PageNum == 0 {
+		param.PageNum = 1
+	}
+	if param.PageSize == 0 {
+		param.PageSize = 100
+	}
+
+	for {
+		resp, err := s.GetRiskReverseHistory(ctx, param)
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("get risk reverse history failed, code=%d message=%s", resp.Code, resp.Message)
+		}
+
+		if len(resp.Data.ResultList) > 0 {
+			if err := fn(resp.Data.ResultList); err != nil {
+				return fmt.Errorf("page %d: %w", param.PageNum, err)
+			}
+		}
+
+		if param.PageNum >= resp.Data.TotalPage || len(resp.Data.ResultList) == 0 {
+			return nil
+		}
+
+		param.PageNum++
+	}
+}
@@ -17,6 +17,8 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type GetContractDetailsParams struct {
 	Symbol string `url:"symbols,omitempty" validate:"omitempty"`
 }
@@ -27,38 +29,38 @@ type GetContractDetailsResp struct {
 }
 
 type ContractDetail struct {
-	Symbol                    string   `json:"symbol"`
-	DisplayName               string   `json:"displayName"`
-	DisplayNameEn             string   `json:"displayNameEn"`
-	PositionOpenType          int      `json:"positionOpenType"`
-	BaseCoin                  string   `json:"baseCoin"`
-	QuoteCoin                 string   `json:"quoteCoin"`
-	SettleCoin                string   `json:"settleCoin"`
-	ContractSize              float64  `json:"contractSize"`
-	MinLeverage               float64  `json:"minLeverage"`
-	MaxLeverage               float64  `json:"maxLeverage"`
-	PriceScale                float64  `json:"priceScale"`
-	VolScale                  float64  `json:"volScale"`
-	AmountScale               float64  `json:"amountScale"`
-	PriceUnit                 float64  `json:"priceUnit"`
-	VolUnit                   float64  `json:"volUnit"`
-	MinVol                    float64  `json:"minVol"`
-	MaxVol                    float64  `json:"maxVol"`
-	BidLimitPriceRate         float64  `json:"bidLimitPriceRate"`
-	AskLimitPriceRate         float64  `json:"askLimitPriceRate"`
-	TakerFeeRate              float64  `json:"takerFeeRate"`
-	MakerFeeRate              float64  `json:"makerFeeRate"`
-	MaintenanceMarginRate     float64  `json:"maintenanceMarginRate"`
-	InitialMarginRate         float64  `json:"initialMarginRate"`
-	RiskBaseVol               float64  `json:"riskBaseVol"`
-	RiskIncrVol               float64  `json:"riskIncrVol"`
-	RiskIncrMmr               float64  `json:"riskIncrMmr"`
-	RiskIncrImr               float64  `json:"riskIncrImr"`
-	RiskLevelLimit            float64  `json:"riskLevelLimit"`
-	PriceCoefficientVariation float64  `json:"priceCoefficientVariation"`
-	IndexOrigin               []string `json:"indexOrigin"`
-	State                     int      `json:"state"`
-	ApiAllowed                bool     `json:"apiAllowed"`
-	ConceptPlate              []string `json:"conceptPlate"`
-	RiskLimitType             string   `json:"riskLimitType"`
+	Symbol                    string          `json:"symbol"`
+	DisplayName               string          `json:"displayName"`
+	DisplayNameEn             string          `json:"displayNameEn"`
+	PositionOpenType          int             `json:"positionOpenType"`
+	BaseCoin                  string          `json:"baseCoin"`
+	QuoteCoin                 string          `json:"quoteCoin"`
+	SettleCoin                string          `json:"settleCoin"`
+	ContractSize              decimal.Decimal `json:"contractSize"`
+	MinLeverage               decimal.Decimal `json:"minLeverage"`
+	MaxLeverage               decimal.Decimal `json:"maxLeverage"`
+	PriceScale                decimal.Decimal `json:"priceScale"`
+	VolScale                  decimal.Decimal `json:"volScale"`
+	AmountScale               decimal.Decimal `json:"amountScale"`
+	PriceUnit                 decimal.Decimal `json:"priceUnit"`
+	VolUnit                   decimal.Decimal `json:"volUnit"`
+	MinVol                    decimal.Decimal `json:"minVol"`
+	MaxVol                    decimal.Decimal `json:"maxVol"`
+	BidLimitPriceRate         decimal.Decimal `json:"bidLimitPriceRate"`
+	AskLimitPriceRate         decimal.Decimal `json:"askLimitPriceRate"`
+	TakerFeeRate              decimal.Decimal `json:"takerFeeRate"`
+	MakerFeeRate              decimal.Decimal `json:"makerFeeRate"`
+	MaintenanceMarginRate     decimal.Decimal `json:"maintenanceMarginRate"`
+	InitialMarginRate         decimal.Decimal `json:"initialMarginRate"`
+	RiskBaseVol               decimal.Decimal `json:"riskBaseVol"`
+	RiskIncrVol               decimal.Decimal `json:"riskIncrVol"`
+	RiskIncrMmr               decimal.Decimal `json:"riskIncrMmr"`
+	RiskIncrImr               decimal.Decimal `json:"riskIncrImr"`
+	RiskLevelLimit            decimal.Decimal `json:"riskLevelLimit"`
+	PriceCoefficientVariation decimal.Decimal `json:"priceCoefficientVariation"`
+	IndexOrigin               []string        `json:"indexOrigin"`
+	State                     int             `json:"state"`
+	ApiAllowed                bool            `json:"apiAllowed"`
+	ConceptPlate              []string        `json:"conceptPlate"`
+	RiskLimitType             string          `json:"riskLimitType"`
 }
@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+type GetDepthParams struct {
+	Symbol string `url:"-" validate:"required"`
+	Limit  int    `url:"limit,omitempty" validate:"omitempty"`
+}
+
+type GetDepthResp struct {
+	Response
+	Data *Depth `json:"data"`
+}
+
+type Depth struct {
+	Asks      []*DepthLevel `json:"asks"`
+	Bids      []*DepthLevel `json:"bids"`
+	Version   int64         `json:"version"`
+	Timestamp int64         `json:"timestamp"`
+}
+
+// DepthLevel is a price level of the order book, sent by MEXC as [price, vol, orderCount].
+type DepthLevel struct {
+	Price      decimal.Decimal
+	Vol        decimal.Decimal
+	OrderCount int
+}
+
+func (l *DepthLevel) UnmarshalJSON(data []byte) error {
+	var v []json.Number
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if len(v) != 3 {
+		return fmt.Errorf("unknown depth level: %s", string(data))
+	}
+
+	price, err := decimal.Parse(v[0].String())
+	if err != nil {
+		return err
+	}
+
+	vol, err := decimal.Parse(v[1].String())
+	if err != nil {
+		return err
+	}
+
+	count, err := strconv.Atoi(v[2].String())
+	if err != nil {
+		return err
+	}
+
+	l.Price = price
+	l.Vol = vol
+	l.OrderCount = count
+
+	return nil
+}
+
+func (l DepthLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{l.Price, l.Vol, l.OrderCount})
+}
+
+type GetDepthCommitsParams struct {
+	Symbol string `url:"-" validate:"required"`
+	Limit  int    `url:"-" validate:"required"`
+}
+
+type GetDepthCommitsResp struct {
+	Response
+	Data []*Depth `json:"data"`
+}
+
+type GetDealsParams struct {
+	Symbol string `url:"-" validate:"required"`
+	Limit  int    `url:"limit,omitempty" validate:"omitempty,max=100"`
+}
+
+type GetDealsResp struct {
+	Response
+	Data []*Deal `json:"data"`
+}
+
+type Deal struct {
+	P decimal.Decimal `json:"p"` // Price
+	V decimal.Decimal `json:"v"` // Volume
+	T int             `json:"T"` // Deal type, 1: purchase, 2: sell
+	O int             `json:"O"` // Open position, 1: yes, 2: no; vol is the additional position when O is 1
+	M int             `json:"M"` // Self-transact, 1: yes, 2: no
+	// Timestamp
+	Ts int64 `json:"t"`
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDepthLevelUnmarshal(t *testing.T) {
+	var depth Depth
+	err := json.Unmarshal([]byte(`{"asks":[[6859.5,3251,1]],"bids":[[6859.4,1,2],[6859.3,0.5,4]],"version":96801927,"timestamp":1587442022003}`), &depth)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "6859.5", depth.Asks[0].Price.String())
+	assert.Equal(t, "3251", depth.Asks[0].Vol.String())
+	assert.Equal(t, 1, depth.Asks[0].OrderCount)
+	assert.Equal(t, "6859.3", depth.Bids[1].Price.String())
+	assert.Equal(t, "0.5", depth.Bids[1].Vol.String())
+	assert.Equal(t, 4, depth.Bids[1].OrderCount)
+	assert.Equal(t, int64(96801927), depth.Version)
+
+	err = json.Unmarshal([]byte(`[1,2]`), &DepthLevel{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"time"
+
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+type GetFundingRateParams struct {
+	Symbol string `url:"-" validate:"required"`
+}
+
+type GetFundingRateResp struct {
+	Response
+	Data *FundingRate `json:"data"`
+}
+
+type FundingRate struct {
+	Symbol         string          `json:"symbol"`
+	FundingRate    decimal.Decimal `json:"fundingRate"`
+	MaxFundingRate decimal.Decimal `json:"maxFundingRate"`
+	MinFundingRate decimal.Decimal `json:"minFundingRate"`
+	CollectCycle   int             `json:"collectCycle"` // in hours
+	NextSettleTime int64           `json:"nextSettleTime"`
+	Timestamp      int64           `json:"timestamp"`
+}
+
+// UpcomingSettleTimes returns the next n funding settlement times in milliseconds,
+// starting at NextSettleTime and spaced by CollectCycle.
+func (f *FundingRate) UpcomingSettleTimes(n int) []int64 {
+	if f.CollectCycle <= 0 || n <= 0 {
+		return nil
+	}
+
+	cycle := (time.Duration(f.CollectCycle) * time.Hour).Milliseconds()
+
+	ret := make([]int64, 0, n)
+	for i := 0; i < n; i++ {
+		ret = append(ret, f.NextSettleTime+int64(i)*cycle)
+	}
+
+	return ret
+}
+
+type GetFundingRateHistoryParams struct {
+	Symbol   string `url:"symbol" validate:"required"`
+	PageNum  int    `url:"page_num,omitempty" validate:"omitempty"`
+	PageSize int    `url:"page_size,omitempty" validate:"omitempty,max=1000"`
+}
+
+type GetFundingRateHistoryResp struct {
+	Response
+	Data struct {
+		Page
+		ResultList []*FundingRateRecord `json:"resultList"`
+	} `json:"data"`
+}
+
+type FundingRateRecord struct {
+	Symbol      string          `json:"symbol"`
+	FundingRate decimal.Decimal `json:"fundingRate"`
+	SettleTime  int64           `json:"settleTime"`
+}
+
+type GetIndexPriceResp struct {
+	Response
+	Data struct {
+		Symbol     string          `json:"symbol"`
+		IndexPrice decimal.Decimal `json:"indexPrice"`
+		Timestamp  int64           `json:"timestamp"`
+	} `json:"data"`
+}
+
+type GetFairPriceResp struct {
+	Response
+	Data struct {
+		Symbol    string          `json:"symbol"`
+		FairPrice decimal.Decimal `json:"fairPrice"`
+		Timestamp int64           `json:"timestamp"`
+	} `json:"data"`
+}
@@ -0,0 +1,34 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpcomingSettleTimes(t *testing.T) {
+	rate := FundingRate{
+		CollectCycle:   8,
+		NextSettleTime: 1587456000000,
+	}
+
+	assert.Equal(t, []int64{1587456000000, 1587484800000, 1587513600000}, rate.UpcomingSettleTimes(3))
+	assert.Nil(t, (&FundingRate{}).UpcomingSettleTimes(3))
+}
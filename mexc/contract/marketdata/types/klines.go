@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"github.com/jl1/nexapi/mexc/contract/utils"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+type GetKlineParam struct {
+	Symbol   string              `url:"-" validate:"required"`
+	Interval utils.KlineInterval `url:"interval" validate:"required,oneof=Min1 Min5 Min15 Min30 Min60 Hour4 Hour8 Day1 Week1 Month1"`
+	// start and end are in seconds
+	Start int64 `url:"start,omitempty" validate:"omitempty"`
+	End   int64 `url:"end,omitempty" validate:"omitempty"`
+}
+
+// Kline has the same shape as the spot kline, times are in milliseconds.
+type Kline struct {
+	OpenTime         int64           `json:"openTime"`
+	OpenPrice        decimal.Decimal `json:"openPrice"`
+	HighPrice        decimal.Decimal `json:"highPrice"`
+	LowPrice         decimal.Decimal `json:"lowPrice"`
+	ClosePrice       decimal.Decimal `json:"closePrice"`
+	Volume           decimal.Decimal `json:"volume"`
+	CloseTime        int64           `json:"closeTime"`
+	QuoteAssetVolume decimal.Decimal `json:"quoteAssetVolume"`
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import "github.com/jl1/nexapi/utils/decimal"
+
+type GetRiskReverseResp struct {
+	Response
+	Data []*RiskReverse `json:"data"`
+}
+
+// RiskReverse is the insurance fund balance of a contract.
+type RiskReverse struct {
+	Symbol    string          `json:"symbol"`
+	Currency  string          `json:"currency"`
+	Available decimal.Decimal `json:"available"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+type GetRiskReverseHistoryParams struct {
+	Symbol   string `url:"symbol" validate:"required"`
+	PageNum  int    `url:"page_num,omitempty" validate:"omitempty"`
+	PageSize int    `url:"page_size,omitempty" validate:"omitempty,max=100"`
+}
+
+type GetRiskReverseHistoryResp struct {
+	Response
+	Data struct {
+		Page
+		ResultList []*RiskReverseSnapshot `json:"resultList"`
+	} `json:"data"`
+}
+
+type RiskReverseSnapshot struct {
+	Symbol       string          `json:"symbol"`
+	Currency     string          `json:"currency"`
+	Available    decimal.Decimal `json:"available"`
+	SnapshotTime int64           `json:"snapshotTime"`
+}
@@ -18,8 +18,9 @@
 package types
 
 type Response struct {
-	Success bool `json:"success"`
-	Code    int  `json:"code"`
+	Success bool   `json:"success"`
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
 }
 
 type ServerTime struct {
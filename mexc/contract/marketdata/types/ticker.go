@@ -17,6 +17,8 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type GetTickerForSymbolParam struct {
 	Symbol string `url:"symbol" validate:"required"`
 }
@@ -36,33 +38,33 @@ type GetAllTickersResp struct {
 }
 
 type Ticker struct {
-	ContractID    int     `json:"contractId"`
-	Symbol        string  `json:"symbol"`
-	LastPrice     float64 `json:"lastPrice"`
-	Bid1          float64 `json:"bid1"`
-	Ask1          float64 `json:"ask1"`
-	Volume24      float64 `json:"volume24"`
-	Amount24      float64 `json:"amount24"`
-	HoldVol       float64 `json:"holdVol"`
-	Lower24Price  float64 `json:"lower24Price"`
-	High24Price   float64 `json:"high24Price"`
-	RiseFallRate  float64 `json:"riseFallRate"`
-	RiseFallValue float64 `json:"riseFallValue"`
-	IndexPrice    float64 `json:"indexPrice"`
-	FairPrice     float64 `json:"fairPrice"`
-	FundingRate   float64 `json:"fundingRate"`
-	MaxBidPrice   float64 `json:"maxBidPrice"`
-	MinAskPrice   float64 `json:"minAskPrice"`
-	Timestamp     int64   `json:"timestamp"`
+	ContractID    int             `json:"contractId"`
+	Symbol        string          `json:"symbol"`
+	LastPrice     decimal.Decimal `json:"lastPrice"`
+	Bid1          decimal.Decimal `json:"bid1"`
+	Ask1          decimal.Decimal `json:"ask1"`
+	Volume24      decimal.Decimal `json:"volume24"`
+	Amount24      decimal.Decimal `json:"amount24"`
+	HoldVol       decimal.Decimal `json:"holdVol"`
+	Lower24Price  decimal.Decimal `json:"lower24Price"`
+	High24Price   decimal.Decimal `json:"high24Price"`
+	RiseFallRate  decimal.Decimal `json:"riseFallRate"`
+	RiseFallValue decimal.Decimal `json:"riseFallValue"`
+	IndexPrice    decimal.Decimal `json:"indexPrice"`
+	FairPrice     decimal.Decimal `json:"fairPrice"`
+	FundingRate   decimal.Decimal `json:"fundingRate"`
+	MaxBidPrice   decimal.Decimal `json:"maxBidPrice"`
+	MinAskPrice   decimal.Decimal `json:"minAskPrice"`
+	Timestamp     int64           `json:"timestamp"`
 	RiseFallRates struct {
-		Zone string  `json:"zone"`
-		R    float64 `json:"r"`
-		V    float64 `json:"v"`
-		R7   float64 `json:"r7"`
-		R30  float64 `json:"r30"`
-		R90  float64 `json:"r90"`
-		R180 float64 `json:"r180"`
-		R365 float64 `json:"r365"`
+		Zone string          `json:"zone"`
+		R    decimal.Decimal `json:"r"`
+		V    decimal.Decimal `json:"v"`
+		R7   decimal.Decimal `json:"r7"`
+		R30  decimal.Decimal `json:"r30"`
+		R90  decimal.Decimal `json:"r90"`
+		R180 decimal.Decimal `json:"r180"`
+		R365 decimal.Decimal `json:"r365"`
 	} `json:"riseFallRates"`
-	RiseFallRatesOfTimezone []float64 `json:"riseFallRatesOfTimezone"`
+	RiseFallRatesOfTimezone []decimal.Decimal `json:"riseFallRatesOfTimezone"`
 }
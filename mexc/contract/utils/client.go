@@ -30,7 +30,6 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/go-playground/validator"
@@ -162,11 +161,12 @@ func (c *ContractClient) GenAuthHeaders(req HTTPRequest) (map[string]string, err
 func (c *ContractClient) SendHTTPRequest(ctx context.Context, req HTTPRequest) ([]byte, error) {
 	var body io.Reader
 	if req.Body != nil {
-		formData, err := query.Values(req.Body)
+		// the body must match the signed content, see GenAuthHeaders
+		jsonBody, err := json.Marshal(req.Body)
 		if err != nil {
 			return nil, err
 		}
-		body = strings.NewReader(formData.Encode())
+		body = bytes.NewReader(jsonBody)
 	}
 
 	url, err := url.Parse(req.BaseURL + req.Path)
@@ -17,6 +17,8 @@
 
 package utils
 
+import "time"
+
 var (
 	BaseURL = "https://contract.mexc.com"
 )
@@ -35,3 +37,37 @@ var (
 	Week1    KlineInterval = "Week1"
 	Month1   KlineInterval = "Month1"
 )
+
+// CloseTime returns the close time in milliseconds of the kline opened at openTime,
+// which is in milliseconds too.
+func (k KlineInterval) CloseTime(openTime int64) int64 {
+	open := time.UnixMilli(openTime).UTC()
+
+	var end time.Time
+	switch k {
+	case Minute1:
+		end = open.Add(time.Minute)
+	case Minute5:
+		end = open.Add(5 * time.Minute)
+	case Minute15:
+		end = open.Add(15 * time.Minute)
+	case Minute30:
+		end = open.Add(30 * time.Minute)
+	case Minute60:
+		end = open.Add(time.Hour)
+	case Hour4:
+		end = open.Add(4 * time.Hour)
+	case Hour8:
+		end = open.Add(8 * time.Hour)
+	case Day1:
+		end = open.AddDate(0, 0, 1)
+	case Week1:
+		end = open.AddDate(0, 0, 7)
+	case Month1:
+		end = open.AddDate(0, 1, 0)
+	default:
+		return openTime
+	}
+
+	return end.UnixMilli() - 1
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package websocketmarket subscribes to the public contract market streams of MEXC,
+// see https://mexcdevelop.github.io/apidocs/contract_v1_en/#websocket-api.
+package websocketmarket
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	contractutils "github.com/jl1/nexapi/mexc/contract/utils"
+	"github.com/jl1/nexapi/mexc/contract/websocketmarket/types"
+	"github.com/jl1/nexapi/mexc/stream"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+)
+
+var ContractMarketStreamBaseURL = "wss://contract.mexc.com/edge"
+
+var (
+	ErrInvalidTopic = errors.New("invalid topic")
+	ErrUnknownTopic = errors.New("unknown topic")
+)
+
+type ContractMarketStreamCfg struct {
+	Debug bool
+	// Logger
+	Logger *slog.Logger
+
+	BaseURL       string `validate:"required"`
+	AutoReconnect bool
+}
+
+// ContractMarketStreamClient decodes the pushes of the contract market streams and
+// passes them to the listeners of their topic:
+//
+//   - deal topics emit *types.Deals
+//   - ticker topics emit *contracttypes.Ticker
+//   - depth topics emit *contracttypes.Depth, updates of the levels that changed
+//   - kline topics emit *types.Kline
+//   - funding rate topics emit *types.FundingRate
+//
+// Topics are the name of the channel and the symbol, like deal@BTC_USDT, followed by
+// the interval for klines.
+type ContractMarketStreamClient struct {
+	*stream.Conn
+	*stream.Emitter
+
+	logger *slog.Logger
+}
+
+func NewContractMarketStreamClient(cfg *ContractMarketStreamCfg) (*ContractMarketStreamClient, error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := stream.NewConn(&stream.ConnCfg{
+		Debug:         cfg.Debug,
+		Logger:        cfg.Logger,
+		URL:           cfg.BaseURL,
+		Protocol:      protocol{},
+		AutoReconnect: cfg.AutoReconnect,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cli := &ContractMarketStreamClient{
+		Conn:    conn,
+		Emitter: &stream.Emitter{},
+		logger:  cfg.Logger,
+	}
+
+	if cli.logger == nil {
+		cli.logger = slog.Default()
+	}
+
+	conn.OnMessage(func(msg *stream.Message) {
+		if err := cli.Dispatch(msg); err != nil {
+			cli.logger.Warn("contract stream message dropped", "topic", msg.Topic, "error", err)
+		}
+	})
+
+	return cli, nil
+}
+
+func (s *ContractMarketStreamClient) GetDealTopic(symbol string) (string, error) {
+	return topic("deal", symbol)
+}
+
+func (s *ContractMarketStreamClient) GetTickerTopic(symbol string) (string, error) {
+	return topic("ticker", symbol)
+}
+
+func (s *ContractMarketStreamClient) GetDepthTopic(symbol string) (string, error) {
+	return topic("depth", symbol)
+}
+
+func (s *ContractMarketStreamClient) GetKlineTopic(symbol string, interval contractutils.KlineInterval) (string, error) {
+	if interval == "" {
+		return "", fmt.Errorf("%w: missing interval", ErrInvalidTopic)
+	}
+	return topic("kline", symbol, string(interval))
+}
+
+func (s *ContractMarketStreamClient) GetFundingRateTopic(symbol string) (string, error) {
+	return topic("funding.rate", symbol)
+}
+
+func topic(channel, symbol string, params ...string) (string, error) {
+	if symbol == "" {
+		return "", fmt.Errorf("%w: missing symbol", ErrInvalidTopic)
+	}
+
+	return strings.Join(append([]string{channel, strings.ToUpper(symbol)}, params...), "@"), nil
+}
+
+// Decode returns the event of a pushed message.
+func (s *ContractMarketStreamClient) Decode(msg *stream.Message) (any, error) {
+	channel, _, _ := strings.Cut(msg.Topic, "@")
+
+	switch channel {
+	case "deal":
+		v := &types.Deals{Symbol: msg.Symbol, EventTime: msg.Time}
+		data := bytes.TrimSpace(msg.Data)
+		if len(data) > 0 && data[0] == '[' {
+			if err := json.Unmarshal(data, &v.Deals); err != nil {
+				return nil, err
+			}
+			return v, nil
+		}
+
+		var deal contracttypes.Deal
+		if err := json.Unmarshal(data, &deal); err != nil {
+			return nil, err
+		}
+		v.Deals = []*contracttypes.Deal{&deal}
+		return v, nil
+	case "ticker":
+		var v contracttypes.Ticker
+		if err := json.Unmarshal(msg.Data, &v); err != nil {
+			return nil, err
+		}
+		if v.Symbol == "" {
+			v.Symbol = msg.Symbol
+		}
+		return &v, nil
+	case "depth":
+		var v contracttypes.Depth
+		if err := json.Unmarshal(msg.Data, &v); err != nil {
+			return nil, err
+		}
+		if v.Timestamp == 0 {
+			v.Timestamp = msg.Time
+		}
+		return &v, nil
+	case "kline":
+		var v types.Kline
+		if err := json.Unmarshal(msg.Data, &v); err != nil {
+			return nil, err
+		}
+		if v.Symbol == "" {
+			v.Symbol = msg.Symbol
+		}
+		v.EventTime = msg.Time
+		return &v, nil
+	case "funding.rate":
+		var v types.FundingRate
+		if err := json.Unmarshal(msg.Data, &v); err != nil {
+			return nil, err
+		}
+		if v.Symbol == "" {
+			v.Symbol = msg.Symbol
+		}
+		v.EventTime = msg.Time
+		return &v, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTopic, msg.Topic)
+	}
+}
+
+// Dispatch decodes msg and emits its event to the listeners of its topic. Messages
+// are dispatched as they are received, Dispatch also feeds recorded messages.
+func (s *ContractMarketStreamClient) Dispatch(msg *stream.Message) error {
+	event, err := s.Decode(msg)
+	if err != nil {
+		return err
+	}
+
+	s.Emit(msg.Topic, event)
+
+	return nil
+}
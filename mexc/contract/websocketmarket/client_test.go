@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocketmarket
+
+import (
+	"encoding/json"
+	"testing"
+
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	contractutils "github.com/jl1/nexapi/mexc/contract/utils"
+	"github.com/jl1/nexapi/mexc/contract/websocketmarket/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequests(t *testing.T) {
+	cli, err := NewContractMarketStreamClient(&ContractMarketStreamCfg{BaseURL: ContractMarketStreamBaseURL})
+	assert.Nil(t, err)
+
+	deal, err := cli.GetDealTopic("btc_usdt")
+	assert.Nil(t, err)
+	kline, err := cli.GetKlineTopic("BTC_USDT", contractutils.Minute60)
+	assert.Nil(t, err)
+	assert.Equal(t, "kline@BTC_USDT@Min60", kline)
+
+	data, err := json.Marshal(protocol{}.Subscribe([]string{deal, kline}))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[
+		{"method":"sub.deal","param":{"symbol":"BTC_USDT"}},
+		{"method":"sub.kline","param":{"symbol":"BTC_USDT","interval":"Min60"}}
+	]`, string(data))
+
+	data, err = json.Marshal(protocol{}.Unsubscribe([]string{deal}))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"method":"unsub.deal","param":{"symbol":"BTC_USDT"}}]`, string(data))
+}
+
+func TestDispatch(t *testing.T) {
+	cli, err := NewContractMarketStreamClient(&ContractMarketStreamCfg{BaseURL: ContractMarketStreamBaseURL})
+	assert.Nil(t, err)
+
+	p := protocol{}
+
+	for _, v := range []string{`{"channel":"pong","data":1700000000000}`, `{"channel":"rs.sub.deal","data":"success","ts":1700000000000}`} {
+		msg, err := p.Parse([]byte(v))
+		assert.Nil(t, err)
+		assert.Nil(t, msg)
+	}
+
+	_, err = p.Parse([]byte(`{"channel":"rs.error","data":"invalid symbol"}`))
+	assert.NotNil(t, err)
+
+	var events []any
+	for _, topic := range []string{"deal@BTC_USDT", "depth@BTC_USDT", "kline@BTC_USDT@Min1", "funding.rate@BTC_USDT"} {
+		cli.AddListener(topic, func(e any) { events = append(events, e) })
+	}
+
+	for _, v := range []string{
+		`{"channel":"push.deal","data":{"M":1,"O":1,"T":1,"p":60000.5,"t":1700000000001,"v":10},"symbol":"BTC_USDT","ts":1700000000002}`,
+		`{"channel":"push.deal","data":[{"M":2,"O":2,"T":2,"p":60000,"t":1700000000003,"v":1},{"M":2,"O":2,"T":2,"p":59999,"t":1700000000003,"v":2}],"symbol":"BTC_USDT","ts":1700000000004}`,
+		`{"channel":"push.depth","data":{"asks":[[60001,5,1]],"bids":[],"version":42},"symbol":"BTC_USDT","ts":1700000000005}`,
+		`{"channel":"push.kline","data":{"a":1000,"c":60001,"h":60002,"interval":"Min1","l":59999,"o":60000,"q":100,"symbol":"BTC_USDT","t":1700000040},"symbol":"BTC_USDT","ts":1700000050000}`,
+		`{"channel":"push.funding.rate","data":{"rate":0.0001,"symbol":"BTC_USDT","nextSettleTime":1700006400000},"symbol":"BTC_USDT","ts":1700000000006}`,
+	} {
+		msg, err := p.Parse([]byte(v))
+		assert.Nil(t, err)
+		assert.Nil(t, cli.Dispatch(msg))
+	}
+
+	assert.Len(t, events, 5)
+
+	deals := events[0].(*types.Deals)
+	assert.Len(t, deals.Deals, 1)
+	assert.Equal(t, "60000.5", deals.Deals[0].P.String())
+	assert.Len(t, events[1].(*types.Deals).Deals, 2)
+
+	depth := events[2].(*contracttypes.Depth)
+	assert.Equal(t, int64(42), depth.Version)
+	assert.Equal(t, int64(1700000000005), depth.Timestamp)
+	assert.Equal(t, 1, depth.Asks[0].OrderCount)
+
+	kline := events[3].(*types.Kline)
+	assert.Equal(t, "Min1", kline.Interval)
+	assert.Equal(t, "100", kline.Volume.String())
+
+	funding := events[4].(*types.FundingRate)
+	assert.Equal(t, "0.0001", funding.Rate.String())
+	assert.Equal(t, int64(1700000000006), funding.EventTime)
+}
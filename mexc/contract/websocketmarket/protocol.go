@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocketmarket
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jl1/nexapi/mexc/stream"
+)
+
+type request struct {
+	Method string `json:"method"`
+	Param  *param `json:"param,omitempty"`
+}
+
+type param struct {
+	Symbol   string `json:"symbol"`
+	Interval string `json:"interval,omitempty"`
+}
+
+// envelope is a push {"channel":"push.deal","data":...,"symbol":...,"ts":...}, or a
+// response whose channel is pong, rs.sub.* or rs.error.
+type envelope struct {
+	Channel string          `json:"channel"`
+	Data    json.RawMessage `json:"data"`
+	Symbol  string          `json:"symbol"`
+	Ts      int64           `json:"ts"`
+}
+
+type protocol struct{}
+
+func (protocol) Subscribe(topics []string) []any {
+	return requests("sub.", topics)
+}
+
+func (protocol) Unsubscribe(topics []string) []any {
+	return requests("unsub.", topics)
+}
+
+// requests returns a request per topic, the contract streams take one channel and
+// symbol per request.
+func requests(prefix string, topics []string) []any {
+	ret := make([]any, 0, len(topics))
+	for _, v := range topics {
+		parts := strings.Split(v, "@")
+		if len(parts) < 2 {
+			continue
+		}
+
+		p := &param{Symbol: parts[1]}
+		if len(parts) > 2 {
+			p.Interval = parts[2]
+		}
+		ret = append(ret, &request{Method: prefix + parts[0], Param: p})
+	}
+
+	return ret
+}
+
+func (protocol) Ping() any {
+	return &request{Method: "ping"}
+}
+
+func (protocol) Parse(data []byte) (*stream.Message, error) {
+	var v envelope
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	channel, ok := strings.CutPrefix(v.Channel, "push.")
+	if !ok {
+		if v.Channel == "rs.error" {
+			return nil, fmt.Errorf("stream error: %s", string(v.Data))
+		}
+		return nil, nil
+	}
+
+	msg := &stream.Message{Symbol: v.Symbol, Time: v.Ts, Data: v.Data}
+
+	// klines of every interval are pushed on the same channel
+	if channel == "kline" {
+		var k struct {
+			Symbol   string `json:"symbol"`
+			Interval string `json:"interval"`
+		}
+		if err := json.Unmarshal(v.Data, &k); err != nil {
+			return nil, err
+		}
+		if msg.Symbol == "" {
+			msg.Symbol = k.Symbol
+		}
+		msg.Topic = channel + "@" + msg.Symbol + "@" + k.Interval
+		return msg, nil
+	}
+
+	msg.Topic = channel + "@" + msg.Symbol
+
+	return msg, nil
+}
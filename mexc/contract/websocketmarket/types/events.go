@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// Deals holds the deals of a push, MEXC sends either one deal or a list of them.
+type Deals struct {
+	Symbol    string                `json:"symbol"`
+	EventTime int64                 `json:"eventTime"`
+	Deals     []*contracttypes.Deal `json:"deals"`
+}
+
+type Kline struct {
+	Symbol    string `json:"symbol"`
+	EventTime int64  `json:"eventTime"`
+	Interval  string `json:"interval"`
+	// OpenTime is in seconds
+	OpenTime int64           `json:"t"`
+	Open     decimal.Decimal `json:"o"`
+	High     decimal.Decimal `json:"h"`
+	Low      decimal.Decimal `json:"l"`
+	Close    decimal.Decimal `json:"c"`
+	// Amount is the traded value and Volume the traded number of contracts
+	Amount decimal.Decimal `json:"a"`
+	Volume decimal.Decimal `json:"q"`
+}
+
+type FundingRate struct {
+	Symbol         string          `json:"symbol"`
+	EventTime      int64           `json:"eventTime"`
+	Rate           decimal.Decimal `json:"rate"`
+	NextSettleTime int64           `json:"nextSettleTime"`
+}
@@ -0,0 +1,279 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+)
+
+const (
+	// MaxAggTrades is the maximum number of aggregate trades returned by a single request.
+	MaxAggTrades = 1000
+	// MaxAggTradesWindow is the longest time window accepted by the API.
+	MaxAggTradesWindow = time.Hour
+)
+
+var (
+	ErrSaturatedWindow = errors.New("more aggregate trades than the page size in one millisecond")
+	ErrMissingTradeIDs = errors.New("aggregate trade ids are not contiguous")
+	ErrNoTradeIDs      = errors.New("aggregate trades have no ids")
+)
+
+// AggTradeClient is implemented by marketdata.SpotMarketDataClient.
+type AggTradeClient interface {
+	GetAggTrades(ctx context.Context, param spottypes.GetAggTradesParam) ([]*spottypes.AggTrade, error)
+}
+
+// AggTradeCheckpoint records the progress of a download, persist it after each chunk
+// to resume an interrupted download with AggTradeDownloader.Resume.
+type AggTradeCheckpoint struct {
+	Symbol string `json:"symbol"`
+	// NextTime is the start of the next window in milliseconds
+	NextTime int64 `json:"nextTime"`
+	End      int64 `json:"end"`
+	// LastID is the id of the last aggregate trade, zero when the API does not report ids
+	LastID int `json:"lastId,omitempty"`
+	// Unverified is set once trades without ids were accepted, the download could
+	// not be checked for duplicate or missing trades
+	Unverified bool `json:"unverified,omitempty"`
+}
+
+// Done reports whether the download reached its end.
+func (c *AggTradeCheckpoint) Done() bool {
+	return c.NextTime > c.End
+}
+
+// Save writes the checkpoint to path atomically.
+func (c *AggTradeCheckpoint) Save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func LoadAggTradeCheckpoint(path string) (*AggTradeCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret AggTradeCheckpoint
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// AggTradeChunk holds the aggregate trades of one window ordered by time, and the
+// checkpoint to resume after them.
+type AggTradeChunk struct {
+	Trades     []*spottypes.AggTrade
+	Checkpoint AggTradeCheckpoint
+	// Unverified is set when trades of the chunk have no ids, see
+	// AggTradeDownloaderCfg.AllowMissingIDs
+	Unverified bool
+}
+
+type AggTradeDownloaderCfg struct {
+	// Logger
+	Logger *slog.Logger
+
+	Client AggTradeClient `validate:"required"`
+	// Window is the initial time window of a request, defaults to and is capped at
+	// MaxAggTradesWindow
+	Window time.Duration `validate:"gte=0"`
+	// MinInterval is the minimum delay between two requests, defaults to DefaultMinInterval
+	MinInterval time.Duration
+	// Retries is the number of times a failed request is retried, the delay starts
+	// at RetryDelay and doubles after every attempt
+	Retries    int `validate:"gte=0"`
+	RetryDelay time.Duration
+	// AllowMissingIDs accepts the trades whose ids the API omits, as it usually does,
+	// as served and marks their chunks Unverified, duplicate and missing trades are not
+	// detected among them. By default the download fails with ErrNoTradeIDs.
+	AllowMissingIDs bool
+}
+
+// AggTradeDownloader pages through aggregate trades by time window. A page as large
+// as MaxAggTrades may be truncated, so its window is halved and requested again
+// until the page is complete; the window grows back when pages are sparse.
+type AggTradeDownloader struct {
+	client          AggTradeClient
+	window          int64
+	allowMissingIDs bool
+	throttle        *throttle
+}
+
+func NewAggTradeDownloader(cfg *AggTradeDownloaderCfg) (*AggTradeDownloader, error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &AggTradeDownloader{
+		client:          cfg.Client,
+		window:          cfg.Window.Milliseconds(),
+		allowMissingIDs: cfg.AllowMissingIDs,
+		throttle:        newThrottle(cfg.MinInterval, cfg.Retries, cfg.RetryDelay, cfg.Logger),
+	}
+
+	if d.window <= 0 || d.window > MaxAggTradesWindow.Milliseconds() {
+		d.window = MaxAggTradesWindow.Milliseconds()
+	}
+
+	return d, nil
+}
+
+// Download fetches the aggregate trades of symbol between start and end, in
+// milliseconds, and passes them to fn window by window. An end of zero or in the
+// future means now.
+func (d *AggTradeDownloader) Download(ctx context.Context, symbol string, start, end int64, fn func(*AggTradeChunk) error) error {
+	if now := time.Now().UnixMilli(); end <= 0 || end > now {
+		end = now
+	}
+
+	if start > end {
+		return fmt.Errorf("%w: %d > %d", ErrInvalidRange, start, end)
+	}
+
+	return d.Resume(ctx, &AggTradeCheckpoint{Symbol: symbol, NextTime: start, End: end}, fn)
+}
+
+// Resume continues the download recorded by cp.
+func (d *AggTradeDownloader) Resume(ctx context.Context, cp *AggTradeCheckpoint, fn func(*AggTradeChunk) error) error {
+	state := *cp
+	window := d.window
+	maxWindow := MaxAggTradesWindow.Milliseconds()
+
+	for !state.Done() {
+		windowEnd := min(state.NextTime+window-1, state.End)
+
+		var trades []*spottypes.AggTrade
+		err := d.throttle.do(ctx, func() (err error) {
+			trades, err = d.client.GetAggTrades(ctx, spottypes.GetAggTradesParam{
+				Symbol:    state.Symbol,
+				StartTime: state.NextTime,
+				EndTime:   windowEnd,
+				Limit:     MaxAggTrades,
+			})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		// the page may be truncated, request a smaller window
+		if len(trades) >= MaxAggTrades {
+			if window == 1 {
+				return fmt.Errorf("%w: %s at %d", ErrSaturatedWindow, state.Symbol, state.NextTime)
+			}
+			window = max(window/2, 1)
+			continue
+		}
+
+		chunk, err := d.accept(&state, trades, windowEnd)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(chunk); err != nil {
+			return err
+		}
+
+		if len(trades) < MaxAggTrades/4 {
+			window = min(window*2, maxWindow)
+		}
+	}
+
+	return nil
+}
+
+// accept sorts trades, drops the ones outside of the window or already seen, checks
+// the continuity of ids and moves state past windowEnd. Windows do not overlap, so
+// trades without ids are all kept, two trades of the same millisecond, price,
+// quantity and side are distinct trades.
+func (d *AggTradeDownloader) accept(state *AggTradeCheckpoint, trades []*spottypes.AggTrade, windowEnd int64) (*AggTradeChunk, error) {
+	sort.SliceStable(trades, func(i, j int) bool {
+		if trades[i].T != trades[j].T {
+			return trades[i].T < trades[j].T
+		}
+		return trades[i].A < trades[j].A
+	})
+
+	chunk := &AggTradeChunk{}
+	for _, v := range trades {
+		if v.T < state.NextTime || v.T > windowEnd {
+			continue
+		}
+
+		if v.A == 0 {
+			if !d.allowMissingIDs {
+				return nil, fmt.Errorf("%w: %s at %d", ErrNoTradeIDs, state.Symbol, v.T)
+			}
+			chunk.Unverified, state.Unverified = true, true
+		} else {
+			if v.A <= state.LastID {
+				continue
+			}
+
+			if state.LastID != 0 && v.A != state.LastID+1 {
+				return nil, fmt.Errorf("%w: %s %d after %d", ErrMissingTradeIDs, state.Symbol, v.A, state.LastID)
+			}
+
+			state.LastID = v.A
+		}
+
+		chunk.Trades = append(chunk.Trades, v)
+	}
+
+	state.NextTime = windowEnd + 1
+	chunk.Checkpoint = *state
+
+	return chunk, nil
+}
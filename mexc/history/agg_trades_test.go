@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAggTradeClient serves the trades opened within the requested window, inclusive
+// of both ends, and truncates pages to the limit like the API.
+type fakeAggTradeClient struct {
+	trades  []*spottypes.AggTrade
+	windows [][2]int64
+}
+
+func (f *fakeAggTradeClient) GetAggTrades(ctx context.Context, param spottypes.GetAggTradesParam) ([]*spottypes.AggTrade, error) {
+	f.windows = append(f.windows, [2]int64{param.StartTime, param.EndTime})
+
+	var ret []*spottypes.AggTrade
+	for _, v := range f.trades {
+		if v.T >= param.StartTime && v.T <= param.EndTime && len(ret) < param.Limit {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret, nil
+}
+
+func newFakeAggTrades(times ...int64) []*spottypes.AggTrade {
+	var ret []*spottypes.AggTrade
+	for i, v := range times {
+		ret = append(ret, &spottypes.AggTrade{A: i + 1, T: v})
+	}
+	return ret
+}
+
+func TestAggTradeDownload(t *testing.T) {
+	// a burst saturating the first window forces it to shrink
+	var times []int64
+	for i := 0; i < 1500; i++ {
+		times = append(times, int64(i))
+	}
+	times = append(times, 5000, 3_000_000)
+
+	cli := &fakeAggTradeClient{trades: newFakeAggTrades(times...)}
+	d, err := NewAggTradeDownloader(&AggTradeDownloaderCfg{Client: cli, MinInterval: time.Microsecond})
+	assert.Nil(t, err)
+
+	var (
+		ids  []int
+		last AggTradeCheckpoint
+	)
+	err = d.Download(context.TODO(), "BTCUSDT", 0, 4_000_000, func(chunk *AggTradeChunk) error {
+		for _, v := range chunk.Trades {
+			ids = append(ids, v.A)
+		}
+		last = chunk.Checkpoint
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, ids, len(times))
+	for i, v := range ids {
+		assert.Equal(t, i+1, v)
+	}
+	assert.True(t, last.Done())
+	assert.Equal(t, len(times), last.LastID)
+	assert.Equal(t, [2]int64{0, 3_599_999}, cli.windows[0])
+}
+
+func TestAggTradeResume(t *testing.T) {
+	cli := &fakeAggTradeClient{trades: newFakeAggTrades(10, 20, 30, 40)}
+	d, err := NewAggTradeDownloader(&AggTradeDownloaderCfg{
+		Client:      cli,
+		Window:      15 * time.Millisecond,
+		MinInterval: time.Microsecond,
+	})
+	assert.Nil(t, err)
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	stop := errors.New("stop")
+
+	err = d.Download(context.TODO(), "BTCUSDT", 0, 44, func(chunk *AggTradeChunk) error {
+		if err := chunk.Checkpoint.Save(path); err != nil {
+			return err
+		}
+		if len(chunk.Trades) > 0 {
+			return stop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+
+	cp, err := LoadAggTradeCheckpoint(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, cp.LastID)
+
+	var ids []int
+	err = d.Resume(context.TODO(), cp, func(chunk *AggTradeChunk) error {
+		for _, v := range chunk.Trades {
+			ids = append(ids, v.A)
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 3, 4}, ids)
+}
+
+func TestAggTradeMissingIDs(t *testing.T) {
+	trades := newFakeAggTrades(10, 20)
+	trades[1].A = 3
+
+	d, err := NewAggTradeDownloader(&AggTradeDownloaderCfg{
+		Client:      &fakeAggTradeClient{trades: trades},
+		MinInterval: time.Microsecond,
+	})
+	assert.Nil(t, err)
+
+	err = d.Download(context.TODO(), "BTCUSDT", 0, 100, func(chunk *AggTradeChunk) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrMissingTradeIDs)
+}
+
+func TestAggTradeWithoutIDs(t *testing.T) {
+	// identical trades of the same millisecond are distinct trades
+	trades := newFakeAggTrades(10, 10, 20)
+	for _, v := range trades {
+		v.A = 0
+	}
+
+	d, err := NewAggTradeDownloader(&AggTradeDownloaderCfg{
+		Client:          &fakeAggTradeClient{trades: trades},
+		MinInterval:     time.Microsecond,
+		AllowMissingIDs: true,
+	})
+	assert.Nil(t, err)
+
+	var (
+		count int
+		last  *AggTradeChunk
+	)
+	err = d.Download(context.TODO(), "BTCUSDT", 0, 100, func(chunk *AggTradeChunk) error {
+		count += len(chunk.Trades)
+		last = chunk
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, count)
+	assert.True(t, last.Unverified)
+	assert.True(t, last.Checkpoint.Unverified)
+
+	d, err = NewAggTradeDownloader(&AggTradeDownloaderCfg{
+		Client:      &fakeAggTradeClient{trades: trades},
+		MinInterval: time.Microsecond,
+	})
+	assert.Nil(t, err)
+
+	err = d.Download(context.TODO(), "BTCUSDT", 0, 100, func(chunk *AggTradeChunk) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrNoTradeIDs)
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	"github.com/jl1/nexapi/utils/candle"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// FromSpotKlines converts spot klines, e.g. to resample them with candle.Resample.
+func FromSpotKlines(klines []*spottypes.Kline) []*candle.Candle {
+	ret := make([]*candle.Candle, 0, len(klines))
+	for _, v := range klines {
+		ret = append(ret, FromSpotKline(v))
+	}
+
+	return ret
+}
+
+// FromContractKlines converts contract klines, their volume is converted from contracts
+// to the base coin with contractSize like in AddContractDeal.
+func FromContractKlines(klines []*contracttypes.Kline, contractSize decimal.Decimal) []*candle.Candle {
+	ret := make([]*candle.Candle, 0, len(klines))
+	for _, v := range klines {
+		ret = append(ret, FromContractKline(v, contractSize))
+	}
+
+	return ret
+}
+
+func AddSpotTrade(b *candle.Builder, t *spottypes.Trade) error {
+	return b.Add(t.Time, t.Price, t.Qty)
+}
+
+func AddAggTrade(b *candle.Builder, t *spottypes.AggTrade) error {
+	return b.Add(t.T, t.P, t.Q)
+}
+
+// AddContractDeal adds a contract deal, its volume is counted in contracts and is
+// converted to the base coin with contractSize, see ContractDetail.ContractSize.
+func AddContractDeal(b *candle.Builder, deal *contracttypes.Deal, contractSize decimal.Decimal) error {
+	return b.Add(deal.Ts, deal.P, deal.V.Mul(contractSize))
+}
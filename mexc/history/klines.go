@@ -0,0 +1,314 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package history downloads long ranges of MEXC market data in chunks sized for the
+// REST API limits.
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	contractutils "github.com/jl1/nexapi/mexc/contract/utils"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	spotutils "github.com/jl1/nexapi/mexc/spot/utils"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+	"github.com/jl1/nexapi/utils/candle"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// MaxKlines is the maximum number of klines returned by a single request.
+const MaxKlines = 1000
+
+var (
+	ErrInvalidRange    = errors.New("start is after end")
+	ErrInvalidInterval = errors.New("unsupported kline interval")
+)
+
+// KlineFetcher loads the klines of one symbol and interval.
+type KlineFetcher interface {
+	// FetchKlines returns at most limit klines opened between start and end, in milliseconds.
+	FetchKlines(ctx context.Context, start, end int64, limit int) ([]*candle.Candle, error)
+	// CloseTime returns the close time of the kline opened at openTime.
+	CloseTime(openTime int64) int64
+}
+
+// SpotKlineClient is implemented by marketdata.SpotMarketDataClient.
+type SpotKlineClient interface {
+	GetKlines(ctx context.Context, param spottypes.GetKlineParam) ([]*spottypes.Kline, error)
+}
+
+// ContractKlineClient is implemented by marketdata.ContractMarketDataClient.
+type ContractKlineClient interface {
+	GetKlines(ctx context.Context, param contracttypes.GetKlineParam) ([]*contracttypes.Kline, error)
+}
+
+type spotKlineFetcher struct {
+	cli      SpotKlineClient
+	symbol   string
+	interval spotutils.KlineInterval
+}
+
+func NewSpotKlineFetcher(cli SpotKlineClient, symbol string, interval spotutils.KlineInterval) KlineFetcher {
+	return &spotKlineFetcher{cli: cli, symbol: symbol, interval: interval}
+}
+
+func (f *spotKlineFetcher) FetchKlines(ctx context.Context, start, end int64, limit int) ([]*candle.Candle, error) {
+	klines, err := f.cli.GetKlines(ctx, spottypes.GetKlineParam{
+		Symbol:    f.symbol,
+		Interval:  f.interval,
+		StartTime: start,
+		EndTime:   end,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return FromSpotKlines(klines), nil
+}
+
+func (f *spotKlineFetcher) CloseTime(openTime int64) int64 {
+	return f.interval.CloseTime(openTime)
+}
+
+type contractKlineFetcher struct {
+	cli          ContractKlineClient
+	symbol       string
+	interval     contractutils.KlineInterval
+	contractSize decimal.Decimal
+}
+
+// NewContractKlineFetcher fetches the klines of a contract, their volume is converted
+// to the base coin with contractSize.
+func NewContractKlineFetcher(cli ContractKlineClient, symbol string, interval contractutils.KlineInterval, contractSize decimal.Decimal) KlineFetcher {
+	return &contractKlineFetcher{cli: cli, symbol: symbol, interval: interval, contractSize: contractSize}
+}
+
+// FetchKlines ignores limit, the contract API takes no limit and returns at most 2000 klines.
+func (f *contractKlineFetcher) FetchKlines(ctx context.Context, start, end int64, limit int) ([]*candle.Candle, error) {
+	klines, err := f.cli.GetKlines(ctx, contracttypes.GetKlineParam{
+		Symbol:   f.symbol,
+		Interval: f.interval,
+		Start:    start / 1000,
+		End:      end / 1000,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return FromContractKlines(klines, f.contractSize), nil
+}
+
+func (f *contractKlineFetcher) CloseTime(openTime int64) int64 {
+	return f.interval.CloseTime(openTime)
+}
+
+func FromSpotKline(k *spottypes.Kline) *candle.Candle {
+	return &candle.Candle{
+		OpenTime:    k.OpenTime,
+		CloseTime:   k.CloseTime,
+		Open:        k.OpenPrice,
+		High:        k.HighPrice,
+		Low:         k.LowPrice,
+		Close:       k.ClosePrice,
+		Volume:      k.Volume,
+		QuoteVolume: k.QuoteAssetVolume,
+	}
+}
+
+// FromContractKline converts a contract kline, its volume is converted from contracts
+// to the base coin with contractSize, see ContractDetail.ContractSize.
+func FromContractKline(k *contracttypes.Kline, contractSize decimal.Decimal) *candle.Candle {
+	return &candle.Candle{
+		OpenTime:    k.OpenTime,
+		CloseTime:   k.CloseTime,
+		Open:        k.OpenPrice,
+		High:        k.HighPrice,
+		Low:         k.LowPrice,
+		Close:       k.ClosePrice,
+		Volume:      k.Volume.Mul(contractSize),
+		QuoteVolume: k.QuoteAssetVolume,
+	}
+}
+
+// Gap is a run of missing klines, Start is the open time of the first one and End
+// the close time of the last one.
+type Gap struct {
+	Start   int64
+	End     int64
+	Missing int
+}
+
+// KlineChunk is a batch of klines ordered by open time, without duplicates, and the
+// gaps detected before them.
+type KlineChunk struct {
+	Klines []*candle.Candle
+	Gaps   []*Gap
+}
+
+type KlineBackfillerCfg struct {
+	// Logger
+	Logger *slog.Logger
+
+	Fetcher KlineFetcher `validate:"required"`
+	// ChunkSize is the number of klines requested at once, defaults to MaxKlines
+	ChunkSize int `validate:"gte=0,lte=1000"`
+	// MinInterval is the minimum delay between two requests, defaults to DefaultMinInterval
+	MinInterval time.Duration
+	// Retries is the number of times a failed request is retried, the delay starts
+	// at RetryDelay and doubles after every attempt
+	Retries    int `validate:"gte=0"`
+	RetryDelay time.Duration
+}
+
+type KlineBackfiller struct {
+	fetcher   KlineFetcher
+	chunkSize int
+	throttle  *throttle
+}
+
+func NewKlineBackfiller(cfg *KlineBackfillerCfg) (*KlineBackfiller, error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// an unknown interval would never advance
+	if cfg.Fetcher.CloseTime(0) <= 0 {
+		return nil, ErrInvalidInterval
+	}
+
+	b := &KlineBackfiller{
+		fetcher:   cfg.Fetcher,
+		chunkSize: cfg.ChunkSize,
+		throttle:  newThrottle(cfg.MinInterval, cfg.Retries, cfg.RetryDelay, cfg.Logger),
+	}
+
+	if b.chunkSize == 0 {
+		b.chunkSize = MaxKlines
+	}
+
+	return b, nil
+}
+
+// Backfill downloads the klines opened between start and end, in milliseconds, and
+// passes them to fn chunk by chunk in ascending order. An end of zero or in the
+// future means now. Backfill stops at the first error returned by fn.
+//
+// A gap is reported as soon as the kline following it is received, a gap reaching
+// end is reported in a last chunk without klines. Klines missing before the first
+// received kline are not reported since the symbol may not be listed yet.
+func (b *KlineBackfiller) Backfill(ctx context.Context, start, end int64, fn func(*KlineChunk) error) error {
+	if now := time.Now().UnixMilli(); end <= 0 || end > now {
+		end = now
+	}
+
+	if start > end {
+		return fmt.Errorf("%w: %d > %d", ErrInvalidRange, start, end)
+	}
+
+	var (
+		last   int64
+		loaded bool
+	)
+
+	for cursor := start; cursor <= end; {
+		windowEnd := cursor
+		for i := 1; i < b.chunkSize; i++ {
+			windowEnd = b.next(windowEnd)
+		}
+		windowEnd = min(b.fetcher.CloseTime(windowEnd), end)
+
+		var klines []*candle.Candle
+		err := b.throttle.do(ctx, func() (err error) {
+			klines, err = b.fetcher.FetchKlines(ctx, cursor, windowEnd, b.chunkSize)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		sort.SliceStable(klines, func(i, j int) bool {
+			return klines[i].OpenTime < klines[j].OpenTime
+		})
+
+		chunk := &KlineChunk{}
+		for _, v := range klines {
+			// drop klines outside of the window and duplicates
+			if v.OpenTime < cursor || v.OpenTime > windowEnd || (loaded && v.OpenTime <= last) {
+				continue
+			}
+
+			if loaded {
+				if expected := b.next(last); v.OpenTime > expected {
+					chunk.Gaps = append(chunk.Gaps, b.gap(expected, v.OpenTime))
+				}
+			}
+
+			chunk.Klines = append(chunk.Klines, v)
+			last, loaded = v.OpenTime, true
+		}
+
+		if len(chunk.Klines) > 0 {
+			if err := fn(chunk); err != nil {
+				return err
+			}
+		}
+
+		cursor = windowEnd + 1
+	}
+
+	if !loaded {
+		return nil
+	}
+
+	// the trailing gap only counts klines closed before end
+	expected := b.next(last)
+	until := expected
+	for b.fetcher.CloseTime(until) <= end {
+		until = b.next(until)
+	}
+
+	if until > expected {
+		return fn(&KlineChunk{Gaps: []*Gap{b.gap(expected, until)}})
+	}
+
+	return nil
+}
+
+// next returns the open time of the kline following the one opened at openTime.
+func (b *KlineBackfiller) next(openTime int64) int64 {
+	return b.fetcher.CloseTime(openTime) + 1
+}
+
+// gap builds the gap between the open times from and to, to excluded.
+func (b *KlineBackfiller) gap(from, to int64) *Gap {
+	g := &Gap{Start: from, End: to - 1}
+	for t := from; t < to; t = b.next(t) {
+		g.Missing++
+	}
+
+	return g
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	spotutils "github.com/jl1/nexapi/mexc/spot/utils"
+	"github.com/jl1/nexapi/utils/candle"
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+const minute = int64(time.Minute / time.Millisecond)
+
+// fakeKlineFetcher serves 1m klines opened at the given minutes, the same kline can
+// be returned twice to simulate overlapping responses.
+type fakeKlineFetcher struct {
+	minutes []int64
+	calls   int
+	fail    int
+}
+
+func (f *fakeKlineFetcher) FetchKlines(ctx context.Context, start, end int64, limit int) ([]*candle.Candle, error) {
+	f.calls++
+	if f.fail > 0 {
+		f.fail--
+		return nil, errors.New("too many requests")
+	}
+
+	var ret []*candle.Candle
+	for _, m := range f.minutes {
+		if open := m * minute; open >= start-minute && open <= end {
+			ret = append(ret, &candle.Candle{OpenTime: open, CloseTime: open + minute - 1})
+		}
+	}
+
+	return ret, nil
+}
+
+func (f *fakeKlineFetcher) CloseTime(openTime int64) int64 {
+	return spotutils.Minute1.CloseTime(openTime)
+}
+
+func TestKlineBackfill(t *testing.T) {
+	fetcher := &fakeKlineFetcher{minutes: []int64{2, 3, 4, 7, 8, 9, 10}, fail: 1}
+	b, err := NewKlineBackfiller(&KlineBackfillerCfg{
+		Fetcher:     fetcher,
+		ChunkSize:   3,
+		MinInterval: time.Millisecond,
+		Retries:     1,
+		RetryDelay:  time.Millisecond,
+	})
+	assert.Nil(t, err)
+
+	var (
+		opens []int64
+		gaps  []*Gap
+	)
+	err = b.Backfill(context.TODO(), 0, 13*minute-1, func(chunk *KlineChunk) error {
+		for _, v := range chunk.Klines {
+			opens = append(opens, v.OpenTime/minute)
+		}
+		gaps = append(gaps, chunk.Gaps...)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{2, 3, 4, 7, 8, 9, 10}, opens)
+	assert.Equal(t, []*Gap{
+		{Start: 5 * minute, End: 7*minute - 1, Missing: 2},
+		{Start: 11 * minute, End: 13*minute - 1, Missing: 2},
+	}, gaps)
+	// 5 windows of 3 minutes and a retry
+	assert.Equal(t, 6, fetcher.calls)
+}
+
+func TestKlineBackfillStops(t *testing.T) {
+	b, err := NewKlineBackfiller(&KlineBackfillerCfg{
+		Fetcher:     &fakeKlineFetcher{minutes: []int64{0, 1, 2, 3, 4, 5}},
+		ChunkSize:   2,
+		MinInterval: time.Millisecond,
+	})
+	assert.Nil(t, err)
+
+	stop := errors.New("stop")
+	var chunks int
+	err = b.Backfill(context.TODO(), 0, 6*minute-1, func(chunk *KlineChunk) error {
+		chunks++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, chunks)
+
+	err = b.Backfill(context.TODO(), 2*minute, minute, nil)
+	assert.ErrorIs(t, err, ErrInvalidRange)
+}
+
+func TestNewKlineBackfillerInvalidInterval(t *testing.T) {
+	_, err := NewKlineBackfiller(&KlineBackfillerCfg{
+		Fetcher: NewSpotKlineFetcher(nil, "BTCUSDT", "2m"),
+	})
+	assert.ErrorIs(t, err, ErrInvalidInterval)
+}
+
+func TestContractCandleVolume(t *testing.T) {
+	size := decimal.MustParse("0.0001")
+
+	k := FromContractKline(&contracttypes.Kline{OpenTime: 0, CloseTime: minute - 1, ClosePrice: decimal.MustParse("100000"), Volume: decimal.MustParse("1500")}, size)
+
+	aligner, err := candle.NewAligner(time.Minute, time.UTC)
+	assert.Nil(t, err)
+	b := candle.NewBuilder(aligner, false, nil)
+	assert.Nil(t, AddContractDeal(b, &contracttypes.Deal{Ts: 0, P: decimal.MustParse("100000"), V: decimal.MustParse("1500")}, size))
+
+	// klines and deals both count the volume in the base coin
+	assert.True(t, k.Volume.Equal(decimal.MustParse("0.15")), k.Volume.String())
+	assert.True(t, b.Current().Volume.Equal(k.Volume), b.Current().Volume.String())
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMinInterval = 100 * time.Millisecond
+	DefaultRetryDelay  = time.Second
+)
+
+// throttle spaces requests by at least minInterval and retries failed ones with an
+// exponential backoff.
+type throttle struct {
+	minInterval time.Duration
+	retries     int
+	retryDelay  time.Duration
+	logger      *slog.Logger
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+func newThrottle(minInterval time.Duration, retries int, retryDelay time.Duration, logger *slog.Logger) *throttle {
+	if minInterval == 0 {
+		minInterval = DefaultMinInterval
+	}
+
+	if retryDelay == 0 {
+		retryDelay = DefaultRetryDelay
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &throttle{
+		minInterval: minInterval,
+		retries:     retries,
+		retryDelay:  retryDelay,
+		logger:      logger,
+	}
+}
+
+func (t *throttle) wait(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := sleep(ctx, time.Until(t.last.Add(t.minInterval))); err != nil {
+		return err
+	}
+
+	t.last = time.Now()
+	return nil
+}
+
+// do calls fn until it succeeds or the retries are exhausted.
+func (t *throttle) do(ctx context.Context, fn func() error) error {
+	delay := t.retryDelay
+
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(ctx); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil || attempt >= t.retries || ctx.Err() != nil {
+			return err
+		}
+
+		t.logger.Warn("request failed, retrying", "attempt", attempt+1, "delay", delay, "err", err)
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+		delay *= 2
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
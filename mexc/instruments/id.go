@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instruments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidSymbol = errors.New("invalid symbol")
+
+// KnownQuoteAssets are the quote assets recognized when splitting a spot symbol
+// without metadata, e.g. BTCUSDT. The longest matching suffix wins, whatever the order.
+var KnownQuoteAssets = []string{"USDT", "USDC", "TUSD", "USDE", "BUSD", "EUR", "BTC", "ETH", "MX"}
+
+// ID identifies an instrument independently of the naming convention of the market.
+// Its canonical form is BASE/QUOTE for spot and BASE/QUOTE:SETTLE for contracts.
+type ID struct {
+	Market Market
+	Base   string
+	Quote  string
+	// Settle is only set for contracts
+	Settle string
+}
+
+func (id ID) String() string {
+	if id.Market == Contract {
+		return id.Base + "/" + id.Quote + ":" + id.Settle
+	}
+
+	return id.Base + "/" + id.Quote
+}
+
+// Symbol formats id with the convention of its market, BTCUSDT for spot and
+// BTC_USDT for contracts.
+func (id ID) Symbol() string {
+	if id.Market == Contract {
+		return id.Base + "_" + id.Quote
+	}
+
+	return id.Base + id.Quote
+}
+
+// Spot returns the ID of the spot market trading the same pair.
+func (id ID) Spot() ID {
+	return ID{Market: Spot, Base: id.Base, Quote: id.Quote}
+}
+
+// ParseID parses the canonical form returned by ID.String.
+func ParseID(s string) (ID, error) {
+	pair, settle, isContract := strings.Cut(s, ":")
+
+	base, quote, ok := strings.Cut(pair, "/")
+	if !ok || base == "" || quote == "" || (isContract && settle == "") {
+		return ID{}, fmt.Errorf("%w %q", ErrInvalidSymbol, s)
+	}
+
+	id := ID{Market: Spot, Base: base, Quote: quote}
+	if isContract {
+		id.Market = Contract
+		id.Settle = settle
+	}
+
+	return id, nil
+}
+
+// ParseSpotSymbol splits a spot symbol on the longest matching KnownQuoteAssets suffix.
+// Prefer Registry.Get when the metadata is loaded, it does not need to guess.
+func ParseSpotSymbol(symbol string) (ID, error) {
+	var quote string
+	for _, v := range KnownQuoteAssets {
+		if len(v) > len(quote) && len(symbol) > len(v) && strings.HasSuffix(symbol, v) {
+			quote = v
+		}
+	}
+
+	if quote == "" {
+		return ID{}, fmt.Errorf("%w %q", ErrInvalidSymbol, symbol)
+	}
+
+	return ID{Market: Spot, Base: strings.TrimSuffix(symbol, quote), Quote: quote}, nil
+}
+
+// ParseContractSymbol splits a contract symbol such as BTC_USDT. The settle coin can
+// not be told from the symbol, it is assumed to be the quote coin.
+func ParseContractSymbol(symbol string) (ID, error) {
+	base, quote, ok := strings.Cut(symbol, "_")
+	if !ok || base == "" || quote == "" || strings.Contains(quote, "_") {
+		return ID{}, fmt.Errorf("%w %q", ErrInvalidSymbol, symbol)
+	}
+
+	return ID{Market: Contract, Base: base, Quote: quote, Settle: quote}, nil
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instruments
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestID(t *testing.T) {
+	id, err := ParseID("BTC/USDT:USDT")
+	assert.Nil(t, err)
+	assert.Equal(t, ID{Market: Contract, Base: "BTC", Quote: "USDT", Settle: "USDT"}, id)
+	assert.Equal(t, "BTC_USDT", id.Symbol())
+	assert.Equal(t, "BTC/USDT:USDT", id.String())
+	assert.Equal(t, "BTCUSDT", id.Spot().Symbol())
+	assert.Equal(t, "BTC/USDT", id.Spot().String())
+
+	id, err = ParseID("ETH/BTC")
+	assert.Nil(t, err)
+	assert.Equal(t, ID{Market: Spot, Base: "ETH", Quote: "BTC"}, id)
+
+	for _, v := range []string{"BTCUSDT", "BTC/", "BTC/USDT:"} {
+		_, err = ParseID(v)
+		assert.ErrorIs(t, err, ErrInvalidSymbol, v)
+	}
+}
+
+func TestParseSymbol(t *testing.T) {
+	id, err := ParseSpotSymbol("BTCUSDT")
+	assert.Nil(t, err)
+	assert.Equal(t, ID{Market: Spot, Base: "BTC", Quote: "USDT"}, id)
+
+	id, err = ParseSpotSymbol("BTCTUSD")
+	assert.Nil(t, err)
+	assert.Equal(t, "BTC", id.Base)
+
+	_, err = ParseSpotSymbol("USDT")
+	assert.ErrorIs(t, err, ErrInvalidSymbol)
+
+	id, err = ParseContractSymbol("BTC_USDT")
+	assert.Nil(t, err)
+	assert.Equal(t, ID{Market: Contract, Base: "BTC", Quote: "USDT", Settle: "USDT"}, id)
+
+	_, err = ParseContractSymbol("BTCUSDT")
+	assert.ErrorIs(t, err, ErrInvalidSymbol)
+}
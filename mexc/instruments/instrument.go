@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instruments
+
+import (
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	"github.com/jl1/nexapi/mexc/rules"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+)
+
+type Market string
+
+var (
+	Spot     Market = "SPOT"
+	Contract Market = "CONTRACT"
+)
+
+type Status string
+
+var (
+	StatusTrading Status = "TRADING"
+	StatusPaused  Status = "PAUSED"
+	StatusOffline Status = "OFFLINE"
+	StatusUnknown Status = "UNKNOWN"
+)
+
+// Instrument is the metadata of a spot symbol or a contract, exactly one of
+// SpotInfo and ContractInfo is set depending on Market.
+type Instrument struct {
+	Market     Market
+	Symbol     string
+	BaseAsset  string
+	QuoteAsset string
+	// SettleCoin is only set for contracts
+	SettleCoin string
+	Status     Status
+	Rules      *rules.SymbolRules
+
+	SpotInfo     *spottypes.SymbolInfo
+	ContractInfo *contracttypes.ContractDetail
+}
+
+func (i *Instrument) ID() ID {
+	return ID{Market: i.Market, Base: i.BaseAsset, Quote: i.QuoteAsset, Settle: i.SettleCoin}
+}
+
+func (i *Instrument) IsTrading() bool {
+	return i.Status == StatusTrading
+}
+
+func fromSpotSymbol(info *spottypes.SymbolInfo) *Instrument {
+	return &Instrument{
+		Market:     Spot,
+		Symbol:     info.Symbol,
+		BaseAsset:  info.BaseAsset,
+		QuoteAsset: info.QuoteAsset,
+		Status:     spotStatus(info.Status),
+		Rules:      rules.FromSpotSymbol(info),
+		SpotInfo:   info,
+	}
+}
+
+func fromContractDetail(detail *contracttypes.ContractDetail) *Instrument {
+	return &Instrument{
+		Market:       Contract,
+		Symbol:       detail.Symbol,
+		BaseAsset:    detail.BaseCoin,
+		QuoteAsset:   detail.QuoteCoin,
+		SettleCoin:   detail.SettleCoin,
+		Status:       contractStatus(detail.State),
+		Rules:        rules.FromContractDetail(detail),
+		ContractInfo: detail,
+	}
+}
+
+// spotStatus maps the status of a spot symbol, MEXC reports either ENABLED or
+// 1 online, 2 paused, 3 offline.
+func spotStatus(status string) Status {
+	switch status {
+	case "ENABLED", "1":
+		return StatusTrading
+	case "2":
+		return StatusPaused
+	case "3":
+		return StatusOffline
+	default:
+		return StatusUnknown
+	}
+}
+
+// contractStatus maps the state of a contract,
+// 0 enabled, 1 delivery, 2 completed, 3 offline, 4 paused.
+func contractStatus(state int) Status {
+	switch state {
+	case 0:
+		return StatusTrading
+	case 4:
+		return StatusPaused
+	case 1, 2, 3:
+		return StatusOffline
+	default:
+		return StatusUnknown
+	}
+}
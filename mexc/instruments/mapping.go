@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instruments
+
+// Pair links a perpetual contract with the spot market of its underlying.
+type Pair struct {
+	Spot     *Instrument
+	Contract *Instrument
+}
+
+// GetByID returns the instrument identified by id.
+func (r *Registry) GetByID(id ID) (*Instrument, bool) {
+	i, ok := r.load().byID[id]
+	return i, ok
+}
+
+// Underlying returns the spot market with the same base and quote assets as the
+// contract symbol, e.g. BTCUSDT for BTC_USDT.
+func (r *Registry) Underlying(contractSymbol string) (*Instrument, bool) {
+	snap := r.load()
+
+	c, ok := snap.instruments[key{Contract, contractSymbol}]
+	if !ok {
+		return nil, false
+	}
+
+	i, ok := snap.byID[c.ID().Spot()]
+	return i, ok
+}
+
+// Perpetuals returns the contracts whose underlying is the spot symbol, there
+// can be several of them settled in different coins.
+func (r *Registry) Perpetuals(spotSymbol string) []*Instrument {
+	snap := r.load()
+
+	s, ok := snap.instruments[key{Spot, spotSymbol}]
+	if !ok {
+		return nil
+	}
+
+	var ret []*Instrument
+	for _, v := range snap.byBase[s.BaseAsset] {
+		if v.Market == Contract && v.QuoteAsset == s.QuoteAsset {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
+
+// Pairs returns every contract having a spot market for its underlying, ordered
+// by contract symbol.
+func (r *Registry) Pairs() []*Pair {
+	snap := r.load()
+
+	var ret []*Pair
+	for _, c := range snap.all(Contract) {
+		if s, ok := snap.byID[c.ID().Spot()]; ok {
+			ret = append(ret, &Pair{Spot: s, Contract: c})
+		}
+	}
+
+	return ret
+}
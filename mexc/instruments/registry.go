@@ -0,0 +1,389 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package instruments keeps the spot and contract metadata of MEXC in memory and
+// refreshes it periodically.
+package instruments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	"github.com/jl1/nexapi/mexc/rules"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+)
+
+const DefaultTTL = 5 * time.Minute
+
+var ErrNoSource = errors.New("at least one of Spot and Contract source is required")
+
+// SpotSource is implemented by marketdata.SpotMarketDataClient.
+type SpotSource interface {
+	GetExchangeInfo(ctx context.Context, param spottypes.GetExchangeInfoParam) (*spottypes.ExchangeInfo, error)
+}
+
+// ContractSource is implemented by marketdata.ContractMarketDataClient.
+type ContractSource interface {
+	GetContractDetails(ctx context.Context, param contracttypes.GetContractDetailsParams) (*contracttypes.GetContractDetailsResp, error)
+}
+
+type ChangeType string
+
+var (
+	Listed        ChangeType = "LISTED"
+	Delisted      ChangeType = "DELISTED"
+	StatusChanged ChangeType = "STATUS_CHANGED"
+)
+
+// Change describes an instrument which appeared, disappeared or changed status
+// between two refreshes. Previous is nil for Listed and Current is nil for Delisted.
+type Change struct {
+	Type     ChangeType
+	Previous *Instrument
+	Current  *Instrument
+}
+
+type RegistryCfg struct {
+	// Logger
+	Logger *slog.Logger
+
+	// Spot and Contract are optional, but at least one of them is required
+	Spot     SpotSource
+	Contract ContractSource
+	// TTL defaults to DefaultTTL
+	TTL time.Duration `validate:"gte=0"`
+}
+
+type key struct {
+	market Market
+	symbol string
+}
+
+// snapshot is never modified once published, so that readers only hold the lock
+// while fetching the pointer.
+type snapshot struct {
+	instruments map[key]*Instrument
+	byBase      map[string][]*Instrument
+	byQuote     map[string][]*Instrument
+	bySettle    map[string][]*Instrument
+	byID        map[ID]*Instrument
+	loadedAt    time.Time
+}
+
+// Registry is safe for concurrent use.
+type Registry struct {
+	spot     SpotSource
+	contract ContractSource
+	ttl      time.Duration
+	logger   *slog.Logger
+
+	mu        sync.RWMutex
+	snap      *snapshot
+	listeners []func(*Change)
+
+	// serializes refreshes
+	refreshMu sync.Mutex
+}
+
+func NewRegistry(cfg *RegistryCfg) (*Registry, error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Spot == nil && cfg.Contract == nil {
+		return nil, ErrNoSource
+	}
+
+	r := &Registry{
+		spot:     cfg.Spot,
+		contract: cfg.Contract,
+		ttl:      cfg.TTL,
+		logger:   cfg.Logger,
+		snap:     &snapshot{},
+	}
+
+	if r.ttl == 0 {
+		r.ttl = DefaultTTL
+	}
+
+	if r.logger == nil {
+		r.logger = slog.Default()
+	}
+
+	return r, nil
+}
+
+// OnChange registers fn to be called for every change detected by a refresh.
+// The first load does not emit changes. fn is called synchronously from the
+// refreshing goroutine and must not block.
+func (r *Registry) OnChange(fn func(*Change)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.listeners = append(r.listeners, fn)
+}
+
+// Refresh reloads the metadata of all markets. The registry is left untouched
+// when any of the sources fails.
+func (r *Registry) Refresh(ctx context.Context) error {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	var list []*Instrument
+
+	if r.spot != nil {
+		info, err := r.spot.GetExchangeInfo(ctx, spottypes.GetExchangeInfoParam{})
+		if err != nil {
+			return fmt.Errorf("load spot symbols: %w", err)
+		}
+
+		for _, v := range info.Symbols {
+			list = append(list, fromSpotSymbol(v))
+		}
+	}
+
+	if r.contract != nil {
+		resp, err := r.contract.GetContractDetails(ctx, contracttypes.GetContractDetailsParams{})
+		if err != nil {
+			return fmt.Errorf("load contracts: %w", err)
+		}
+
+		if !resp.Success {
+			return fmt.Errorf("load contracts: code %d %s", resp.Code, resp.Message)
+		}
+
+		for _, v := range resp.Data {
+			list = append(list, fromContractDetail(v))
+		}
+	}
+
+	snap := newSnapshot(list, time.Now())
+
+	r.mu.Lock()
+	prev := r.snap
+	r.snap = snap
+	listeners := r.listeners
+	r.mu.Unlock()
+
+	// nothing to compare with on the first load
+	if prev.loadedAt.IsZero() {
+		return nil
+	}
+
+	for _, c := range diff(prev, snap) {
+		for _, fn := range listeners {
+			fn(c)
+		}
+	}
+
+	return nil
+}
+
+// RefreshIfStale refreshes the registry when it was never loaded or its data is
+// older than the TTL.
+func (r *Registry) RefreshIfStale(ctx context.Context) error {
+	if !r.Stale() {
+		return nil
+	}
+
+	return r.Refresh(ctx)
+}
+
+// Stale reports whether the data is older than the TTL.
+func (r *Registry) Stale() bool {
+	loadedAt := r.LoadedAt()
+	return loadedAt.IsZero() || time.Since(loadedAt) >= r.ttl
+}
+
+// LoadedAt returns the time of the last successful refresh.
+func (r *Registry) LoadedAt() time.Time {
+	return r.load().loadedAt
+}
+
+// Run loads the registry and refreshes it every TTL until ctx is done. Failed
+// refreshes are logged and retried on the next tick, only the first load is fatal.
+func (r *Registry) Run(ctx context.Context) error {
+	if err := r.RefreshIfStale(ctx); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(r.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := r.Refresh(ctx); err != nil {
+				r.logger.Warn("refresh instruments", "err", err)
+			}
+		}
+	}
+}
+
+func (r *Registry) load() *snapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.snap
+}
+
+// Get returns the instrument of symbol in market.
+func (r *Registry) Get(market Market, symbol string) (*Instrument, bool) {
+	i, ok := r.load().instruments[key{market, symbol}]
+	return i, ok
+}
+
+// Lookup returns the instrument of symbol in any market, spot symbols are
+// checked first. Spot and contract symbols use different formats (BTCUSDT and
+// BTC_USDT), so they never collide in practice.
+func (r *Registry) Lookup(symbol string) (*Instrument, bool) {
+	snap := r.load()
+
+	if i, ok := snap.instruments[key{Spot, symbol}]; ok {
+		return i, true
+	}
+
+	i, ok := snap.instruments[key{Contract, symbol}]
+	return i, ok
+}
+
+// GetSymbolRules implements rules.Provider.
+func (r *Registry) GetSymbolRules(symbol string) (*rules.SymbolRules, bool) {
+	i, ok := r.Lookup(symbol)
+	if !ok {
+		return nil, false
+	}
+
+	return i.Rules, true
+}
+
+// All returns every instrument of market, or of all markets when market is
+// empty, ordered by market and symbol.
+func (r *Registry) All(market Market) []*Instrument {
+	return r.load().all(market)
+}
+
+func (s *snapshot) all(market Market) []*Instrument {
+	var ret []*Instrument
+	for k, v := range s.instruments {
+		if market == "" || k.market == market {
+			ret = append(ret, v)
+		}
+	}
+
+	sortInstruments(ret)
+	return ret
+}
+
+// ByBaseAsset returns the instruments of all markets trading asset.
+func (r *Registry) ByBaseAsset(asset string) []*Instrument {
+	return slices.Clone(r.load().byBase[asset])
+}
+
+// ByQuoteAsset returns the instruments of all markets quoted in asset.
+func (r *Registry) ByQuoteAsset(asset string) []*Instrument {
+	return slices.Clone(r.load().byQuote[asset])
+}
+
+// BySettleCoin returns the contracts settled in coin.
+func (r *Registry) BySettleCoin(coin string) []*Instrument {
+	return slices.Clone(r.load().bySettle[coin])
+}
+
+func newSnapshot(list []*Instrument, loadedAt time.Time) *snapshot {
+	snap := &snapshot{
+		instruments: make(map[key]*Instrument, len(list)),
+		byBase:      make(map[string][]*Instrument),
+		byQuote:     make(map[string][]*Instrument),
+		bySettle:    make(map[string][]*Instrument),
+		byID:        make(map[ID]*Instrument, len(list)),
+		loadedAt:    loadedAt,
+	}
+
+	sortInstruments(list)
+
+	for _, v := range list {
+		snap.instruments[key{v.Market, v.Symbol}] = v
+		snap.byID[v.ID()] = v
+		snap.byBase[v.BaseAsset] = append(snap.byBase[v.BaseAsset], v)
+		snap.byQuote[v.QuoteAsset] = append(snap.byQuote[v.QuoteAsset], v)
+		if v.SettleCoin != "" {
+			snap.bySettle[v.SettleCoin] = append(snap.bySettle[v.SettleCoin], v)
+		}
+	}
+
+	return snap
+}
+
+func diff(prev, cur *snapshot) []*Change {
+	var ret []*Change
+
+	for k, v := range cur.instruments {
+		old, ok := prev.instruments[k]
+		switch {
+		case !ok:
+			ret = append(ret, &Change{Type: Listed, Current: v})
+		case old.Status != v.Status:
+			ret = append(ret, &Change{Type: StatusChanged, Previous: old, Current: v})
+		}
+	}
+
+	for k, v := range prev.instruments {
+		if _, ok := cur.instruments[k]; !ok {
+			ret = append(ret, &Change{Type: Delisted, Previous: v})
+		}
+	}
+
+	// emit changes in a stable order
+	sort.Slice(ret, func(i, j int) bool {
+		return changeSymbol(ret[i]) < changeSymbol(ret[j])
+	})
+
+	return ret
+}
+
+func changeSymbol(c *Change) string {
+	if c.Current != nil {
+		return string(c.Current.Market) + c.Current.Symbol
+	}
+
+	return string(c.Previous.Market) + c.Previous.Symbol
+}
+
+func sortInstruments(list []*Instrument) {
+	sort.Slice(list, func(i, j int) bool {
+		// spot first
+		if list[i].Market != list[j].Market {
+			return list[i].Market == Spot
+		}
+
+		return list[i].Symbol < list[j].Symbol
+	})
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instruments
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSpot struct {
+	symbols []*spottypes.SymbolInfo
+}
+
+func (f *fakeSpot) GetExchangeInfo(ctx context.Context, param spottypes.GetExchangeInfoParam) (*spottypes.ExchangeInfo, error) {
+	return &spottypes.ExchangeInfo{Symbols: f.symbols}, nil
+}
+
+type fakeContract struct {
+	details []*contracttypes.ContractDetail
+}
+
+func (f *fakeContract) GetContractDetails(ctx context.Context, param contracttypes.GetContractDetailsParams) (*contracttypes.GetContractDetailsResp, error) {
+	resp := &contracttypes.GetContractDetailsResp{Data: f.details}
+	resp.Success = true
+	return resp, nil
+}
+
+func TestRegistry(t *testing.T) {
+	spot := &fakeSpot{symbols: []*spottypes.SymbolInfo{
+		{Symbol: "BTCUSDT", Status: "1", BaseAsset: "BTC", QuoteAsset: "USDT", QuotePrecision: 2, BaseAssetPrecision: 6},
+		{Symbol: "ETHUSDT", Status: "1", BaseAsset: "ETH", QuoteAsset: "USDT", QuotePrecision: 2, BaseAssetPrecision: 5},
+	}}
+	contract := &fakeContract{details: []*contracttypes.ContractDetail{
+		{Symbol: "BTC_USDT", BaseCoin: "BTC", QuoteCoin: "USDT", SettleCoin: "USDT", PriceUnit: decimal.MustParse("0.1"), VolUnit: decimal.MustParse("1")},
+	}}
+
+	r, err := NewRegistry(&RegistryCfg{Spot: spot, Contract: contract, TTL: time.Hour})
+	assert.Nil(t, err)
+	assert.True(t, r.Stale())
+
+	var changes []*Change
+	r.OnChange(func(c *Change) {
+		changes = append(changes, c)
+	})
+
+	assert.Nil(t, r.RefreshIfStale(context.TODO()))
+	assert.False(t, r.Stale())
+	assert.Empty(t, changes)
+
+	i, ok := r.Get(Contract, "BTC_USDT")
+	assert.True(t, ok)
+	assert.Equal(t, "USDT", i.SettleCoin)
+	assert.True(t, i.IsTrading())
+
+	_, ok = r.Get(Spot, "BTC_USDT")
+	assert.False(t, ok)
+
+	rules, ok := r.GetSymbolRules("BTCUSDT")
+	assert.True(t, ok)
+	assert.Equal(t, "0.01", rules.TickSize.String())
+
+	assert.Len(t, r.ByBaseAsset("BTC"), 2)
+
+	// the lists returned are copies
+	byBase := r.ByBaseAsset("BTC")
+	byBase[0] = nil
+	assert.NotNil(t, r.ByBaseAsset("BTC")[0])
+	assert.Len(t, r.ByQuoteAsset("USDT"), 3)
+	assert.Len(t, r.BySettleCoin("USDT"), 1)
+	assert.Len(t, r.All(Spot), 2)
+	assert.Equal(t, "BTCUSDT", r.All("")[0].Symbol)
+
+	spot.symbols = []*spottypes.SymbolInfo{
+		{Symbol: "BTCUSDT", Status: "2", BaseAsset: "BTC", QuoteAsset: "USDT"},
+		{Symbol: "SOLUSDT", Status: "1", BaseAsset: "SOL", QuoteAsset: "USDT"},
+	}
+
+	assert.Nil(t, r.Refresh(context.TODO()))
+	assert.Len(t, changes, 3)
+	assert.Equal(t, StatusChanged, changes[0].Type)
+	assert.Equal(t, StatusPaused, changes[0].Current.Status)
+	assert.Equal(t, Delisted, changes[1].Type)
+	assert.Equal(t, "ETHUSDT", changes[1].Previous.Symbol)
+	assert.Equal(t, Listed, changes[2].Type)
+	assert.Equal(t, "SOLUSDT", changes[2].Current.Symbol)
+
+	_, ok = r.Lookup("ETHUSDT")
+	assert.False(t, ok)
+}
+
+func TestNewRegistryWithoutSource(t *testing.T) {
+	_, err := NewRegistry(&RegistryCfg{})
+	assert.ErrorIs(t, err, ErrNoSource)
+}
+
+func TestPairs(t *testing.T) {
+	r, err := NewRegistry(&RegistryCfg{
+		Spot: &fakeSpot{symbols: []*spottypes.SymbolInfo{
+			{Symbol: "BTCUSDT", BaseAsset: "BTC", QuoteAsset: "USDT"},
+			{Symbol: "ETHUSDT", BaseAsset: "ETH", QuoteAsset: "USDT"},
+		}},
+		Contract: &fakeContract{details: []*contracttypes.ContractDetail{
+			{Symbol: "BTC_USDT", BaseCoin: "BTC", QuoteCoin: "USDT", SettleCoin: "USDT"},
+			{Symbol: "BTC_USD", BaseCoin: "BTC", QuoteCoin: "USD", SettleCoin: "BTC"},
+			{Symbol: "SOL_USDT", BaseCoin: "SOL", QuoteCoin: "USDT", SettleCoin: "USDT"},
+		}},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, r.Refresh(context.TODO()))
+
+	s, ok := r.Underlying("BTC_USDT")
+	assert.True(t, ok)
+	assert.Equal(t, "BTCUSDT", s.Symbol)
+
+	_, ok = r.Underlying("SOL_USDT")
+	assert.False(t, ok)
+
+	perps := r.Perpetuals("BTCUSDT")
+	assert.Len(t, perps, 1)
+	assert.Equal(t, "BTC_USDT", perps[0].Symbol)
+
+	pairs := r.Pairs()
+	assert.Len(t, pairs, 1)
+	assert.Equal(t, "BTCUSDT", pairs[0].Spot.Symbol)
+
+	i, ok := r.GetByID(ID{Market: Contract, Base: "BTC", Quote: "USD", Settle: "BTC"})
+	assert.True(t, ok)
+	assert.Equal(t, "BTC_USD", i.Symbol)
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orderbook
+
+import (
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// divPlaces is the number of decimal places kept by divisions.
+const divPlaces = 18
+
+var bps = decimal.NewFromInt(10000)
+
+// Fill is the expected execution of a market order against the book.
+type Fill struct {
+	Side Side
+	// Qty is the base quantity and Quote the quote amount filled
+	Qty   decimal.Decimal
+	Quote decimal.Decimal
+	// AvgPrice is Quote / Qty
+	AvgPrice   decimal.Decimal
+	BestPrice  decimal.Decimal
+	WorstPrice decimal.Decimal
+	// SlippageBps is the distance from AvgPrice to BestPrice in basis points, positive
+	// when the order fills at a worse price than the best one
+	SlippageBps float64
+	// Levels is the number of levels consumed, the last one partially
+	Levels int
+	// Complete is false when the book is too thin to fill the whole order
+	Complete bool
+}
+
+// FillBase simulates a market order of side for qty of the base asset.
+func (b *Book) FillBase(side Side, qty decimal.Decimal) *Fill {
+	return b.fill(side, func(l Level, f *Fill) (decimal.Decimal, bool) {
+		remaining := qty.Sub(f.Qty)
+		if l.Qty.GreaterThanOrEqual(remaining) {
+			return remaining, true
+		}
+		return l.Qty, false
+	})
+}
+
+// FillQuote simulates a market order of side for an amount of the quote asset, like
+// a spot order with QuoteOrderQty.
+func (b *Book) FillQuote(side Side, quote decimal.Decimal) *Fill {
+	return b.fill(side, func(l Level, f *Fill) (decimal.Decimal, bool) {
+		remaining := quote.Sub(f.Quote)
+		if l.Price.Mul(l.Qty).GreaterThanOrEqual(remaining) {
+			return remaining.Div(l.Price, divPlaces), true
+		}
+		return l.Qty, false
+	})
+}
+
+// fill walks the levels consumed by side, take returns the quantity taken from a
+// level and whether the order is complete.
+func (b *Book) fill(side Side, take func(Level, *Fill) (decimal.Decimal, bool)) *Fill {
+	f := &Fill{Side: side}
+
+	for _, l := range b.levels(side) {
+		qty, done := take(l, f)
+
+		if f.Levels == 0 {
+			f.BestPrice = l.Price
+		}
+		f.Levels++
+		f.WorstPrice = l.Price
+		f.Qty = f.Qty.Add(qty)
+		f.Quote = f.Quote.Add(qty.Mul(l.Price))
+
+		if done {
+			f.Complete = true
+			break
+		}
+	}
+
+	if f.Qty.IsPositive() {
+		f.AvgPrice = f.Quote.Div(f.Qty, divPlaces)
+
+		slippage := f.AvgPrice.Sub(f.BestPrice).Mul(bps).Div(f.BestPrice, divPlaces).Float64()
+		if side == Sell {
+			slippage = -slippage
+		}
+		f.SlippageBps = slippage
+	}
+
+	return f
+}
+
+// Depth is the liquidity resting within a distance of the mid price.
+type Depth struct {
+	BidQty   decimal.Decimal
+	BidQuote decimal.Decimal
+	AskQty   decimal.Decimal
+	AskQuote decimal.Decimal
+}
+
+// DepthWithin sums the levels priced within distance basis points of the mid price.
+func (b *Book) DepthWithin(distance float64) Depth {
+	var ret Depth
+
+	mid, ok := b.Mid()
+	if !ok {
+		return ret
+	}
+
+	band := mid.Mul(decimal.NewFromFloat(distance)).Div(bps, divPlaces)
+	low, high := mid.Sub(band), mid.Add(band)
+
+	for _, l := range b.Bids {
+		if l.Price.LessThan(low) {
+			break
+		}
+		ret.BidQty = ret.BidQty.Add(l.Qty)
+		ret.BidQuote = ret.BidQuote.Add(l.Price.Mul(l.Qty))
+	}
+
+	for _, l := range b.Asks {
+		if l.Price.GreaterThan(high) {
+			break
+		}
+		ret.AskQty = ret.AskQty.Add(l.Qty)
+		ret.AskQuote = ret.AskQuote.Add(l.Price.Mul(l.Qty))
+	}
+
+	return ret
+}
+
+// Imbalance returns (bids - asks) / (bids + asks) of the quantities of the best
+// levels of each side, all of them when levels is zero. It ranges from -1, only asks,
+// to 1, only bids, and is zero for an empty book.
+func (b *Book) Imbalance(levels int) float64 {
+	sum := func(side []Level) decimal.Decimal {
+		if levels > 0 && len(side) > levels {
+			side = side[:levels]
+		}
+
+		var ret decimal.Decimal
+		for _, l := range side {
+			ret = ret.Add(l.Qty)
+		}
+		return ret
+	}
+
+	bid, ask := sum(b.Bids), sum(b.Asks)
+	total := bid.Add(ask)
+	if total.IsZero() {
+		return 0
+	}
+
+	return bid.Sub(ask).Div(total, divPlaces).Float64()
+}
+
+// Microprice returns the average of the best bid and ask weighted by the quantity of
+// the opposite side, which leans towards the side likely to be consumed first.
+func (b *Book) Microprice() (decimal.Decimal, bool) {
+	bid, ok := b.BestBid()
+	if !ok {
+		return decimal.Zero, false
+	}
+
+	ask, ok := b.BestAsk()
+	if !ok {
+		return decimal.Zero, false
+	}
+
+	weighted := bid.Price.Mul(ask.Qty).Add(ask.Price.Mul(bid.Qty))
+	return weighted.Div(bid.Qty.Add(ask.Qty), divPlaces), true
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package orderbook keeps an order book in memory and measures the cost of executing
+// orders against it.
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+var ErrInvalidLevel = errors.New("invalid order book level")
+
+type Side string
+
+var (
+	Buy  Side = "BUY"
+	Sell Side = "SELL"
+)
+
+type Level struct {
+	Price decimal.Decimal
+	Qty   decimal.Decimal
+}
+
+// Book holds bids from the best, highest, price down and asks from the best, lowest,
+// price up. Book is not safe for concurrent use.
+type Book struct {
+	Bids []Level
+	Asks []Level
+}
+
+// FromSpotOrderbook builds a book from a snapshot returned by GetOrderbook.
+func FromSpotOrderbook(ob *spottypes.Orderbook) (*Book, error) {
+	b := &Book{}
+
+	for _, v := range ob.Bids {
+		if len(v) < 2 {
+			return nil, fmt.Errorf("%w: bid %v", ErrInvalidLevel, v)
+		}
+		b.SetBid(v[0], v[1])
+	}
+
+	for _, v := range ob.Asks {
+		if len(v) < 2 {
+			return nil, fmt.Errorf("%w: ask %v", ErrInvalidLevel, v)
+		}
+		b.SetAsk(v[0], v[1])
+	}
+
+	return b, nil
+}
+
+// FromContractDepth builds a book from a snapshot returned by GetDepth. Volumes are
+// counted in contracts and converted to the base coin with contractSize, see
+// ContractDetail.ContractSize.
+func FromContractDepth(depth *contracttypes.Depth, contractSize decimal.Decimal) *Book {
+	b := &Book{}
+
+	for _, v := range depth.Bids {
+		b.SetBid(v.Price, v.Vol.Mul(contractSize))
+	}
+
+	for _, v := range depth.Asks {
+		b.SetAsk(v.Price, v.Vol.Mul(contractSize))
+	}
+
+	return b
+}
+
+// SetBid replaces the quantity of the bid at price, a zero quantity removes the
+// level, so that a local book can be maintained from incremental updates.
+func (b *Book) SetBid(price, qty decimal.Decimal) {
+	i := sort.Search(len(b.Bids), func(i int) bool {
+		return b.Bids[i].Price.LessThanOrEqual(price)
+	})
+	b.Bids = setLevel(b.Bids, i, price, qty)
+}
+
+// SetAsk replaces the quantity of the ask at price, see SetBid.
+func (b *Book) SetAsk(price, qty decimal.Decimal) {
+	i := sort.Search(len(b.Asks), func(i int) bool {
+		return b.Asks[i].Price.GreaterThanOrEqual(price)
+	})
+	b.Asks = setLevel(b.Asks, i, price, qty)
+}
+
+// setLevel updates levels at i, the position of price in the sorted levels.
+func setLevel(levels []Level, i int, price, qty decimal.Decimal) []Level {
+	found := i < len(levels) && levels[i].Price.Equal(price)
+
+	switch {
+	case found && qty.IsPositive():
+		levels[i].Qty = qty
+	case found:
+		levels = append(levels[:i], levels[i+1:]...)
+	case qty.IsPositive():
+		levels = append(levels, Level{})
+		copy(levels[i+1:], levels[i:])
+		levels[i] = Level{Price: price, Qty: qty}
+	}
+
+	return levels
+}
+
+func (b *Book) BestBid() (Level, bool) {
+	if len(b.Bids) == 0 {
+		return Level{}, false
+	}
+	return b.Bids[0], true
+}
+
+func (b *Book) BestAsk() (Level, bool) {
+	if len(b.Asks) == 0 {
+		return Level{}, false
+	}
+	return b.Asks[0], true
+}
+
+// Mid returns the average of the best bid and ask.
+func (b *Book) Mid() (decimal.Decimal, bool) {
+	bid, ok := b.BestBid()
+	if !ok {
+		return decimal.Zero, false
+	}
+
+	ask, ok := b.BestAsk()
+	if !ok {
+		return decimal.Zero, false
+	}
+
+	return bid.Price.Add(ask.Price).Div(decimal.NewFromInt(2), max(bid.Price.Scale(), ask.Price.Scale())+1), true
+}
+
+// levels returns the levels consumed by a taker order of side.
+func (b *Book) levels(side Side) []Level {
+	if side == Sell {
+		return b.Bids
+	}
+	return b.Asks
+}
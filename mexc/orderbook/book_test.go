@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orderbook
+
+import (
+	"encoding/json"
+	"testing"
+
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	spotwstypes "github.com/jl1/nexapi/mexc/spot/websocketmarket/types"
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var d = decimal.MustParse
+
+func testBook(t *testing.T) *Book {
+	var ob spottypes.Orderbook
+	err := json.Unmarshal([]byte(`{
+		"lastUpdateId":1,
+		"bids":[["99","2"],["100","1"],["98","5"]],
+		"asks":[["101","1"],["102","2"],["105","10"]]
+	}`), &ob)
+	assert.Nil(t, err)
+
+	b, err := FromSpotOrderbook(&ob)
+	assert.Nil(t, err)
+	return b
+}
+
+func TestBook(t *testing.T) {
+	b := testBook(t)
+
+	assert.Equal(t, "100", b.Bids[0].Price.String())
+	assert.Equal(t, "98", b.Bids[2].Price.String())
+
+	mid, ok := b.Mid()
+	assert.True(t, ok)
+	assert.Equal(t, "100.5", mid.String())
+
+	b.SetAsk(d("100.5"), d("3"))
+	b.SetAsk(d("101"), d("0"))
+	b.SetBid(d("99"), d("4"))
+	assert.Equal(t, "100.5", b.Asks[0].Price.String())
+	assert.Equal(t, "102", b.Asks[1].Price.String())
+	assert.Equal(t, "4", b.Bids[1].Qty.String())
+
+	_, err := FromSpotOrderbook(&spottypes.Orderbook{Bids: [][]decimal.Decimal{{d("1")}}})
+	assert.ErrorIs(t, err, ErrInvalidLevel)
+}
+
+func TestFill(t *testing.T) {
+	b := testBook(t)
+
+	// 1 at 101 and 1 at 102
+	f := b.FillBase(Buy, d("2"))
+	assert.True(t, f.Complete)
+	assert.Equal(t, 2, f.Levels)
+	assert.Equal(t, "203", f.Quote.String())
+	assert.True(t, f.AvgPrice.Equal(d("101.5")))
+	assert.Equal(t, "102", f.WorstPrice.String())
+	assert.InDelta(t, 0.5/101*10000, f.SlippageBps, 1e-9)
+
+	// 1 at 100 and 2 at 99, the slippage of a sell is positive too
+	f = b.FillBase(Sell, d("3"))
+	assert.True(t, f.Complete)
+	assert.InDelta(t, (100-298.0/3)/100*10000, f.SlippageBps, 1e-9)
+
+	// 101 buys 1, the remaining 102 buys 1 at 102
+	f = b.FillQuote(Buy, d("203"))
+	assert.True(t, f.Complete)
+	assert.True(t, f.Qty.Equal(d("2")))
+
+	f = b.FillBase(Buy, d("100"))
+	assert.False(t, f.Complete)
+	assert.Equal(t, "13", f.Qty.String())
+
+	f = (&Book{}).FillBase(Buy, d("1"))
+	assert.False(t, f.Complete)
+	assert.True(t, f.AvgPrice.IsZero())
+}
+
+func TestAnalytics(t *testing.T) {
+	b := testBook(t)
+
+	// mid is 100.5, 100 bps is 1.005: bids down to 99.495 and asks up to 101.505
+	depth := b.DepthWithin(100)
+	assert.Equal(t, "1", depth.BidQty.String())
+	assert.Equal(t, "1", depth.AskQty.String())
+	assert.Equal(t, "101", depth.AskQuote.String())
+
+	depth = b.DepthWithin(200)
+	assert.Equal(t, "3", depth.BidQty.String())
+	assert.Equal(t, "298", depth.BidQuote.String())
+
+	assert.InDelta(t, 0, b.Imbalance(1), 1e-9)
+	assert.InDelta(t, (8.0-13)/21, b.Imbalance(0), 1e-9)
+	assert.Equal(t, 0.0, (&Book{}).Imbalance(0))
+
+	b.SetBid(d("100"), d("3"))
+	micro, ok := b.Microprice()
+	assert.True(t, ok)
+	// (100 * 1 + 101 * 3) / 4
+	assert.True(t, micro.Equal(d("100.75")))
+}
+
+func TestApplyDepth(t *testing.T) {
+	b := testBook(t)
+
+	b.ApplySpotDepth(&spotwstypes.Depth{
+		Bids: []*spotwstypes.DepthLevel{{Price: d("100"), Quantity: d("0")}, {Price: d("99.5"), Quantity: d("1")}},
+	})
+	assert.Equal(t, "99.5", b.Bids[0].Price.String())
+	assert.Len(t, b.Asks, 3)
+
+	b.ApplySpotDepth(&spotwstypes.Depth{
+		Snapshot: true,
+		Asks:     []*spotwstypes.DepthLevel{{Price: d("103"), Quantity: d("1")}},
+	})
+	assert.Empty(t, b.Bids)
+	assert.Equal(t, "103", b.Asks[0].Price.String())
+
+	b.ApplyContractDepth(&contracttypes.Depth{
+		Bids: []*contracttypes.DepthLevel{{Price: d("102"), Vol: d("10")}},
+		Asks: []*contracttypes.DepthLevel{{Price: d("103"), Vol: d("0")}},
+	}, d("0.0001"))
+	assert.True(t, b.Bids[0].Qty.Equal(d("0.001")))
+	assert.Empty(t, b.Asks)
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orderbook
+
+import (
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	spotwstypes "github.com/jl1/nexapi/mexc/spot/websocketmarket/types"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// ApplySpotDepth applies a push of the spot depth topics, a snapshot of the limit
+// depth topic replaces the book and an update of the increase depth topic replaces
+// the levels it carries.
+func (b *Book) ApplySpotDepth(d *spotwstypes.Depth) {
+	if d.Snapshot {
+		b.Bids, b.Asks = nil, nil
+	}
+
+	for _, v := range d.Bids {
+		b.SetBid(v.Price, v.Quantity)
+	}
+
+	for _, v := range d.Asks {
+		b.SetAsk(v.Price, v.Quantity)
+	}
+}
+
+// ApplyContractDepth applies a push of the contract depth topic, volumes are
+// converted with contractSize like FromContractDepth does.
+func (b *Book) ApplyContractDepth(d *contracttypes.Depth, contractSize decimal.Decimal) {
+	for _, v := range d.Bids {
+		b.SetBid(v.Price, v.Vol.Mul(contractSize))
+	}
+
+	for _, v := range d.Asks {
+		b.SetAsk(v.Price, v.Vol.Mul(contractSize))
+	}
+}
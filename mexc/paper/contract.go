@@ -0,0 +1,997 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package paper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-playground/validator"
+	"github.com/jl1/nexapi/mexc/backtest"
+	"github.com/jl1/nexapi/mexc/contract/account/types"
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	"github.com/jl1/nexapi/mexc/contract/websocketmarket"
+	"github.com/jl1/nexapi/mexc/orderbook"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// DefaultLeverage is the leverage of the positions opened without one, capped by
+// the leverage range of the contract.
+const DefaultLeverage = 20
+
+// divPlaces is the precision of the divisions
+const divPlaces = 12
+
+// closedPosition is the state of the positions in the history.
+const closedPosition = 3
+
+var (
+	ErrUnknownContract  = errors.New("unknown contract")
+	ErrInvalidLeverage  = errors.New("leverage is out of the range of the contract")
+	ErrNoPosition       = errors.New("no position to close")
+	ErrOppositePosition = errors.New("an opposite position is open in one-way mode")
+	ErrUnsupportedOrder = errors.New("unsupported order")
+	ErrEmptyDepth       = errors.New("empty depth response")
+)
+
+type ContractAccountCfg struct {
+	// Logger
+	Logger *slog.Logger
+
+	// StatePath is the file the state is saved to, it is restored from it at start
+	// when the file exists
+	StatePath string `validate:"required"`
+	// Details are the details of the tradable contracts as returned by
+	// GetContractDetails, they give the contract sizes, the fee rates, the leverage
+	// range and the margin rates
+	Details []*contracttypes.ContractDetail `validate:"required,min=1"`
+	// Balances are the initial balances by currency, ignored when the state is
+	// restored
+	Balances map[string]decimal.Decimal
+	// Leverage is the leverage of the positions opened without one, defaults to
+	// DefaultLeverage
+	Leverage int `validate:"gte=0"`
+	// Latency defaults to no latency
+	Latency backtest.LatencyModel
+}
+
+type contractOrder struct {
+	types.Order
+
+	// Arrival is the time the order reaches the exchange, CancelAt the time its
+	// cancellation does, zero when not canceled
+	Arrival  int64 `json:"arrival"`
+	CancelAt int64 `json:"cancelAt,omitempty"`
+	// Active is set once the order was matched on arrival, it rests on the book after
+	Active bool `json:"active"`
+}
+
+func (o *contractOrder) isOpen() bool {
+	return o.State == types.OrderPending || o.State == types.OrderUncompleted
+}
+
+// buys reports whether o buys, opening a long or closing a short.
+func (o *contractOrder) buys() bool {
+	return o.Side == types.OpenLong || o.Side == types.CloseShort
+}
+
+func (o *contractOrder) opens() bool {
+	return o.Side == types.OpenLong || o.Side == types.OpenShort
+}
+
+func positionType(side types.OrderSide) types.PositionType {
+	if side == types.OpenLong || side == types.CloseLong {
+		return types.LongPosition
+	}
+
+	return types.ShortPosition
+}
+
+// prices are the last prices of a contract, Fair values the positions.
+type prices struct {
+	Bid   decimal.Decimal `json:"bid"`
+	Ask   decimal.Decimal `json:"ask"`
+	Last  decimal.Decimal `json:"last"`
+	Fair  decimal.Decimal `json:"fair"`
+	Index decimal.Decimal `json:"index"`
+}
+
+// mark returns the price positions are valued at.
+func (p *prices) mark() decimal.Decimal {
+	if p.Fair.IsPositive() {
+		return p.Fair
+	}
+
+	return p.Last
+}
+
+// trend returns the price watched by plan and stop orders with trend t.
+func (p *prices) trend(t types.TriggerType) decimal.Decimal {
+	switch t {
+	case types.FairPrice:
+		return p.mark()
+	case types.IndexPrice:
+		return p.Index
+	default:
+		return p.Last
+	}
+}
+
+type contractState struct {
+	Now          int64              `json:"now"`
+	OrderSeq     int64              `json:"orderSeq"`
+	PositionSeq  int64              `json:"positionSeq"`
+	DealSeq      int64              `json:"dealSeq"`
+	PlanSeq      int64              `json:"planSeq"`
+	StopSeq      int64              `json:"stopSeq"`
+	PositionMode types.PositionMode `json:"positionMode"`
+	// Balances are the wallet balances by currency, position margins included
+	Balances map[string]decimal.Decimal `json:"balances"`
+	// Leverage is the leverage of new positions by symbol and position type
+	Leverage  map[string]int        `json:"leverage"`
+	Orders    []*contractOrder      `json:"orders"`
+	Positions []*types.OpenPosition `json:"positions"`
+	Deals     []*types.Deal         `json:"deals"`
+	Prices    map[string]*prices    `json:"prices"`
+	// PlanOrders are the trigger orders, StopOrders the stop-loss and take-profit
+	// orders of the positions
+	PlanOrders []*types.PlanOrder `json:"planOrders"`
+	StopOrders []*stopOrder       `json:"stopOrders"`
+}
+
+// contractTick is a market update orders are matched against, quantities are in
+// contracts.
+type contractTick struct {
+	time int64
+	ask  decimal.Decimal
+	bid  decimal.Decimal
+	// askQty and bidQty cap the quantity filled by resting buys and sells, nil when
+	// unknown
+	askQty *decimal.Decimal
+	bidQty *decimal.Decimal
+	// book is walked by takers when set
+	book *orderbook.Book
+}
+
+// depthBook is a book maintained from the depth pushes.
+type depthBook struct {
+	*orderbook.Book
+	version int64
+}
+
+// ContractAccount is a paper futures account with the methods of
+// ContractAccountClient. It supports limit, post-only, immediate-or-cancel,
+// fill-or-kill and market orders in hedge and one-way modes, with isolated and cross
+// margin. Positions are valued at the fair price of the ticker and liquidated when
+// their margin falls to the maintenance margin. Plan orders and the stop-loss and
+// take-profit prices of opening orders are triggered by the last, fair or index
+// price of the ticker. Funding, transfers and automatic margin top-ups are not
+// simulated, and GetProfitRate returns ErrUnsupportedOrder.
+type ContractAccount struct {
+	logger    *slog.Logger
+	statePath string
+	details   map[string]*contracttypes.ContractDetail
+	leverage  int
+	latency   backtest.LatencyModel
+	validate  *validator.Validate
+
+	mu     sync.Mutex
+	state  *contractState
+	orders map[int64]*contractOrder
+	books  map[string]*depthBook
+	dirty  bool
+}
+
+func NewContractAccount(cfg *ContractAccountCfg) (*ContractAccount, error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &ContractAccount{
+		logger:    cfg.Logger,
+		statePath: cfg.StatePath,
+		details:   make(map[string]*contracttypes.ContractDetail, len(cfg.Details)),
+		leverage:  cfg.Leverage,
+		latency:   cfg.Latency,
+		validate:  validator,
+		orders:    make(map[int64]*contractOrder),
+		books:     make(map[string]*depthBook),
+	}
+
+	if c.logger == nil {
+		c.logger = slog.Default()
+	}
+
+	if c.leverage == 0 {
+		c.leverage = DefaultLeverage
+	}
+
+	if c.latency == nil {
+		c.latency = backtest.FixedLatency(0)
+	}
+
+	for _, v := range cfg.Details {
+		c.details[v.Symbol] = v
+	}
+
+	data, err := loadFile(cfg.StatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if data != nil {
+		var state contractState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, err
+		}
+		c.state = &state
+
+		for _, v := range state.Orders {
+			c.orders[v.OrderId] = v
+		}
+		if c.state.Leverage == nil {
+			c.state.Leverage = make(map[string]int)
+		}
+		if c.state.Prices == nil {
+			c.state.Prices = make(map[string]*prices)
+		}
+
+		return c, nil
+	}
+
+	c.state = &contractState{
+		PositionMode: types.HedgeMode,
+		Balances:     make(map[string]decimal.Decimal),
+		Leverage:     make(map[string]int),
+		Prices:       make(map[string]*prices),
+	}
+	for k, v := range cfg.Balances {
+		c.state.Balances[k] = v
+	}
+
+	c.dirty = true
+	if err := c.save(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// save writes the state to the state file if it changed, c.mu must be held.
+func (c *ContractAccount) save() error {
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c.state)
+	if err != nil {
+		return err
+	}
+
+	if err := saveFile(c.statePath, data); err != nil {
+		return err
+	}
+	c.dirty = false
+
+	return nil
+}
+
+func (c *ContractAccount) detail(symbol string) (*contracttypes.ContractDetail, error) {
+	d, ok := c.details[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownContract, symbol)
+	}
+
+	return d, nil
+}
+
+func (c *ContractAccount) prices(symbol string) *prices {
+	p, ok := c.state.Prices[symbol]
+	if !ok {
+		p = &prices{}
+		c.state.Prices[symbol] = p
+	}
+
+	return p
+}
+
+func leverageKey(symbol string, positionType types.PositionType) string {
+	return fmt.Sprintf("%s:%d", symbol, positionType)
+}
+
+// defaultLeverage returns the leverage of the new positions of symbol.
+func (c *ContractAccount) defaultLeverage(d *contracttypes.ContractDetail, positionType types.PositionType) int {
+	if v, ok := c.state.Leverage[leverageKey(d.Symbol, positionType)]; ok {
+		return v
+	}
+
+	ret := c.leverage
+	if max := int(d.MaxLeverage.IntPart()); max > 0 && ret > max {
+		ret = max
+	}
+	if min := int(d.MinLeverage.IntPart()); ret < min {
+		ret = min
+	}
+
+	return ret
+}
+
+func checkLeverage(d *contracttypes.ContractDetail, leverage int) error {
+	v := decimal.NewFromInt(int64(leverage))
+	if leverage <= 0 || v.LessThan(d.MinLeverage) || (d.MaxLeverage.IsPositive() && v.GreaterThan(d.MaxLeverage)) {
+		return fmt.Errorf("%w: %d not in [%s, %s]", ErrInvalidLeverage, leverage, d.MinLeverage, d.MaxLeverage)
+	}
+
+	return nil
+}
+
+// position returns the open position of symbol and positionType, nil if none.
+func (c *ContractAccount) position(symbol string, positionType types.PositionType) *types.OpenPosition {
+	for _, v := range c.state.Positions {
+		if v.State != closedPosition && v.Symbol == symbol && v.PositionType == positionType {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func (c *ContractAccount) positionByID(id int64) *types.OpenPosition {
+	for _, v := range c.state.Positions {
+		if v.PositionID == id {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// unrealized returns the profit of p at its mark price.
+func (c *ContractAccount) unrealized(p *types.OpenPosition) decimal.Decimal {
+	mark := c.prices(p.Symbol).mark()
+	if !mark.IsPositive() {
+		return decimal.Decimal{}
+	}
+
+	pnl := mark.Sub(p.HoldAvgPrice).Mul(p.HoldVol).Mul(c.details[p.Symbol].ContractSize)
+	if p.PositionType == types.ShortPosition {
+		return pnl.Neg()
+	}
+
+	return pnl
+}
+
+// orderMargin returns the margin reserved by the unfilled part of o.
+func (c *ContractAccount) orderMargin(o *contractOrder) decimal.Decimal {
+	if !o.opens() || !o.isOpen() || o.DealVol.GreaterThanOrEqual(o.Vol) {
+		return decimal.Decimal{}
+	}
+
+	return o.OrderMargin.Mul(o.Vol.Sub(o.DealVol)).Div(o.Vol, divPlaces)
+}
+
+// funds returns the frozen order margins and the position margins of currency.
+func (c *ContractAccount) funds(currency string) (frozen, margin decimal.Decimal) {
+	for _, v := range c.state.Orders {
+		if c.details[v.Symbol] != nil && v.FeeCurrency == currency {
+			frozen = frozen.Add(c.orderMargin(v))
+		}
+	}
+
+	for _, v := range c.state.Positions {
+		if v.State != closedPosition && c.settle(v.Symbol) == currency {
+			margin = margin.Add(v.Im)
+		}
+	}
+
+	return frozen, margin
+}
+
+func (c *ContractAccount) available(currency string) decimal.Decimal {
+	frozen, margin := c.funds(currency)
+	return c.state.Balances[currency].Sub(frozen).Sub(margin)
+}
+
+func (c *ContractAccount) settle(symbol string) string {
+	if d, ok := c.details[symbol]; ok {
+		return d.SettleCoin
+	}
+
+	return ""
+}
+
+// ApplyTicker updates the prices of the symbol of t, triggers its plan and stop
+// orders, and matches its orders against the best bid and ask unless they are
+// matched against a depth.
+func (c *ContractAccount) ApplyTicker(t *contracttypes.Ticker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.details[t.Symbol]; !ok {
+		return
+	}
+
+	p := c.prices(t.Symbol)
+	p.Last, p.Fair, p.Index = t.LastPrice, t.FairPrice, t.IndexPrice
+	c.trigger(t.Symbol, t.Timestamp)
+
+	if _, ok := c.books[t.Symbol]; ok || !t.Bid1.IsPositive() || !t.Ask1.IsPositive() {
+		c.liquidate(t.Symbol, t.Timestamp)
+		c.saveUpdate()
+		return
+	}
+
+	p.Bid, p.Ask = t.Bid1, t.Ask1
+	c.apply(t.Symbol, &contractTick{time: t.Timestamp, ask: t.Ask1, bid: t.Bid1})
+}
+
+// ApplyDepthSnapshot replaces the book of symbol with a snapshot returned by
+// GetDepth and matches its orders against the book. Volumes are in contracts.
+func (c *ContractAccount) ApplyDepthSnapshot(symbol string, d *contracttypes.Depth) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.details[symbol]; !ok {
+		return
+	}
+
+	book := &depthBook{Book: orderbook.FromContractDepth(d, decimal.NewFromInt(1)), version: d.Version}
+	c.books[symbol] = book
+	c.applyBook(symbol, d.Timestamp, book.Book)
+}
+
+// ApplyDepth updates the book of symbol with a push of the depth topic and matches
+// its orders against the book, pushes not newer than the book are ignored. It
+// returns false when there is no book, or when versions are missing and the book
+// was dropped, ApplyDepthSnapshot must then seed the book again.
+func (c *ContractAccount) ApplyDepth(symbol string, d *contracttypes.Depth) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.details[symbol]; !ok {
+		return true
+	}
+
+	book, ok := c.books[symbol]
+	switch {
+	case !ok:
+		return false
+	case d.Version <= book.version:
+		return true
+	case d.Version > book.version+1:
+		delete(c.books, symbol)
+		c.logger.Warn("paper book dropped on a version gap", "symbol", symbol, "version", book.version, "next", d.Version)
+		return false
+	}
+
+	book.ApplyContractDepth(d, decimal.NewFromInt(1))
+	book.version = d.Version
+	c.applyBook(symbol, d.Timestamp, book.Book)
+
+	return true
+}
+
+// applyBook matches the orders of symbol against book, c.mu must be held.
+func (c *ContractAccount) applyBook(symbol string, ts int64, book *orderbook.Book) {
+	bid, okBid := book.BestBid()
+	ask, okAsk := book.BestAsk()
+	if !okBid || !okAsk {
+		return
+	}
+
+	p := c.prices(symbol)
+	p.Bid, p.Ask = bid.Price, ask.Price
+	if !p.Last.IsPositive() {
+		p.Last = bid.Price.Add(ask.Price).Div(decimal.NewFromInt(2), divPlaces)
+	}
+
+	c.apply(symbol, &contractTick{time: ts, ask: ask.Price, bid: bid.Price, book: book})
+}
+
+// DepthClient is implemented by marketdata.ContractMarketDataClient.
+type DepthClient interface {
+	GetDepth(ctx context.Context, param contracttypes.GetDepthParams) (*contracttypes.GetDepthResp, error)
+}
+
+// Watch subscribes cli to the ticker of symbols and matches the orders against the
+// pushes. When md is set the depth of symbols is subscribed too, the books are
+// seeded with md and seeded again when pushes are missing.
+func (c *ContractAccount) Watch(ctx context.Context, cli *websocketmarket.ContractMarketStreamClient, md DepthClient, symbols []string) error {
+	var topics []string
+	for _, v := range symbols {
+		symbol := v
+
+		topic, err := cli.GetTickerTopic(symbol)
+		if err != nil {
+			return err
+		}
+		cli.AddListener(topic, func(e any) {
+			if t, ok := e.(*contracttypes.Ticker); ok {
+				c.ApplyTicker(t)
+			}
+		})
+		topics = append(topics, topic)
+
+		if md == nil {
+			continue
+		}
+
+		topic, err = cli.GetDepthTopic(symbol)
+		if err != nil {
+			return err
+		}
+
+		var seeding atomic.Bool
+		cli.AddListener(topic, func(e any) {
+			d, ok := e.(*contracttypes.Depth)
+			if !ok || c.ApplyDepth(symbol, d) || !seeding.CompareAndSwap(false, true) {
+				return
+			}
+
+			go func() {
+				defer seeding.Store(false)
+				if err := c.seed(ctx, md, symbol); err != nil {
+					c.logger.Warn("paper book not seeded", "symbol", symbol, "error", err)
+				}
+			}()
+		})
+		topics = append(topics, topic)
+	}
+
+	if err := cli.Subscribe(topics); err != nil {
+		return err
+	}
+
+	// the pushes received before the snapshot are not newer than it
+	if md != nil {
+		for _, symbol := range symbols {
+			if err := c.seed(ctx, md, symbol); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *ContractAccount) seed(ctx context.Context, md DepthClient, symbol string) error {
+	resp, err := md.GetDepth(ctx, contracttypes.GetDepthParams{Symbol: symbol})
+	if err != nil {
+		return err
+	}
+	if resp.Data == nil {
+		return fmt.Errorf("%w: depth of %s", ErrEmptyDepth, symbol)
+	}
+
+	c.ApplyDepthSnapshot(symbol, resp.Data)
+
+	return nil
+}
+
+// saveUpdate saves the state after a market update, c.mu must be held.
+func (c *ContractAccount) saveUpdate() {
+	if err := c.save(); err != nil {
+		c.logger.Warn("paper contract state not saved", "path", c.statePath, "error", err)
+	}
+}
+
+func (c *ContractAccount) apply(symbol string, t *contractTick) {
+	c.state.Now = max(c.state.Now, t.time)
+
+	for _, o := range c.state.Orders {
+		if o.Symbol == symbol && o.isOpen() {
+			c.match(o, t)
+		}
+	}
+
+	c.liquidate(symbol, t.time)
+	c.saveUpdate()
+}
+
+// match updates the open order o with t.
+func (c *ContractAccount) match(o *contractOrder, t *contractTick) {
+	if o.CancelAt > 0 && o.CancelAt <= t.time {
+		c.closeOrder(o, t.time)
+		return
+	}
+
+	if o.Arrival > t.time {
+		return
+	}
+
+	if !o.Active {
+		o.Active = true
+		c.dirty = true
+
+		switch o.OrderType {
+		case types.MarketOrder, types.ConvertToCurrentPrice:
+			c.take(o, t, nil)
+			return
+		case types.LimitOrder, types.TransactOrCancel, types.TransactAllOrCancelAll:
+			if c.marketable(o, t) {
+				c.take(o, t, &o.Price)
+				return
+			}
+			if o.OrderType != types.LimitOrder {
+				c.closeOrder(o, t.time)
+				return
+			}
+		case types.PostOnlyMaker:
+			if c.marketable(o, t) {
+				c.closeOrder(o, t.time)
+				return
+			}
+		}
+	}
+
+	var through bool
+	if o.buys() {
+		through = t.ask.LessThan(o.Price)
+	} else {
+		through = t.bid.GreaterThan(o.Price)
+	}
+	if !through {
+		return
+	}
+
+	vol := o.Vol.Sub(o.DealVol)
+	if limit := restingVol(o, t); limit != nil {
+		vol = decimal.Min(vol, *limit)
+	}
+	if vol.IsPositive() {
+		c.fill(o, o.Price, vol, true, t.time)
+	}
+
+	if !o.isOpen() {
+		return
+	}
+	if o.DealVol.GreaterThanOrEqual(o.Vol) {
+		c.closeOrder(o, t.time)
+	}
+}
+
+func (c *ContractAccount) marketable(o *contractOrder, t *contractTick) bool {
+	if o.buys() {
+		return t.ask.LessThanOrEqual(o.Price)
+	}
+
+	return t.bid.GreaterThanOrEqual(o.Price)
+}
+
+// restingVol returns the volume available to the resting order o, nil when unknown.
+func restingVol(o *contractOrder, t *contractTick) *decimal.Decimal {
+	if t.book == nil {
+		if o.buys() {
+			return t.askQty
+		}
+		return t.bidQty
+	}
+
+	var ret decimal.Decimal
+	if o.buys() {
+		for _, l := range t.book.Asks {
+			if l.Price.GreaterThanOrEqual(o.Price) {
+				break
+			}
+			ret = ret.Add(l.Qty)
+		}
+	} else {
+		for _, l := range t.book.Bids {
+			if l.Price.LessThanOrEqual(o.Price) {
+				break
+			}
+			ret = ret.Add(l.Qty)
+		}
+	}
+
+	return &ret
+}
+
+// take fills o at once as a taker, not beyond limit when set. The part of a limit
+// order a book could not fill rests on the book, the part of other orders is
+// canceled. Fill-or-kill orders are canceled whole when they can not fill whole.
+func (c *ContractAccount) take(o *contractOrder, t *contractTick, limit *decimal.Decimal) {
+	remaining := o.Vol.Sub(o.DealVol)
+
+	vol, price := remaining, t.ask
+	if !o.buys() {
+		price = t.bid
+	}
+
+	if t.book != nil {
+		levels := t.book.Asks
+		if !o.buys() {
+			levels = t.book.Bids
+		}
+
+		var quote decimal.Decimal
+		vol, quote = walk(levels, o.buys(), remaining, limit)
+		if vol.IsPositive() {
+			price = quote.Div(vol, divPlaces)
+		}
+	}
+
+	if o.OrderType == types.TransactAllOrCancelAll && vol.LessThan(remaining) {
+		vol = decimal.Decimal{}
+	}
+
+	if vol.IsPositive() {
+		c.fill(o, price, vol, false, t.time)
+	}
+
+	if o.isOpen() && (o.OrderType != types.LimitOrder || o.DealVol.GreaterThanOrEqual(o.Vol)) {
+		c.closeOrder(o, t.time)
+	}
+}
+
+// walk takes up to vol from levels, not beyond limit when set. It returns the volume
+// and the price times volume taken.
+func walk(levels []orderbook.Level, buy bool, vol decimal.Decimal, limit *decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	var filled, quote decimal.Decimal
+
+	for _, l := range levels {
+		if limit != nil && (buy && l.Price.GreaterThan(*limit) || !buy && l.Price.LessThan(*limit)) {
+			break
+		}
+
+		take := decimal.Min(l.Qty, vol.Sub(filled))
+		filled = filled.Add(take)
+		quote = quote.Add(take.Mul(l.Price))
+
+		if filled.GreaterThanOrEqual(vol) {
+			break
+		}
+	}
+
+	return filled, quote
+}
+
+// fill executes vol of o at price, updates its position and pays the fee.
+func (c *ContractAccount) fill(o *contractOrder, price, vol decimal.Decimal, maker bool, ts int64) {
+	d := c.details[o.Symbol]
+
+	rate := d.TakerFeeRate
+	if maker {
+		rate = d.MakerFeeRate
+	}
+
+	value := price.Mul(vol).Mul(d.ContractSize)
+	fee := value.Mul(rate)
+	c.state.Balances[d.SettleCoin] = c.state.Balances[d.SettleCoin].Sub(fee)
+
+	var pnl decimal.Decimal
+	if o.opens() {
+		p := c.position(o.Symbol, positionType(o.Side))
+		if p == nil {
+			c.state.PositionSeq++
+			p = &types.OpenPosition{
+				PositionID:   c.state.PositionSeq,
+				Symbol:       o.Symbol,
+				PositionType: positionType(o.Side),
+				OpenType:     o.OpenType,
+				State:        1,
+				Leverage:     o.Leverage,
+				CreateTime:   ts,
+			}
+			c.state.Positions = append(c.state.Positions, p)
+		}
+
+		hold := p.HoldVol.Add(vol)
+		p.HoldAvgPrice = p.HoldAvgPrice.Mul(p.HoldVol).Add(price.Mul(vol)).Div(hold, divPlaces)
+		p.OpenAvgPrice = p.HoldAvgPrice
+		p.HoldVol = hold
+
+		im := value.Div(decimal.NewFromInt(int64(p.Leverage)), divPlaces)
+		p.Im = p.Im.Add(im)
+		p.Oim = p.Oim.Add(im)
+		p.Realised = p.Realised.Sub(fee)
+		p.UpdateTime = ts
+		o.PositionId = p.PositionID
+		c.updateLiquidatePrice(p)
+		c.addStopVol(o, vol, ts)
+	} else {
+		p := c.positionByID(o.PositionId)
+
+		pnl = price.Sub(p.HoldAvgPrice).Mul(vol).Mul(d.ContractSize)
+		if p.PositionType == types.ShortPosition {
+			pnl = pnl.Neg()
+		}
+		c.state.Balances[d.SettleCoin] = c.state.Balances[d.SettleCoin].Add(pnl)
+
+		closed := p.CloseVol.Add(vol)
+		p.CloseAvgPrice = p.CloseAvgPrice.Mul(p.CloseVol).Add(price.Mul(vol)).Div(closed, divPlaces)
+		p.CloseVol = closed
+
+		p.Im = p.Im.Sub(p.Im.Mul(vol).Div(p.HoldVol, divPlaces))
+		p.HoldVol = p.HoldVol.Sub(vol)
+		p.FrozenVol = p.FrozenVol.Sub(vol)
+		p.Realised = p.Realised.Add(pnl).Sub(fee)
+		p.UpdateTime = ts
+		if !p.HoldVol.IsPositive() {
+			p.State = closedPosition
+			p.Im = decimal.Decimal{}
+			p.LiquidatePrice = decimal.Decimal{}
+			c.endPositionStops(p.PositionID, ts)
+		} else {
+			c.updateLiquidatePrice(p)
+		}
+	}
+
+	deal := o.DealVol.Add(vol)
+	o.DealAvgPrice = o.DealAvgPrice.Mul(o.DealVol).Add(price.Mul(vol)).Div(deal, divPlaces)
+	o.DealVol = deal
+	o.Profit = o.Profit.Add(pnl)
+	if maker {
+		o.MakerFee = o.MakerFee.Add(fee)
+	} else {
+		o.TakerFee = o.TakerFee.Add(fee)
+	}
+	o.UpdateTime = ts
+
+	c.state.DealSeq++
+	c.state.Deals = append(c.state.Deals, &types.Deal{
+		Id:          c.state.DealSeq,
+		Symbol:      o.Symbol,
+		Side:        o.Side,
+		Vol:         vol,
+		Price:       price,
+		FeeCurrency: d.SettleCoin,
+		Fee:         fee,
+		Timestamp:   ts,
+		Profit:      pnl,
+		Category:    o.Category,
+		OrderId:     o.OrderId,
+		IsTaker:     !maker,
+	})
+
+	c.dirty = true
+}
+
+// updateLiquidatePrice sets the price at which the margin of the isolated position p
+// falls to the maintenance margin.
+func (c *ContractAccount) updateLiquidatePrice(p *types.OpenPosition) {
+	if p.OpenType != types.IsolatedMargin || !p.HoldVol.IsPositive() {
+		p.LiquidatePrice = decimal.Decimal{}
+		return
+	}
+
+	d := c.details[p.Symbol]
+	one := decimal.NewFromInt(1)
+	qty := p.HoldVol.Mul(d.ContractSize)
+
+	// long: im + (price - avg) * qty = price * qty * mmr
+	// short: im + (avg - price) * qty = price * qty * mmr
+	var price decimal.Decimal
+	if p.PositionType == types.LongPosition {
+		price = p.HoldAvgPrice.Mul(qty).Sub(p.Im).Div(qty.Mul(one.Sub(d.MaintenanceMarginRate)), divPlaces)
+	} else {
+		price = p.HoldAvgPrice.Mul(qty).Add(p.Im).Div(qty.Mul(one.Add(d.MaintenanceMarginRate)), divPlaces)
+	}
+
+	p.LiquidatePrice = decimal.Max(price, decimal.Decimal{})
+}
+
+// closeOrder ends the open order o, its unfilled part is canceled.
+func (c *ContractAccount) closeOrder(o *contractOrder, ts int64) {
+	if !o.opens() {
+		if p := c.positionByID(o.PositionId); p != nil && p.State != closedPosition {
+			p.FrozenVol = p.FrozenVol.Sub(o.Vol.Sub(o.DealVol))
+		}
+	}
+
+	if o.DealVol.GreaterThanOrEqual(o.Vol) {
+		o.State = types.OrderCompleted
+	} else {
+		o.State = types.OrderCancelled
+	}
+
+	// the stop order of an opening order ends with it when nothing was filled
+	if s := c.stopByOrder(o.OrderId); s != nil && o.opens() && !o.DealVol.IsPositive() {
+		c.endStop(s, types.StopInvalidated, ts)
+	}
+	o.UpdateTime = ts
+	c.dirty = true
+}
+
+// liquidate closes the isolated positions of symbol whose margin fell to the
+// maintenance margin, and every cross position of a currency whose cross margin did.
+// The margin of a liquidated isolated position is lost.
+func (c *ContractAccount) liquidate(symbol string, ts int64) {
+	mmr := func(p *types.OpenPosition) decimal.Decimal {
+		d := c.details[p.Symbol]
+		return c.prices(p.Symbol).mark().Mul(p.HoldVol).Mul(d.ContractSize).Mul(d.MaintenanceMarginRate)
+	}
+
+	for _, p := range c.state.Positions {
+		if p.State == closedPosition || p.Symbol != symbol || p.OpenType != types.IsolatedMargin {
+			continue
+		}
+		if !c.prices(symbol).mark().IsPositive() {
+			return
+		}
+
+		if p.Im.Add(c.unrealized(p)).LessThanOrEqual(mmr(p)) {
+			currency := c.settle(symbol)
+			c.state.Balances[currency] = c.state.Balances[currency].Sub(p.Im)
+			p.Realised = p.Realised.Sub(p.Im)
+			c.closePosition(p, ts)
+		}
+	}
+
+	currency := c.settle(symbol)
+	var cross []*types.OpenPosition
+	var equity, maintenance decimal.Decimal
+	for _, p := range c.state.Positions {
+		if p.State == closedPosition || p.OpenType != types.CrossMargin || c.settle(p.Symbol) != currency {
+			continue
+		}
+		if !c.prices(p.Symbol).mark().IsPositive() {
+			return
+		}
+
+		cross = append(cross, p)
+		equity = equity.Add(p.Im).Add(c.unrealized(p))
+		maintenance = maintenance.Add(mmr(p))
+	}
+
+	if len(cross) == 0 || equity.Add(c.available(currency)).GreaterThan(maintenance) {
+		return
+	}
+
+	for _, p := range cross {
+		pnl := c.unrealized(p)
+		c.state.Balances[currency] = c.state.Balances[currency].Add(pnl)
+		p.Realised = p.Realised.Add(pnl)
+		c.closePosition(p, ts)
+	}
+}
+
+// closePosition closes p at its mark price after a liquidation and cancels the
+// orders closing it.
+func (c *ContractAccount) closePosition(p *types.OpenPosition, ts int64) {
+	c.logger.Warn("paper position liquidated", "symbol", p.Symbol, "position_id", p.PositionID, "vol", p.HoldVol)
+
+	mark := c.prices(p.Symbol).mark()
+	closed := p.CloseVol.Add(p.HoldVol)
+	p.CloseAvgPrice = p.CloseAvgPrice.Mul(p.CloseVol).Add(mark.Mul(p.HoldVol)).Div(closed, divPlaces)
+	p.CloseVol = closed
+	p.HoldVol = decimal.Decimal{}
+	p.FrozenVol = decimal.Decimal{}
+	p.Im = decimal.Decimal{}
+	p.LiquidatePrice = decimal.Decimal{}
+	p.State = closedPosition
+	p.UpdateTime = ts
+	c.endPositionStops(p.PositionID, ts)
+
+	for _, o := range c.state.Orders {
+		if o.isOpen() && !o.opens() && o.PositionId == p.PositionID {
+			c.closeOrder(o, ts)
+		}
+	}
+
+	c.dirty = true
+}
@@ -0,0 +1,1032 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package paper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jl1/nexapi/mexc/backtest"
+	"github.com/jl1/nexapi/mexc/contract/account"
+	"github.com/jl1/nexapi/mexc/contract/account/types"
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	"github.com/jl1/nexapi/mexc/rules"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// ContractOrderClient has the methods of account.ContractAccountClient, it is
+// implemented by it and by ContractAccount.
+type ContractOrderClient interface {
+	GetAccountAsset(ctx context.Context, currency string) (*types.GetAccountAsset, error)
+	GetAccountAssets(ctx context.Context) (*types.GetAccountAssets, error)
+	GetOpenPositions(ctx context.Context, param types.GetOpenPositionsParams) (*types.GetOpenPositions, error)
+	GetHistoryPositions(ctx context.Context, param types.GetHistoryPositionsParams) (*types.GetHistoryPositions, error)
+	GetPositionLeverage(ctx context.Context, param types.GetLeverageParams) (*types.GetLeverageResp, error)
+	SetPositionLeverage(ctx context.Context, param types.SetLeverageParams) (*types.SetLeverageResp, error)
+	SubmitOrder(ctx context.Context, param types.NewOrderParam) (*types.SubmitOrderResp, error)
+	SubmitBatchOrders(ctx context.Context, params []types.NewOrderParam) (*types.SubmitBatchOrdersResp, error)
+	CancelOrders(ctx context.Context, orderIds []int64) (*types.CancelOrdersResp, error)
+	CancelOrderWithExternalOid(ctx context.Context, param types.CancelOrderWithExternalOidParams) (*types.Response, error)
+	CancelAllOrders(ctx context.Context, param types.CancelAllOrdersParams) (*types.Response, error)
+	GetOrder(ctx context.Context, orderId int64) (*types.GetOrderResp, error)
+	GetOrderByExternalOid(ctx context.Context, param types.GetOrderByExternalOidParams) (*types.GetOrderResp, error)
+	GetOrdersByIds(ctx context.Context, orderIds []int64) (*types.GetOrdersResp, error)
+	GetOpenOrders(ctx context.Context, param types.GetOpenOrdersParams) (*types.GetOrdersResp, error)
+	GetHistoryOrders(ctx context.Context, param types.GetHistoryOrdersParams) (*types.GetOrdersResp, error)
+	GetOrderDealDetails(ctx context.Context, orderId int64) (*types.GetDealsResp, error)
+	GetOrderDeals(ctx context.Context, param types.GetOrderDealsParams) (*types.GetDealsResp, error)
+	ChangeMargin(ctx context.Context, param types.ChangeMarginParams) (*types.Response, error)
+	ChangeAutoAddMargin(ctx context.Context, param types.ChangeAutoAddMarginParams) (*types.Response, error)
+	GetPositionMode(ctx context.Context) (*types.GetPositionModeResp, error)
+	ChangePositionMode(ctx context.Context, param types.ChangePositionModeParams) (*types.Response, error)
+	PlacePlanOrder(ctx context.Context, param types.PlacePlanOrderParams) (*types.PlacePlanOrderResp, error)
+	CancelPlanOrders(ctx context.Context, params []types.CancelPlanOrderParam) (*types.Response, error)
+	CancelAllPlanOrders(ctx context.Context, param types.CancelAllPlanOrdersParams) (*types.Response, error)
+	GetPlanOrders(ctx context.Context, param types.GetPlanOrdersParams) (*types.GetPlanOrdersResp, error)
+	GetStopOrders(ctx context.Context, param types.GetStopOrdersParams) (*types.GetStopOrdersResp, error)
+	CancelStopOrders(ctx context.Context, params []types.CancelStopOrderParam) (*types.Response, error)
+	CancelAllStopOrders(ctx context.Context, param types.CancelAllStopOrdersParams) (*types.Response, error)
+	ChangeStopPrice(ctx context.Context, param types.ChangeStopPriceParams) (*types.Response, error)
+	ChangeStopPlanPrice(ctx context.Context, param types.ChangeStopPlanPriceParams) (*types.Response, error)
+	GetFundingRecords(ctx context.Context, param types.GetFundingRecordsParams) (*types.GetFundingRecords, error)
+	GetTieredFeeRate(ctx context.Context, param types.GetTieredFeeRateParams) (*types.GetTieredFeeRateResp, error)
+	GetRiskLimit(ctx context.Context, param types.GetRiskLimitParams) (*types.GetRiskLimitResp, error)
+	GetProfitRate(ctx context.Context, rateType types.ProfitRateType) (*types.GetProfitRateResp, error)
+	GetFeeDetails(ctx context.Context, param types.GetFeeDetailsParams) (*types.GetFeeDetailsResp, error)
+	GetTransferRecords(ctx context.Context, param types.GetTransferRecordsParams) (*types.GetTransferRecordsResp, error)
+	WalkHistoryOrders(ctx context.Context, param types.GetHistoryOrdersParams, fn func([]*types.Order) error) error
+	WalkOrderDeals(ctx context.Context, param types.GetOrderDealsParams, fn func([]*types.Deal) error) error
+}
+
+// maxBatchOrders is the maximum number of orders of SubmitBatchOrders.
+const maxBatchOrders = 50
+
+// maxPageSize is the page size of the walks.
+const maxPageSize = 100
+
+func success() types.Response {
+	return types.Response{Success: true}
+}
+
+// pageOf returns the page num of size items out of total, with the defaults of page.
+func pageOf(total, num, size int) types.Page {
+	if num <= 0 {
+		num = 1
+	}
+	if size <= 0 {
+		size = 20
+	}
+
+	return types.Page{PageSize: size, TotalCount: total, TotalPage: (total + size - 1) / size, CurrentPage: num}
+}
+
+// asset returns the asset of currency, c.mu must be held.
+func (c *ContractAccount) asset(currency string) *types.ContractAsset {
+	frozen, margin := c.funds(currency)
+
+	var unrealized decimal.Decimal
+	for _, v := range c.state.Positions {
+		if v.State != closedPosition && c.settle(v.Symbol) == currency {
+			unrealized = unrealized.Add(c.unrealized(v))
+		}
+	}
+
+	balance := c.state.Balances[currency]
+	return &types.ContractAsset{
+		Currency:         currency,
+		PositionMargin:   margin,
+		AvailableBalance: balance.Sub(frozen).Sub(margin),
+		CashBalance:      balance.Sub(margin),
+		FrozenBalance:    frozen,
+		Equity:           balance.Add(unrealized),
+		Unrealized:       unrealized,
+	}
+}
+
+func (c *ContractAccount) GetAccountAsset(_ context.Context, currency string) (*types.GetAccountAsset, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return &types.GetAccountAsset{Response: success(), Data: c.asset(currency)}, nil
+}
+
+func (c *ContractAccount) GetAccountAssets(_ context.Context) (*types.GetAccountAssets, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	currencies := make([]string, 0, len(c.state.Balances))
+	for k := range c.state.Balances {
+		currencies = append(currencies, k)
+	}
+	sort.Strings(currencies)
+
+	ret := &types.GetAccountAssets{Response: success(), Data: make([]*types.ContractAsset, 0, len(currencies))}
+	for _, v := range currencies {
+		ret.Data = append(ret.Data, c.asset(v))
+	}
+
+	return ret, nil
+}
+
+func (c *ContractAccount) GetOpenPositions(_ context.Context, param types.GetOpenPositionsParams) (*types.GetOpenPositions, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := &types.GetOpenPositions{Response: success(), Data: []*types.OpenPosition{}}
+	for _, v := range c.state.Positions {
+		if v.State != closedPosition && (param.Symbol == "" || v.Symbol == param.Symbol) {
+			p := *v
+			ret.Data = append(ret.Data, &p)
+		}
+	}
+
+	return ret, nil
+}
+
+// GetHistoryPositions returns the closed positions, the last closed first.
+func (c *ContractAccount) GetHistoryPositions(_ context.Context, param types.GetHistoryPositionsParams) (*types.GetHistoryPositions, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var list []*types.OpenPosition
+	for i := len(c.state.Positions) - 1; i >= 0; i-- {
+		v := c.state.Positions[i]
+		if v.State != closedPosition || (param.Symbol != "" && v.Symbol != param.Symbol) || (param.Type != 0 && v.PositionType != param.Type) {
+			continue
+		}
+		p := *v
+		list = append(list, &p)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].UpdateTime > list[j].UpdateTime
+	})
+
+	return &types.GetHistoryPositions{Response: success(), Data: page(list, param.PageNum, param.PageSize)}, nil
+}
+
+// GetPositionLeverage returns the leverage of the long and short positions of the
+// symbol, Level is always 1 and Imr is the inverse of the leverage.
+func (c *ContractAccount) GetPositionLeverage(_ context.Context, param types.GetLeverageParams) (*types.GetLeverageResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, err := c.detail(param.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	// the API returns the long and short leverage as a list, the response type keeps
+	// only one of them, the long one
+	leverage := c.defaultLeverage(d, types.LongPosition)
+	if p := c.position(d.Symbol, types.LongPosition); p != nil {
+		leverage = p.Leverage
+	}
+
+	ret := &types.GetLeverageResp{Response: success()}
+	ret.Data.PositionType = types.LongPosition
+	ret.Data.Level = 1
+	ret.Data.Leverage = leverage
+	ret.Data.Imr = decimal.NewFromInt(1).Div(decimal.NewFromInt(int64(leverage)), divPlaces)
+	ret.Data.Mmr = d.MaintenanceMarginRate
+
+	return ret, nil
+}
+
+// SetPositionLeverage changes the leverage of an open position, identified by
+// PositionId, or the leverage of the future positions of Symbol and PositionType.
+// Lowering the leverage of a position adds the margin it needs from the available
+// balance.
+func (c *ContractAccount) SetPositionLeverage(_ context.Context, param types.SetLeverageParams) (*types.SetLeverageResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := &types.SetLeverageResp{Response: success()}
+
+	if param.PositionId != 0 {
+		p := c.positionByID(param.PositionId)
+		if p == nil || p.State == closedPosition {
+			return nil, fmt.Errorf("%w %d", ErrNoPosition, param.PositionId)
+		}
+
+		d := c.details[p.Symbol]
+		if err := checkLeverage(d, param.Leverage); err != nil {
+			return nil, err
+		}
+
+		im := p.HoldAvgPrice.Mul(p.HoldVol).Mul(d.ContractSize).Div(decimal.NewFromInt(int64(param.Leverage)), divPlaces)
+		if im.Sub(p.Im).GreaterThan(c.available(d.SettleCoin)) {
+			return nil, fmt.Errorf("%w: %s margin %s", backtest.ErrInsufficientBalance, d.SettleCoin, im)
+		}
+
+		p.Im, p.Leverage = im, param.Leverage
+		c.updateLiquidatePrice(p)
+		c.state.Leverage[leverageKey(p.Symbol, p.PositionType)] = param.Leverage
+
+		ret.Data.PositionId, ret.Data.Symbol, ret.Data.PositionType = p.PositionID, p.Symbol, p.PositionType
+	} else {
+		if param.Symbol == "" || (param.PositionType != types.LongPosition && param.PositionType != types.ShortPosition) {
+			return nil, fmt.Errorf("%w: symbol and position type are required without a position id", backtest.ErrInvalidOrder)
+		}
+
+		d, err := c.detail(param.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkLeverage(d, param.Leverage); err != nil {
+			return nil, err
+		}
+
+		c.state.Leverage[leverageKey(param.Symbol, param.PositionType)] = param.Leverage
+		ret.Data.Symbol, ret.Data.PositionType = param.Symbol, param.PositionType
+	}
+	ret.Data.Leverage = param.Leverage
+
+	c.dirty = true
+	return ret, c.save()
+}
+
+// submit checks param and registers its order, c.mu must be held.
+func (c *ContractAccount) submit(param types.NewOrderParam) (*contractOrder, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := c.detail(param.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &contractOrder{Order: types.Order{
+		Symbol:      param.Symbol,
+		Vol:         param.Vol,
+		Side:        param.Side,
+		Category:    1,
+		OrderType:   param.Type,
+		FeeCurrency: d.SettleCoin,
+		OpenType:    param.OpenType,
+		State:       types.OrderUncompleted,
+		ExternalOid: param.ExternalOid,
+		CreateTime:  c.state.Now,
+		UpdateTime:  c.state.Now,
+	}}
+
+	r := rules.FromContractDetail(d)
+	if err := r.ValidateQty(param.Vol); err != nil {
+		return nil, err
+	}
+
+	// market orders are valued at the opposite best price
+	p := c.prices(param.Symbol)
+	price := p.Ask
+	if !o.buys() {
+		price = p.Bid
+	}
+	if !price.IsPositive() {
+		price = p.Last
+	}
+
+	if param.Type == types.MarketOrder || param.Type == types.ConvertToCurrentPrice {
+		if !price.IsPositive() {
+			return nil, fmt.Errorf("%w %s", backtest.ErrNoPrice, param.Symbol)
+		}
+	} else {
+		if param.Price == nil {
+			return nil, fmt.Errorf("%w: price is required", backtest.ErrInvalidOrder)
+		}
+		if err := r.ValidatePrice(*param.Price); err != nil {
+			return nil, err
+		}
+		o.Price, price = *param.Price, *param.Price
+	}
+
+	if param.StopLossPrice != nil || param.TakeProfitPrice != nil {
+		if !o.opens() {
+			return nil, fmt.Errorf("%w: stop-loss and take-profit prices of a closing order", ErrUnsupportedOrder)
+		}
+		if err := checkStopPrices(r, param.StopLossPrice, param.TakeProfitPrice); err != nil {
+			return nil, err
+		}
+		o.StopLossPrice, o.TakeProfitPrice = stopPrice(param.StopLossPrice), stopPrice(param.TakeProfitPrice)
+	}
+
+	if param.ExternalOid != "" {
+		for _, v := range c.state.Orders {
+			if v.Symbol == param.Symbol && v.ExternalOid == param.ExternalOid {
+				return nil, fmt.Errorf("%w: duplicate external oid %s", backtest.ErrInvalidOrder, param.ExternalOid)
+			}
+		}
+	}
+
+	ptype := positionType(param.Side)
+	pos := c.position(param.Symbol, ptype)
+
+	if o.opens() {
+		if c.state.PositionMode == types.OneWayMode {
+			opposite := types.ShortPosition
+			if ptype == types.ShortPosition {
+				opposite = types.LongPosition
+			}
+			if c.position(param.Symbol, opposite) != nil {
+				return nil, fmt.Errorf("%w: %s", ErrOppositePosition, param.Symbol)
+			}
+		}
+
+		o.Leverage = param.Leverage
+		if o.Leverage == 0 {
+			o.Leverage = c.defaultLeverage(d, ptype)
+		}
+		if pos != nil {
+			if pos.OpenType != param.OpenType || pos.Leverage != o.Leverage {
+				return nil, fmt.Errorf("%w: the open type and leverage must match the position %d", backtest.ErrInvalidOrder, pos.PositionID)
+			}
+		}
+		if err := checkLeverage(d, o.Leverage); err != nil {
+			return nil, err
+		}
+
+		o.OrderMargin = price.Mul(param.Vol).Mul(d.ContractSize).Div(decimal.NewFromInt(int64(o.Leverage)), divPlaces)
+		if o.OrderMargin.GreaterThan(c.available(d.SettleCoin)) {
+			return nil, fmt.Errorf("%w: %s margin %s", backtest.ErrInsufficientBalance, d.SettleCoin, o.OrderMargin)
+		}
+	} else {
+		if pos == nil || (param.PositionId != 0 && pos.PositionID != param.PositionId) {
+			return nil, fmt.Errorf("%w: %s", ErrNoPosition, param.Symbol)
+		}
+		if pos.HoldVol.Sub(pos.FrozenVol).LessThan(param.Vol) {
+			return nil, fmt.Errorf("%w: %s closable volume %s", ErrNoPosition, param.Symbol, pos.HoldVol.Sub(pos.FrozenVol))
+		}
+
+		pos.FrozenVol = pos.FrozenVol.Add(param.Vol)
+		o.PositionId = pos.PositionID
+		o.Leverage = pos.Leverage
+		o.OpenType = pos.OpenType
+	}
+
+	c.state.OrderSeq++
+	o.OrderId = c.state.OrderSeq
+	o.Arrival = c.state.Now + c.latency.Latency().Milliseconds()
+
+	c.state.Orders = append(c.state.Orders, o)
+	c.orders[o.OrderId] = o
+	c.dirty = true
+
+	if o.StopLossPrice.IsPositive() || o.TakeProfitPrice.IsPositive() {
+		c.newStop(o, param.LossTrend, param.ProfitTrend)
+	}
+
+	return o, nil
+}
+
+// SubmitOrder places an order, it is matched by the next market update following its
+// arrival. The stop-loss and take-profit prices of an opening order place a stop order
+// on the volume it fills, closing orders refuse them with ErrUnsupportedOrder.
+func (c *ContractAccount) SubmitOrder(_ context.Context, param types.NewOrderParam) (*types.SubmitOrderResp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, err := c.submit(param)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.SubmitOrderResp{Response: success(), Data: o.OrderId}, c.save()
+}
+
+// SubmitBatchOrders places up to 50 orders, the orders refused have an error code of
+// 1 and the error as message.
+func (c *ContractAccount) SubmitBatchOrders(_ context.Context, params []types.NewOrderParam) (*types.SubmitBatchOrdersResp, error) {
+	if len(params) == 0 || len(params) > maxBatchOrders {
+		return nil, fmt.Errorf("%w: %d orders, between 1 and %d are accepted", backtest.ErrInvalidOrder, len(params), maxBatchOrders)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := &types.SubmitBatchOrdersResp{Response: success()}
+	ret.Data = make([]struct {
+		OrderId     int64  `json:"orderId"`
+		ExternalOid string `json:"externalOid"`
+		ErrorCode   int    `json:"errorCode"`
+		ErrorMsg    string `json:"errorMsg"`
+	}, len(params))
+
+	for i, v := range params {
+		ret.Data[i].ExternalOid = v.ExternalOid
+
+		o, err := c.submit(v)
+		if err != nil {
+			ret.Data[i].ErrorCode, ret.Data[i].ErrorMsg = 1, err.Error()
+			continue
+		}
+		ret.Data[i].OrderId = o.OrderId
+	}
+
+	return ret, c.save()
+}
+
+// cancel requests the cancellation of o, it takes effect once the request reaches
+// the exchange, c.mu must be held.
+func (c *ContractAccount) cancel(o *contractOrder) error {
+	if !o.isOpen() || o.CancelAt > 0 {
+		return fmt.Errorf("%w: order %d is not open", backtest.ErrInvalidOrder, o.OrderId)
+	}
+
+	o.CancelAt = max(c.state.Now+c.latency.Latency().Milliseconds(), o.Arrival, 1)
+	c.dirty = true
+
+	return nil
+}
+
+// CancelOrders cancels orders by id, the orders which can not be canceled have an
+// error code of 1 and the error as message.
+func (c *ContractAccount) CancelOrders(_ context.Context, orderIds []int64) (*types.CancelOrdersResp, error) {
+	if len(orderIds) == 0 || len(orderIds) > maxBatchOrders {
+		return nil, fmt.Errorf("%w: %d orders, between 1 and %d are accepted", backtest.ErrInvalidOrder, len(orderIds), maxBatchOrders)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := &types.CancelOrdersResp{Response: success()}
+	ret.Data = make([]struct {
+		OrderId   int64  `json:"orderId"`
+		ErrorCode int    `json:"errorCode"`
+		ErrorMsg  string `json:"errorMsg"`
+	}, len(orderIds))
+
+	for i, v := range orderIds {
+		ret.Data[i].OrderId = v
+
+		err := backtest.ErrUnknownOrder
+		if o, ok := c.orders[v]; ok {
+			err = c.cancel(o)
+		}
+		if err != nil {
+			ret.Data[i].ErrorCode, ret.Data[i].ErrorMsg = 1, err.Error()
+		}
+	}
+
+	return ret, c.save()
+}
+
+func (c *ContractAccount) CancelOrderWithExternalOid(_ context.Context, param types.CancelOrderWithExternalOidParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o := c.orderByExternalOid(param.Symbol, param.ExternalOid)
+	if o == nil {
+		return nil, fmt.Errorf("%w: %s %s", backtest.ErrUnknownOrder, param.Symbol, param.ExternalOid)
+	}
+
+	if err := c.cancel(o); err != nil {
+		return nil, err
+	}
+
+	ret := success()
+	return &ret, c.save()
+}
+
+// CancelAllOrders cancels the open orders of the symbol, of every symbol when empty.
+func (c *ContractAccount) CancelAllOrders(_ context.Context, param types.CancelAllOrdersParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, v := range c.state.Orders {
+		if v.isOpen() && v.CancelAt == 0 && (param.Symbol == "" || v.Symbol == param.Symbol) {
+			_ = c.cancel(v)
+		}
+	}
+
+	ret := success()
+	return &ret, c.save()
+}
+
+func (c *ContractAccount) orderByExternalOid(symbol, externalOid string) *contractOrder {
+	for _, v := range c.state.Orders {
+		if v.Symbol == symbol && v.ExternalOid == externalOid {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func (c *ContractAccount) GetOrder(_ context.Context, orderId int64) (*types.GetOrderResp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, ok := c.orders[orderId]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", backtest.ErrUnknownOrder, orderId)
+	}
+
+	ret := o.Order
+	return &types.GetOrderResp{Response: success(), Data: &ret}, nil
+}
+
+func (c *ContractAccount) GetOrderByExternalOid(_ context.Context, param types.GetOrderByExternalOidParams) (*types.GetOrderResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o := c.orderByExternalOid(param.Symbol, param.ExternalOid)
+	if o == nil {
+		return nil, fmt.Errorf("%w: %s %s", backtest.ErrUnknownOrder, param.Symbol, param.ExternalOid)
+	}
+
+	ret := o.Order
+	return &types.GetOrderResp{Response: success(), Data: &ret}, nil
+}
+
+// GetOrdersByIds skips the unknown ids.
+func (c *ContractAccount) GetOrdersByIds(_ context.Context, orderIds []int64) (*types.GetOrdersResp, error) {
+	if len(orderIds) == 0 || len(orderIds) > maxBatchOrders {
+		return nil, fmt.Errorf("%w: %d orders, between 1 and %d are accepted", backtest.ErrInvalidOrder, len(orderIds), maxBatchOrders)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := &types.GetOrdersResp{Response: success(), Data: []*types.Order{}}
+	for _, v := range orderIds {
+		if o, ok := c.orders[v]; ok {
+			order := o.Order
+			ret.Data = append(ret.Data, &order)
+		}
+	}
+
+	return ret, nil
+}
+
+// filterOrders returns the orders matched by keep, the last created first.
+func (c *ContractAccount) filterOrders(keep func(*contractOrder) bool) []*types.Order {
+	var ret []*types.Order
+	for i := len(c.state.Orders) - 1; i >= 0; i-- {
+		if v := c.state.Orders[i]; keep(v) {
+			order := v.Order
+			ret = append(ret, &order)
+		}
+	}
+
+	return ret
+}
+
+func (c *ContractAccount) GetOpenOrders(_ context.Context, param types.GetOpenOrdersParams) (*types.GetOrdersResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := c.filterOrders(func(o *contractOrder) bool {
+		return o.isOpen() && (param.Symbol == "" || o.Symbol == param.Symbol)
+	})
+
+	return &types.GetOrdersResp{Response: success(), Data: page(list, param.PageNum, param.PageSize)}, nil
+}
+
+// GetHistoryOrders returns the orders of every state, States filters them.
+func (c *ContractAccount) GetHistoryOrders(_ context.Context, param types.GetHistoryOrdersParams) (*types.GetOrdersResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := parseStates(param.States)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := c.filterOrders(func(o *contractOrder) bool {
+		return (param.Symbol == "" || o.Symbol == param.Symbol) &&
+			(len(states) == 0 || states[o.State]) &&
+			(param.Category == 0 || o.Category == param.Category) &&
+			(param.Side == 0 || o.Side == param.Side) &&
+			(param.StartTime == 0 || o.CreateTime >= param.StartTime) &&
+			(param.EndTime == 0 || o.CreateTime <= param.EndTime)
+	})
+
+	return &types.GetOrdersResp{Response: success(), Data: page(list, param.PageNum, param.PageSize)}, nil
+}
+
+// parseStates parses a list of states separated by commas.
+func parseStates(s string) (map[int]bool, error) {
+	states := make(map[int]bool)
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		state, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: state %q", backtest.ErrInvalidOrder, v)
+		}
+		states[state] = true
+	}
+
+	return states, nil
+}
+
+// deals returns the deals matched by keep, the last first.
+func (c *ContractAccount) deals(keep func(*types.Deal) bool) []*types.Deal {
+	ret := []*types.Deal{}
+	for i := len(c.state.Deals) - 1; i >= 0; i-- {
+		if v := c.state.Deals[i]; keep(v) {
+			deal := *v
+			ret = append(ret, &deal)
+		}
+	}
+
+	return ret
+}
+
+func (c *ContractAccount) GetOrderDealDetails(_ context.Context, orderId int64) (*types.GetDealsResp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.orders[orderId]; !ok {
+		return nil, fmt.Errorf("%w %d", backtest.ErrUnknownOrder, orderId)
+	}
+
+	list := c.deals(func(d *types.Deal) bool {
+		return d.OrderId == orderId
+	})
+
+	return &types.GetDealsResp{Response: success(), Data: list}, nil
+}
+
+func (c *ContractAccount) GetOrderDeals(_ context.Context, param types.GetOrderDealsParams) (*types.GetDealsResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := c.deals(func(d *types.Deal) bool {
+		return d.Symbol == param.Symbol &&
+			(param.StartTime == 0 || d.Timestamp >= param.StartTime) &&
+			(param.EndTime == 0 || d.Timestamp <= param.EndTime)
+	})
+
+	return &types.GetDealsResp{Response: success(), Data: page(list, param.PageNum, param.PageSize)}, nil
+}
+
+// ChangeMargin adds margin to an isolated position from the available balance, or
+// withdraws margin from it as long as it keeps its initial margin.
+func (c *ContractAccount) ChangeMargin(_ context.Context, param types.ChangeMarginParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := c.positionByID(param.PositionId)
+	if p == nil || p.State == closedPosition {
+		return nil, fmt.Errorf("%w %d", ErrNoPosition, param.PositionId)
+	}
+	if p.OpenType != types.IsolatedMargin {
+		return nil, fmt.Errorf("%w: the margin of cross position %d can not be changed", backtest.ErrInvalidOrder, p.PositionID)
+	}
+
+	d := c.details[p.Symbol]
+	if param.Type == types.AddMargin {
+		if param.Amount.GreaterThan(c.available(d.SettleCoin)) {
+			return nil, fmt.Errorf("%w: %s %s", backtest.ErrInsufficientBalance, d.SettleCoin, param.Amount)
+		}
+		p.Im = p.Im.Add(param.Amount)
+	} else {
+		initial := p.HoldAvgPrice.Mul(p.HoldVol).Mul(d.ContractSize).Div(decimal.NewFromInt(int64(p.Leverage)), divPlaces)
+		if p.Im.Sub(param.Amount).LessThan(initial) {
+			return nil, fmt.Errorf("%w: position %d margin %s, initial margin %s", backtest.ErrInsufficientBalance, p.PositionID, p.Im, initial)
+		}
+		p.Im = p.Im.Sub(param.Amount)
+	}
+	c.updateLiquidatePrice(p)
+
+	c.dirty = true
+	ret := success()
+	return &ret, c.save()
+}
+
+// ChangeAutoAddMargin only records the setting, automatic top-ups are not simulated.
+func (c *ContractAccount) ChangeAutoAddMargin(_ context.Context, param types.ChangeAutoAddMarginParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := c.positionByID(param.PositionId)
+	if p == nil || p.State == closedPosition {
+		return nil, fmt.Errorf("%w %d", ErrNoPosition, param.PositionId)
+	}
+	p.AutoAddIm = param.IsEnabled
+
+	c.dirty = true
+	ret := success()
+	return &ret, c.save()
+}
+
+func (c *ContractAccount) GetPositionMode(_ context.Context) (*types.GetPositionModeResp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return &types.GetPositionModeResp{Response: success(), Data: c.state.PositionMode}, nil
+}
+
+// ChangePositionMode switches between hedge and one-way mode. It is refused with
+// account.ErrPositionsOpen while any position is open, like ContractAccountClient.
+func (c *ContractAccount) ChangePositionMode(_ context.Context, param types.ChangePositionModeParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, v := range c.state.Positions {
+		if v.State != closedPosition {
+			return nil, account.ErrPositionsOpen
+		}
+	}
+	c.state.PositionMode = param.PositionMode
+
+	c.dirty = true
+	ret := success()
+	return &ret, c.save()
+}
+
+// GetFundingRecords returns no records, funding is not simulated.
+func (c *ContractAccount) GetFundingRecords(_ context.Context, param types.GetFundingRecordsParams) (*types.GetFundingRecords, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &types.GetFundingRecords{Response: success()}
+	ret.Data.Page = pageOf(0, param.PageNum, param.PageSize)
+	ret.Data.ResultList = []*types.FundingRecord{}
+
+	return ret, nil
+}
+
+// GetTieredFeeRate returns the fee rates of the contract details at level 1, and the
+// value of the deals and the wallet balance in the settle coin of the symbol.
+func (c *ContractAccount) GetTieredFeeRate(_ context.Context, param types.GetTieredFeeRateParams) (*types.GetTieredFeeRateResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, err := c.detail(param.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &types.GetTieredFeeRateResp{Response: success()}
+	ret.Data.Level = 1
+	ret.Data.MakerFee, ret.Data.TakerFee = d.MakerFeeRate, d.TakerFeeRate
+	ret.Data.WalletBalance = c.state.Balances[d.SettleCoin]
+	for _, v := range c.state.Deals {
+		if v.FeeCurrency == d.SettleCoin {
+			ret.Data.DealAmount = ret.Data.DealAmount.Add(v.Price.Mul(v.Vol).Mul(c.details[v.Symbol].ContractSize))
+		}
+	}
+
+	return ret, nil
+}
+
+// GetRiskLimit returns a single level per contract and position type built from the
+// contract details, of every contract when Symbol is empty.
+func (c *ContractAccount) GetRiskLimit(_ context.Context, param types.GetRiskLimitParams) (*types.GetRiskLimitResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	details := c.details
+	if param.Symbol != "" {
+		d, err := c.detail(param.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		details = map[string]*contracttypes.ContractDetail{d.Symbol: d}
+	}
+
+	ret := &types.GetRiskLimitResp{Response: success(), Data: make(map[string][]*types.RiskLimit, len(details))}
+	for symbol, d := range details {
+		maxVol := d.RiskBaseVol
+		if !maxVol.IsPositive() {
+			maxVol = d.MaxVol
+		}
+
+		for _, ptype := range []types.PositionType{types.LongPosition, types.ShortPosition} {
+			limit := &types.RiskLimit{
+				Symbol:       symbol,
+				Level:        1,
+				MaxVol:       maxVol,
+				Mmr:          d.MaintenanceMarginRate,
+				Imr:          d.InitialMarginRate,
+				MaxLeverage:  int(d.MaxLeverage.IntPart()),
+				PositionType: ptype,
+				Leverage:     c.defaultLeverage(d, ptype),
+				CurrentMmr:   d.MaintenanceMarginRate,
+			}
+			if p := c.position(symbol, ptype); p != nil {
+				limit.OpenType, limit.Leverage = p.OpenType, p.Leverage
+			}
+			ret.Data[symbol] = append(ret.Data[symbol], limit)
+		}
+	}
+
+	return ret, nil
+}
+
+// GetProfitRate returns ErrUnsupportedOrder, the paper account keeps no history of
+// its balances.
+func (c *ContractAccount) GetProfitRate(_ context.Context, rateType types.ProfitRateType) (*types.GetProfitRateResp, error) {
+	err := c.validate.Var(rateType, "oneof=1 2")
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("%w: profit rate", ErrUnsupportedOrder)
+}
+
+// GetFeeDetails returns the fees of the deals, the last first.
+func (c *ContractAccount) GetFeeDetails(_ context.Context, param types.GetFeeDetailsParams) (*types.GetFeeDetailsResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deals := c.deals(func(d *types.Deal) bool {
+		return (param.Symbol == "" || d.Symbol == param.Symbol) &&
+			(param.StartTime == 0 || d.Timestamp >= param.StartTime) &&
+			(param.EndTime == 0 || d.Timestamp <= param.EndTime)
+	})
+
+	list := make([]*types.FeeDetail, len(deals))
+	for i, v := range deals {
+		list[i] = &types.FeeDetail{
+			Id:          v.Id,
+			Symbol:      v.Symbol,
+			OrderId:     v.OrderId,
+			Fee:         v.Fee,
+			FeeCurrency: v.FeeCurrency,
+			IsTaker:     v.IsTaker,
+			CreateTime:  v.Timestamp,
+		}
+	}
+
+	ret := &types.GetFeeDetailsResp{Response: success()}
+	ret.Data.Page = pageOf(len(list), param.PageNum, param.PageSize)
+	ret.Data.ResultList = page(list, param.PageNum, param.PageSize)
+
+	return ret, nil
+}
+
+// GetTransferRecords returns no records, the balances of the paper account are set
+// by its configuration.
+func (c *ContractAccount) GetTransferRecords(_ context.Context, param types.GetTransferRecordsParams) (*types.GetTransferRecordsResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &types.GetTransferRecordsResp{Response: success()}
+	ret.Data.Page = pageOf(0, param.PageNum, param.PageSize)
+	ret.Data.ResultList = []*types.TransferRecord{}
+
+	return ret, nil
+}
+
+// WalkHistoryOrders walks all pages of the historical orders matching param, starting
+// at param.PageNum, and calls fn with each non-empty page. Walking stops at the first
+// error returned by fn.
+func (c *ContractAccount) WalkHistoryOrders(ctx context.Context, param types.GetHistoryOrdersParams, fn func([]*types.Order) error) error {
+	if param.PageNum == 0 {
+		param.PageNum = 1
+	}
+	if param.PageSize == 0 {
+		param.PageSize = maxPageSize
+	}
+
+	for {
+		resp, err := c.GetHistoryOrders(ctx, param)
+		if err != nil {
+			return err
+		}
+
+		if len(resp.Data) > 0 {
+			if err := fn(resp.Data); err != nil {
+				return fmt.Errorf("page %d: %w", param.PageNum, err)
+			}
+		}
+
+		if len(resp.Data) < param.PageSize {
+			return nil
+		}
+
+		param.PageNum++
+	}
+}
+
+// WalkOrderDeals walks all pages of the deals matching param, starting at
+// param.PageNum, and calls fn with each non-empty page. Walking stops at the first
+// error returned by fn.
+func (c *ContractAccount) WalkOrderDeals(ctx context.Context, param types.GetOrderDealsParams, fn func([]*types.Deal) error) error {
+	if param.PageNum == 0 {
+		param.PageNum = 1
+	}
+	if param.PageSize == 0 {
+		param.PageSize = maxPageSize
+	}
+
+	for {
+		resp, err := c.GetOrderDeals(ctx, param)
+		if err != nil {
+			return err
+		}
+
+		if len(resp.Data) > 0 {
+			if err := fn(resp.Data); err != nil {
+				return fmt.Errorf("page %d: %w", param.PageNum, err)
+			}
+		}
+
+		if len(resp.Data) < param.PageSize {
+			return nil
+		}
+
+		param.PageNum++
+	}
+}
@@ -0,0 +1,622 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package paper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jl1/nexapi/mexc/backtest"
+	"github.com/jl1/nexapi/mexc/contract/account/types"
+	"github.com/jl1/nexapi/mexc/rules"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// trigger sides of the executed stop orders
+const (
+	takeProfitSide = 1
+	stopLossSide   = 2
+)
+
+// stopOrder is the stop-loss and take-profit order of the volume filled by an
+// opening order.
+type stopOrder struct {
+	types.StopOrder
+
+	LossTrend   types.TriggerType `json:"lossTrend"`
+	ProfitTrend types.TriggerType `json:"profitTrend"`
+}
+
+// stopPrice returns the stop price p, zero when unset.
+func stopPrice(p *decimal.Decimal) decimal.Decimal {
+	if p == nil {
+		return decimal.Decimal{}
+	}
+
+	return *p
+}
+
+// checkStopPrices checks the stop-loss and take-profit prices which are set.
+func checkStopPrices(r *rules.SymbolRules, prices ...*decimal.Decimal) error {
+	for _, v := range prices {
+		if v == nil || v.IsZero() {
+			continue
+		}
+		if err := r.ValidatePrice(*v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reached reports whether price reached the trigger price in direction.
+func reached(direction types.TriggerDirection, price, trigger decimal.Decimal) bool {
+	if !price.IsPositive() {
+		return false
+	}
+	if direction == types.GreaterOrEqual {
+		return price.GreaterThanOrEqual(trigger)
+	}
+
+	return price.LessThanOrEqual(trigger)
+}
+
+// executeCycle returns how long a plan order stays effective, in milliseconds.
+func executeCycle(cycle types.ExecuteCycle) int64 {
+	if cycle == types.Cycle7Days {
+		return (7 * 24 * time.Hour).Milliseconds()
+	}
+
+	return (24 * time.Hour).Milliseconds()
+}
+
+// trigger executes the plan and stop orders of symbol whose trigger price was
+// reached, and invalidates the expired plan orders, c.mu must be held.
+func (c *ContractAccount) trigger(symbol string, ts int64) {
+	c.state.Now = max(c.state.Now, ts)
+	p := c.prices(symbol)
+
+	for _, v := range c.state.PlanOrders {
+		if v.Symbol != symbol || v.State != types.PlanUntriggered {
+			continue
+		}
+
+		if ts >= v.CreateTime+executeCycle(v.ExecuteCycle) {
+			c.endPlan(v, types.PlanInvalidated)
+			continue
+		}
+
+		if reached(v.TriggerType, p.trend(v.Trend), v.TriggerPrice) {
+			c.executePlan(v)
+		}
+	}
+
+	for _, v := range c.state.StopOrders {
+		if v.Symbol != symbol || v.State != types.StopUntriggered || !v.Vol.IsPositive() {
+			continue
+		}
+
+		// a long takes profit above and stops its loss below, a short the reverse
+		profit, loss := types.GreaterOrEqual, types.LessOrEqual
+		if v.PositionType == types.ShortPosition {
+			profit, loss = loss, profit
+		}
+
+		switch {
+		case v.StopLossPrice.IsPositive() && reached(loss, p.trend(v.LossTrend), v.StopLossPrice):
+			c.executeStop(v, stopLossSide)
+		case v.TakeProfitPrice.IsPositive() && reached(profit, p.trend(v.ProfitTrend), v.TakeProfitPrice):
+			c.executeStop(v, takeProfitSide)
+		}
+	}
+}
+
+// executePlan submits the order of the triggered plan order v, the plan order fails
+// when the order is refused.
+func (c *ContractAccount) executePlan(v *types.PlanOrder) {
+	param := types.NewOrderParam{
+		Symbol:   v.Symbol,
+		Vol:      v.Vol,
+		Leverage: v.Leverage,
+		Side:     v.Side,
+		Type:     v.OrderType,
+		OpenType: v.OpenType,
+	}
+	if v.OrderType != types.PlanMarketOrder {
+		price := v.Price
+		param.Price = &price
+	}
+
+	o, err := c.submit(param)
+	if err != nil {
+		c.logger.Warn("paper plan order failed", "symbol", v.Symbol, "id", v.Id, "error", err)
+		v.ErrorCode = 1
+		c.endPlan(v, types.PlanExecutionFailed)
+		return
+	}
+
+	v.OrderId = o.OrderId
+	c.endPlan(v, types.PlanExecuted)
+}
+
+// executeStop closes the volume of the triggered stop order v with a market order.
+func (c *ContractAccount) executeStop(v *stopOrder, side int) {
+	v.TriggerSide = side
+
+	p := c.positionByID(v.PositionId)
+	if p == nil || p.State == closedPosition {
+		c.endStop(v, types.StopInvalidated, c.state.Now)
+		return
+	}
+
+	closeSide := types.CloseLong
+	if p.PositionType == types.ShortPosition {
+		closeSide = types.CloseShort
+	}
+
+	vol := decimal.Min(v.Vol, p.HoldVol.Sub(p.FrozenVol))
+	o, err := c.submit(types.NewOrderParam{
+		Symbol:     v.Symbol,
+		Vol:        vol,
+		Side:       closeSide,
+		Type:       types.MarketOrder,
+		OpenType:   p.OpenType,
+		PositionId: p.PositionID,
+	})
+	if err != nil {
+		c.logger.Warn("paper stop order failed", "symbol", v.Symbol, "id", v.Id, "error", err)
+		v.ErrorCode = 1
+		c.endStop(v, types.StopExecutionFailed, c.state.Now)
+		return
+	}
+
+	v.PlaceOrderId, v.RealityVol = o.OrderId, vol
+	c.endStop(v, types.StopExecuted, c.state.Now)
+}
+
+func (c *ContractAccount) endPlan(v *types.PlanOrder, state types.PlanOrderState) {
+	v.State = state
+	v.UpdateTime = c.state.Now
+	c.dirty = true
+}
+
+// endStop sets the state of the untriggered stop order v.
+func (c *ContractAccount) endStop(v *stopOrder, state types.StopOrderState, ts int64) {
+	if v.State != types.StopUntriggered {
+		return
+	}
+
+	v.State = state
+	v.IsFinished = 1
+	v.UpdateTime = ts
+	c.dirty = true
+}
+
+// endPositionStops invalidates the stop orders of a closed position.
+func (c *ContractAccount) endPositionStops(positionID int64, ts int64) {
+	for _, v := range c.state.StopOrders {
+		if v.PositionId == positionID {
+			c.endStop(v, types.StopInvalidated, ts)
+		}
+	}
+}
+
+// newStop registers the stop order of the opening order o, the trends default to the
+// last price.
+func (c *ContractAccount) newStop(o *contractOrder, lossTrend, profitTrend types.TriggerType) *stopOrder {
+	if lossTrend == 0 {
+		lossTrend = types.LastPrice
+	}
+	if profitTrend == 0 {
+		profitTrend = types.LastPrice
+	}
+
+	c.state.StopSeq++
+	v := &stopOrder{
+		StopOrder: types.StopOrder{
+			Id:              c.state.StopSeq,
+			OrderId:         o.OrderId,
+			Symbol:          o.Symbol,
+			PositionId:      o.PositionId,
+			StopLossPrice:   o.StopLossPrice,
+			TakeProfitPrice: o.TakeProfitPrice,
+			State:           types.StopUntriggered,
+			PositionType:    positionType(o.Side),
+			Vol:             o.DealVol,
+			CreateTime:      c.state.Now,
+			UpdateTime:      c.state.Now,
+		},
+		LossTrend:   lossTrend,
+		ProfitTrend: profitTrend,
+	}
+
+	c.state.StopOrders = append(c.state.StopOrders, v)
+	c.dirty = true
+
+	return v
+}
+
+// addStopVol adds vol filled by the opening order o to its stop order.
+func (c *ContractAccount) addStopVol(o *contractOrder, vol decimal.Decimal, ts int64) {
+	v := c.stopByOrder(o.OrderId)
+	if v == nil || v.State != types.StopUntriggered {
+		return
+	}
+
+	v.PositionId = o.PositionId
+	v.Vol = v.Vol.Add(vol)
+	v.UpdateTime = ts
+}
+
+func (c *ContractAccount) stopByOrder(orderId int64) *stopOrder {
+	for _, v := range c.state.StopOrders {
+		if v.OrderId == orderId {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func (c *ContractAccount) stopByID(id int64) *stopOrder {
+	for _, v := range c.state.StopOrders {
+		if v.Id == id {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func (c *ContractAccount) planByID(id int64) *types.PlanOrder {
+	for _, v := range c.state.PlanOrders {
+		if v.Id == id {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// changeStop sets the prices of the untriggered stop order v and of its order, the
+// stop order is canceled when both are unset or zero.
+func (c *ContractAccount) changeStop(v *stopOrder, stopLoss, takeProfit *decimal.Decimal) error {
+	if v.State != types.StopUntriggered {
+		return fmt.Errorf("%w: stop order %d is finished", backtest.ErrInvalidOrder, v.Id)
+	}
+
+	if err := checkStopPrices(rules.FromContractDetail(c.details[v.Symbol]), stopLoss, takeProfit); err != nil {
+		return err
+	}
+
+	v.StopLossPrice, v.TakeProfitPrice = stopPrice(stopLoss), stopPrice(takeProfit)
+	v.Version++
+	v.UpdateTime = c.state.Now
+	c.dirty = true
+
+	if o, ok := c.orders[v.OrderId]; ok {
+		o.StopLossPrice, o.TakeProfitPrice = v.StopLossPrice, v.TakeProfitPrice
+	}
+
+	if v.StopLossPrice.IsZero() && v.TakeProfitPrice.IsZero() {
+		c.endStop(v, types.StopCancelled, c.state.Now)
+	}
+
+	return nil
+}
+
+// PlacePlanOrder places an order submitted when the price watched by Trend reaches
+// the trigger price. The order is checked when it is submitted, the plan order fails
+// if it is refused. PositionMode and ReduceOnly are ignored.
+func (c *ContractAccount) PlacePlanOrder(_ context.Context, param types.PlacePlanOrderParams) (*types.PlacePlanOrderResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, err := c.detail(param.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	r := rules.FromContractDetail(d)
+	if err := r.ValidateQty(param.Vol); err != nil {
+		return nil, err
+	}
+	if err := r.ValidatePrice(param.TriggerPrice); err != nil {
+		return nil, err
+	}
+	if param.Leverage != 0 {
+		if err := checkLeverage(d, param.Leverage); err != nil {
+			return nil, err
+		}
+	}
+
+	c.state.PlanSeq++
+	plan := &types.PlanOrder{
+		Id:           c.state.PlanSeq,
+		Symbol:       param.Symbol,
+		Leverage:     param.Leverage,
+		Side:         param.Side,
+		TriggerPrice: param.TriggerPrice,
+		Vol:          param.Vol,
+		OpenType:     param.OpenType,
+		TriggerType:  param.TriggerType,
+		State:        types.PlanUntriggered,
+		ExecuteCycle: param.ExecuteCycle,
+		Trend:        param.Trend,
+		OrderType:    param.OrderType,
+		CreateTime:   c.state.Now,
+		UpdateTime:   c.state.Now,
+	}
+
+	if param.OrderType != types.PlanMarketOrder {
+		if param.Price == nil {
+			return nil, fmt.Errorf("%w: price is required", backtest.ErrInvalidOrder)
+		}
+		if err := r.ValidatePrice(*param.Price); err != nil {
+			return nil, err
+		}
+		plan.Price = *param.Price
+	}
+
+	c.state.PlanOrders = append(c.state.PlanOrders, plan)
+	c.dirty = true
+
+	return &types.PlacePlanOrderResp{Response: success(), Data: plan.Id}, c.save()
+}
+
+// CancelPlanOrders cancels up to 50 untriggered plan orders, none is canceled when
+// one of them can not be.
+func (c *ContractAccount) CancelPlanOrders(_ context.Context, params []types.CancelPlanOrderParam) (*types.Response, error) {
+	if len(params) == 0 || len(params) > maxBatchOrders {
+		return nil, fmt.Errorf("%w: %d orders, between 1 and %d are accepted", backtest.ErrInvalidOrder, len(params), maxBatchOrders)
+	}
+
+	for _, param := range params {
+		err := c.validate.Struct(param)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	plans := make([]*types.PlanOrder, len(params))
+	for i, v := range params {
+		plan := c.planByID(v.OrderId)
+		if plan == nil || plan.Symbol != v.Symbol {
+			return nil, fmt.Errorf("%w: plan order %d", backtest.ErrUnknownOrder, v.OrderId)
+		}
+		if plan.State != types.PlanUntriggered {
+			return nil, fmt.Errorf("%w: plan order %d is finished", backtest.ErrInvalidOrder, v.OrderId)
+		}
+		plans[i] = plan
+	}
+
+	for _, v := range plans {
+		c.endPlan(v, types.PlanCancelled)
+	}
+
+	ret := success()
+	return &ret, c.save()
+}
+
+// CancelAllPlanOrders cancels the untriggered plan orders of the symbol, of every
+// symbol when empty.
+func (c *ContractAccount) CancelAllPlanOrders(_ context.Context, param types.CancelAllPlanOrdersParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, v := range c.state.PlanOrders {
+		if v.State == types.PlanUntriggered && (param.Symbol == "" || v.Symbol == param.Symbol) {
+			c.endPlan(v, types.PlanCancelled)
+		}
+	}
+
+	ret := success()
+	return &ret, c.save()
+}
+
+// GetPlanOrders returns the plan orders, the last placed first, States filters them.
+func (c *ContractAccount) GetPlanOrders(_ context.Context, param types.GetPlanOrdersParams) (*types.GetPlanOrdersResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := parseStates(param.States)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := []*types.PlanOrder{}
+	for i := len(c.state.PlanOrders) - 1; i >= 0; i-- {
+		v := c.state.PlanOrders[i]
+		if (param.Symbol == "" || v.Symbol == param.Symbol) &&
+			(len(states) == 0 || states[v.State]) &&
+			(param.StartTime == 0 || v.CreateTime >= param.StartTime) &&
+			(param.EndTime == 0 || v.CreateTime <= param.EndTime) {
+			plan := *v
+			list = append(list, &plan)
+		}
+	}
+
+	return &types.GetPlanOrdersResp{Response: success(), Data: page(list, param.PageNum, param.PageSize)}, nil
+}
+
+// GetStopOrders returns the stop orders, the last placed first. An IsFinished of 1
+// only returns the finished ones.
+func (c *ContractAccount) GetStopOrders(_ context.Context, param types.GetStopOrdersParams) (*types.GetStopOrdersResp, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := []*types.StopOrder{}
+	for i := len(c.state.StopOrders) - 1; i >= 0; i-- {
+		v := c.state.StopOrders[i]
+		if (param.Symbol == "" || v.Symbol == param.Symbol) &&
+			(param.IsFinished == 0 || v.IsFinished == param.IsFinished) &&
+			(param.StartTime == 0 || v.CreateTime >= param.StartTime) &&
+			(param.EndTime == 0 || v.CreateTime <= param.EndTime) {
+			stop := v.StopOrder
+			list = append(list, &stop)
+		}
+	}
+
+	return &types.GetStopOrdersResp{Response: success(), Data: page(list, param.PageNum, param.PageSize)}, nil
+}
+
+// CancelStopOrders cancels up to 50 untriggered stop orders, none is canceled when
+// one of them can not be.
+func (c *ContractAccount) CancelStopOrders(_ context.Context, params []types.CancelStopOrderParam) (*types.Response, error) {
+	if len(params) == 0 || len(params) > maxBatchOrders {
+		return nil, fmt.Errorf("%w: %d orders, between 1 and %d are accepted", backtest.ErrInvalidOrder, len(params), maxBatchOrders)
+	}
+
+	for _, param := range params {
+		err := c.validate.Struct(param)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stops := make([]*stopOrder, len(params))
+	for i, v := range params {
+		stop := c.stopByID(v.StopPlanOrderId)
+		if stop == nil {
+			return nil, fmt.Errorf("%w: stop order %d", backtest.ErrUnknownOrder, v.StopPlanOrderId)
+		}
+		if stop.State != types.StopUntriggered {
+			return nil, fmt.Errorf("%w: stop order %d is finished", backtest.ErrInvalidOrder, v.StopPlanOrderId)
+		}
+		stops[i] = stop
+	}
+
+	for _, v := range stops {
+		c.endStop(v, types.StopCancelled, c.state.Now)
+	}
+
+	ret := success()
+	return &ret, c.save()
+}
+
+// CancelAllStopOrders cancels the untriggered stop orders of the position and of the
+// symbol which are set, all of them when neither is.
+func (c *ContractAccount) CancelAllStopOrders(_ context.Context, param types.CancelAllStopOrdersParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, v := range c.state.StopOrders {
+		if (param.PositionId == 0 || v.PositionId == param.PositionId) && (param.Symbol == "" || v.Symbol == param.Symbol) {
+			c.endStop(v, types.StopCancelled, c.state.Now)
+		}
+	}
+
+	ret := success()
+	return &ret, c.save()
+}
+
+// ChangeStopPrice sets the stop-loss and take-profit prices of an opening order, the
+// prices left unset are removed and its stop order is canceled when both are.
+func (c *ContractAccount) ChangeStopPrice(_ context.Context, param types.ChangeStopPriceParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, ok := c.orders[param.OrderId]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", backtest.ErrUnknownOrder, param.OrderId)
+	}
+	if !o.opens() {
+		return nil, fmt.Errorf("%w: stop-loss and take-profit prices of a closing order", ErrUnsupportedOrder)
+	}
+
+	v := c.stopByOrder(o.OrderId)
+	if v == nil {
+		if !o.isOpen() {
+			return nil, fmt.Errorf("%w: order %d is not open", backtest.ErrInvalidOrder, o.OrderId)
+		}
+		if stopPrice(param.StopLossPrice).IsZero() && stopPrice(param.TakeProfitPrice).IsZero() {
+			ret := success()
+			return &ret, nil
+		}
+		v = c.newStop(o, types.LastPrice, types.LastPrice)
+	}
+
+	if err := c.changeStop(v, param.StopLossPrice, param.TakeProfitPrice); err != nil {
+		return nil, err
+	}
+
+	ret := success()
+	return &ret, c.save()
+}
+
+// ChangeStopPlanPrice sets the stop-loss and take-profit prices of a stop order, the
+// prices left unset are removed and the stop order is canceled when both are.
+func (c *ContractAccount) ChangeStopPlanPrice(_ context.Context, param types.ChangeStopPlanPriceParams) (*types.Response, error) {
+	err := c.validate.Struct(param)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v := c.stopByID(param.StopPlanOrderId)
+	if v == nil {
+		return nil, fmt.Errorf("%w: stop order %d", backtest.ErrUnknownOrder, param.StopPlanOrderId)
+	}
+
+	if err := c.changeStop(v, param.StopLossPrice, param.TakeProfitPrice); err != nil {
+		return nil, err
+	}
+
+	ret := success()
+	return &ret, c.save()
+}
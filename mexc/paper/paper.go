@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package paper trades on live market data without sending orders. SpotAccount and
+// ContractAccount have the methods of the spot and contract account clients, see
+// SpotOrderClient and ContractOrderClient, they keep simulated balances and positions, match the orders against the book
+// ticker or the depth pushed by the market streams, and save their state to a file
+// after every change so that a restart resumes where it stopped.
+package paper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// saveFile writes data to path atomically.
+func saveFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadFile returns the content of path, or nil when it does not exist.
+func loadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return data, err
+}
+
+// page returns the page num of size items of list, num starts at 1 and size
+// defaults to 20 like the API.
+func page[T any](list []T, num, size int) []T {
+	if num <= 0 {
+		num = 1
+	}
+	if size <= 0 {
+		size = 20
+	}
+
+	start := (num - 1) * size
+	if start >= len(list) {
+		return []T{}
+	}
+
+	return list[start:min(start+size, len(list))]
+}
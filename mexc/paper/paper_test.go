@@ -0,0 +1,503 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package paper
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/jl1/nexapi/mexc/backtest"
+	"github.com/jl1/nexapi/mexc/contract/account"
+	"github.com/jl1/nexapi/mexc/contract/account/types"
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	"github.com/jl1/nexapi/mexc/spot/spotaccount"
+	spottypes "github.com/jl1/nexapi/mexc/spot/spotaccount/types"
+	spotwstypes "github.com/jl1/nexapi/mexc/spot/websocketmarket/types"
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ backtest.OrderClient = (*SpotAccount)(nil)
+	_ SpotOrderClient      = (*spotaccount.SpotAccountClient)(nil)
+	_ SpotOrderClient      = (*SpotAccount)(nil)
+	_ ContractOrderClient  = (*account.ContractAccountClient)(nil)
+	_ ContractOrderClient  = (*ContractAccount)(nil)
+)
+
+var d = decimal.MustParse
+
+func ptr(s string) *decimal.Decimal {
+	v := d(s)
+	return &v
+}
+
+func TestSpotAccount(t *testing.T) {
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "spot.json")
+
+	var fee spottypes.TradeFee
+	fee.Data.MakerCommission, fee.Data.TakerCommission = d("0.001"), d("0.002")
+
+	cfg := &SpotAccountCfg{
+		StatePath: path,
+		Balances:  map[string]decimal.Decimal{"USDT": d("1000")},
+		Fee:       &fee,
+		Symbols:   []string{"BTCUSDT"},
+	}
+
+	a, err := NewSpotAccount(cfg)
+	assert.Nil(t, err)
+
+	a.ApplyBookTicker(&spotwstypes.BookTicker{Symbol: "BTCUSDT", EventTime: 1000, BidPrice: d("99"), BidQty: d("5"), AskPrice: d("101"), AskQty: d("5")})
+
+	resp, err := a.CreateOrder(ctx, spottypes.CreateOrderParam{Symbol: "BTCUSDT", Side: spottypes.BuySide, Type: spottypes.LimitOrder, Price: ptr("100"), Quantity: ptr("2")})
+	assert.Nil(t, err)
+
+	// the order and its locked funds survive a restart
+	a, err = NewSpotAccount(cfg)
+	assert.Nil(t, err)
+
+	o, err := a.QueryOrder(ctx, spottypes.QueryOrderParam{Symbol: "BTCUSDT", OrderID: resp.OrderID})
+	assert.Nil(t, err)
+	assert.Equal(t, spottypes.NewStatus, o.Status)
+
+	a.ApplyBookTicker(&spotwstypes.BookTicker{Symbol: "BTCUSDT", EventTime: 1500, BidPrice: d("99"), BidQty: d("5"), AskPrice: d("101"), AskQty: d("5")})
+
+	// the ask moves through the resting order, which fills as a maker
+	a.ApplyBookTicker(&spotwstypes.BookTicker{Symbol: "BTCUSDT", EventTime: 2000, BidPrice: d("98"), BidQty: d("5"), AskPrice: d("99.5"), AskQty: d("5")})
+
+	a, err = NewSpotAccount(cfg)
+	assert.Nil(t, err)
+
+	o, err = a.QueryOrder(ctx, spottypes.QueryOrderParam{Symbol: "BTCUSDT", OrderID: resp.OrderID})
+	assert.Nil(t, err)
+	assert.Equal(t, spottypes.FilledStatus, o.Status)
+
+	info, err := a.GetAccountInfo(ctx)
+	assert.Nil(t, err)
+	for _, v := range info.Balances {
+		switch v.Asset {
+		case "USDT":
+			assert.True(t, v.Free.Equal(d("800")), v.Free.String())
+		case "BTC":
+			assert.True(t, v.Free.Equal(d("1.998")), v.Free.String())
+		}
+	}
+
+	symbols, err := a.GetSelfSymbols(ctx)
+	assert.Nil(t, err)
+	assert.True(t, symbols.IsAPITradable("BTCUSDT"))
+
+	mx, err := a.SetMxDeduct(ctx, spottypes.MxDeductParam{MxDeductEnable: true})
+	assert.Nil(t, err)
+	assert.True(t, mx.Data.MxDeductEnable)
+
+	err = a.Transfer(ctx, spottypes.TransferParam{FromAccountType: "SPOT", ToAccountType: "FUTURES", Asset: "USDT", Amount: d("1")})
+	assert.ErrorIs(t, err, ErrUnsupported)
+	_, err = a.SelectDust(ctx, d("1"))
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func testDetail() *contracttypes.ContractDetail {
+	return &contracttypes.ContractDetail{
+		Symbol:                "BTC_USDT",
+		BaseCoin:              "BTC",
+		QuoteCoin:             "USDT",
+		SettleCoin:            "USDT",
+		ContractSize:          d("0.0001"),
+		MinLeverage:           d("1"),
+		MaxLeverage:           d("125"),
+		PriceUnit:             d("0.1"),
+		VolUnit:               d("1"),
+		MinVol:                d("1"),
+		MaxVol:                d("1000000"),
+		TakerFeeRate:          d("0.0006"),
+		MakerFeeRate:          d("0.0002"),
+		MaintenanceMarginRate: d("0.004"),
+		InitialMarginRate:     d("0.008"),
+	}
+}
+
+func ticker(ts int64, bid, ask, fair string) *contracttypes.Ticker {
+	return &contracttypes.Ticker{Symbol: "BTC_USDT", Timestamp: ts, Bid1: d(bid), Ask1: d(ask), LastPrice: d(bid), FairPrice: d(fair)}
+}
+
+func TestContractAccount(t *testing.T) {
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "contract.json")
+
+	cfg := &ContractAccountCfg{
+		StatePath: path,
+		Details:   []*contracttypes.ContractDetail{testDetail()},
+		Balances:  map[string]decimal.Decimal{"USDT": d("1000")},
+	}
+
+	c, err := NewContractAccount(cfg)
+	assert.Nil(t, err)
+
+	c.ApplyTicker(ticker(1000, "100000", "100010", "100005"))
+
+	// leverage is checked against the range of the contract
+	_, err = c.SetPositionLeverage(ctx, types.SetLeverageParams{Symbol: "BTC_USDT", PositionType: types.LongPosition, Leverage: 200})
+	assert.ErrorIs(t, err, ErrInvalidLeverage)
+
+	lev, err := c.GetPositionLeverage(ctx, types.GetLeverageParams{Symbol: "BTC_USDT"})
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultLeverage, lev.Data.Leverage)
+
+	// closing without a position is refused
+	_, err = c.SubmitOrder(ctx, types.NewOrderParam{Symbol: "BTC_USDT", Vol: d("1"), Side: types.CloseLong, Type: types.MarketOrder, OpenType: types.IsolatedMargin})
+	assert.ErrorIs(t, err, ErrNoPosition)
+
+	// a market order opens a long at the ask as a taker
+	open, err := c.SubmitOrder(ctx, types.NewOrderParam{Symbol: "BTC_USDT", Vol: d("100"), Leverage: 10, Side: types.OpenLong, Type: types.MarketOrder, OpenType: types.IsolatedMargin})
+	assert.Nil(t, err)
+
+	asset, err := c.GetAccountAsset(ctx, "USDT")
+	assert.Nil(t, err)
+	assert.True(t, asset.Data.FrozenBalance.Equal(d("100.01")), asset.Data.FrozenBalance.String())
+
+	c.ApplyTicker(ticker(2000, "100000", "100010", "100005"))
+
+	order, err := c.GetOrder(ctx, open.Data)
+	assert.Nil(t, err)
+	assert.Equal(t, types.OrderCompleted, order.Data.State)
+	assert.True(t, order.Data.DealAvgPrice.Equal(d("100010")))
+	assert.True(t, order.Data.TakerFee.Equal(d("0.60006")), order.Data.TakerFee.String())
+
+	positions, err := c.GetOpenPositions(ctx, types.GetOpenPositionsParams{})
+	assert.Nil(t, err)
+	assert.Len(t, positions.Data, 1)
+	position := positions.Data[0]
+	assert.True(t, position.HoldVol.Equal(d("100")))
+	assert.True(t, position.Im.Equal(d("100.01")), position.Im.String())
+	assert.Equal(t, 10, position.Leverage)
+	assert.True(t, position.LiquidatePrice.IsPositive())
+
+	// a resting limit closes the long as a maker once the bid moves through it
+	closing, err := c.SubmitOrder(ctx, types.NewOrderParam{Symbol: "BTC_USDT", Price: ptr("101000"), Vol: d("100"), Side: types.CloseLong, Type: types.LimitOrder, OpenType: types.IsolatedMargin})
+	assert.Nil(t, err)
+
+	c.ApplyTicker(ticker(3000, "100900", "100910", "100905"))
+	order, err = c.GetOrder(ctx, closing.Data)
+	assert.Nil(t, err)
+	assert.Equal(t, types.OrderUncompleted, order.Data.State)
+
+	c.ApplyTicker(ticker(4000, "101100", "101110", "101105"))
+	order, err = c.GetOrder(ctx, closing.Data)
+	assert.Nil(t, err)
+	assert.Equal(t, types.OrderCompleted, order.Data.State)
+	assert.True(t, order.Data.Profit.Equal(d("9.9")), order.Data.Profit.String())
+	assert.True(t, order.Data.MakerFee.Equal(d("0.202")), order.Data.MakerFee.String())
+
+	deals, err := c.GetOrderDeals(ctx, types.GetOrderDealsParams{Symbol: "BTC_USDT"})
+	assert.Nil(t, err)
+	assert.Len(t, deals.Data, 2)
+	assert.Equal(t, closing.Data, deals.Data[0].OrderId)
+	assert.False(t, deals.Data[0].IsTaker)
+
+	asset, err = c.GetAccountAsset(ctx, "USDT")
+	assert.Nil(t, err)
+	assert.True(t, asset.Data.AvailableBalance.Equal(d("1009.09794")), asset.Data.AvailableBalance.String())
+	assert.True(t, asset.Data.PositionMargin.IsZero())
+
+	history, err := c.GetHistoryPositions(ctx, types.GetHistoryPositionsParams{})
+	assert.Nil(t, err)
+	assert.Len(t, history.Data, 1)
+	assert.True(t, history.Data[0].CloseAvgPrice.Equal(d("101000")))
+
+	// one-way mode refuses an opposite position
+	_, err = c.ChangePositionMode(ctx, types.ChangePositionModeParams{PositionMode: types.OneWayMode})
+	assert.Nil(t, err)
+
+	// a short at the highest leverage is liquidated when the fair price reaches its
+	// liquidation price, its margin is lost
+	short, err := c.SubmitOrder(ctx, types.NewOrderParam{Symbol: "BTC_USDT", Vol: d("100"), Leverage: 100, Side: types.OpenShort, Type: types.MarketOrder, OpenType: types.IsolatedMargin})
+	assert.Nil(t, err)
+	c.ApplyTicker(ticker(5000, "101100", "101110", "101105"))
+
+	_, err = c.SubmitOrder(ctx, types.NewOrderParam{Symbol: "BTC_USDT", Price: ptr("100000"), Vol: d("1"), Leverage: 10, Side: types.OpenLong, Type: types.LimitOrder, OpenType: types.IsolatedMargin})
+	assert.ErrorIs(t, err, ErrOppositePosition)
+
+	_, err = c.ChangePositionMode(ctx, types.ChangePositionModeParams{PositionMode: types.HedgeMode})
+	assert.ErrorIs(t, err, account.ErrPositionsOpen)
+
+	positions, err = c.GetOpenPositions(ctx, types.GetOpenPositionsParams{})
+	assert.Nil(t, err)
+	assert.Len(t, positions.Data, 1)
+	assert.True(t, positions.Data[0].LiquidatePrice.Round(2).Equal(d("101704.18")), positions.Data[0].LiquidatePrice.String())
+
+	c.ApplyTicker(ticker(6000, "101600", "101610", "101600"))
+	positions, err = c.GetOpenPositions(ctx, types.GetOpenPositionsParams{})
+	assert.Nil(t, err)
+	assert.Len(t, positions.Data, 1)
+
+	c.ApplyTicker(ticker(7000, "101800", "101810", "101800"))
+	positions, err = c.GetOpenPositions(ctx, types.GetOpenPositionsParams{})
+	assert.Nil(t, err)
+	assert.Len(t, positions.Data, 0)
+
+	order, err = c.GetOrder(ctx, short.Data)
+	assert.Nil(t, err)
+	assert.True(t, order.Data.TakerFee.Equal(d("0.6066")), order.Data.TakerFee.String())
+
+	// 1009.09794 - 0.6066 fee - 10.11 margin
+	asset, err = c.GetAccountAsset(ctx, "USDT")
+	assert.Nil(t, err)
+	assert.True(t, asset.Data.AvailableBalance.Equal(d("998.38134")), asset.Data.AvailableBalance.String())
+
+	// the state is restored after a restart
+	c, err = NewContractAccount(cfg)
+	assert.Nil(t, err)
+
+	restored, err := c.GetAccountAsset(ctx, "USDT")
+	assert.Nil(t, err)
+	assert.True(t, restored.Data.AvailableBalance.Equal(asset.Data.AvailableBalance))
+
+	history, err = c.GetHistoryPositions(ctx, types.GetHistoryPositionsParams{})
+	assert.Nil(t, err)
+	assert.Len(t, history.Data, 2)
+
+	mode, err := c.GetPositionMode(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, types.OneWayMode, mode.Data)
+
+	next, err := c.SubmitOrder(ctx, types.NewOrderParam{Symbol: "BTC_USDT", Price: ptr("100000"), Vol: d("1"), Side: types.OpenLong, Type: types.PostOnlyMaker, OpenType: types.CrossMargin})
+	assert.Nil(t, err)
+	assert.Equal(t, short.Data+1, next.Data)
+
+	cancel, err := c.CancelOrders(ctx, []int64{next.Data, 99})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, cancel.Data[0].ErrorCode)
+	assert.Equal(t, 1, cancel.Data[1].ErrorCode)
+
+	c.ApplyTicker(ticker(8000, "101800", "101810", "101800"))
+	order, err = c.GetOrder(ctx, next.Data)
+	assert.Nil(t, err)
+	assert.Equal(t, types.OrderCancelled, order.Data.State)
+}
+
+type depthClient func(symbol string) *contracttypes.Depth
+
+func (f depthClient) GetDepth(ctx context.Context, param contracttypes.GetDepthParams) (*contracttypes.GetDepthResp, error) {
+	return &contracttypes.GetDepthResp{Data: f(param.Symbol)}, nil
+}
+
+func depth(t *testing.T, data string) *contracttypes.Depth {
+	var ret contracttypes.Depth
+	assert.Nil(t, json.Unmarshal([]byte(data), &ret))
+	return &ret
+}
+
+func TestContractDepth(t *testing.T) {
+	c, err := NewContractAccount(&ContractAccountCfg{
+		StatePath: filepath.Join(t.TempDir(), "contract.json"),
+		Details:   []*contracttypes.ContractDetail{testDetail()},
+		Balances:  map[string]decimal.Decimal{"USDT": d("1000")},
+	})
+	assert.Nil(t, err)
+
+	// pushes need a snapshot first
+	assert.False(t, c.ApplyDepth("BTC_USDT", depth(t, `{"asks":[[100010,5,1]],"bids":[],"version":4}`)))
+
+	md := depthClient(func(string) *contracttypes.Depth {
+		return depth(t, `{"asks":[[100010,5,1]],"bids":[[100000,5,1]],"version":5,"timestamp":1000}`)
+	})
+	assert.Nil(t, c.seed(context.TODO(), md, "BTC_USDT"))
+
+	assert.True(t, c.ApplyDepth("BTC_USDT", depth(t, `{"asks":[[100020,3,1]],"bids":[],"version":5}`)))
+	assert.True(t, c.ApplyDepth("BTC_USDT", depth(t, `{"asks":[[100020,3,1]],"bids":[[99990,2,1]],"version":6}`)))
+	assert.Len(t, c.books["BTC_USDT"].Asks, 2)
+	assert.Len(t, c.books["BTC_USDT"].Bids, 2)
+
+	// a snapshot replaces the levels of the updates
+	c.ApplyDepthSnapshot("BTC_USDT", depth(t, `{"asks":[[100030,1,1]],"bids":[[100000,1,1]],"version":9,"timestamp":2000}`))
+	if assert.Len(t, c.books["BTC_USDT"].Asks, 1) {
+		assert.True(t, c.books["BTC_USDT"].Asks[0].Price.Equal(d("100030")))
+	}
+	assert.Len(t, c.books["BTC_USDT"].Bids, 1)
+
+	// a missing version drops the book until the next snapshot
+	assert.True(t, c.ApplyDepth("BTC_USDT", depth(t, `{"asks":[],"bids":[[100000,0,0]],"version":8}`)))
+	assert.Len(t, c.books["BTC_USDT"].Bids, 1)
+	assert.False(t, c.ApplyDepth("BTC_USDT", depth(t, `{"asks":[],"bids":[[99000,1,1]],"version":11}`)))
+	assert.Nil(t, c.books["BTC_USDT"])
+}
+
+func TestContractPlanAndStopOrders(t *testing.T) {
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "contract.json")
+
+	cfg := &ContractAccountCfg{
+		StatePath: path,
+		Details:   []*contracttypes.ContractDetail{testDetail()},
+		Balances:  map[string]decimal.Decimal{"USDT": d("1000")},
+	}
+
+	c, err := NewContractAccount(cfg)
+	assert.Nil(t, err)
+
+	c.ApplyTicker(ticker(1000, "100000", "100010", "100005"))
+
+	// a market plan order opens a long when the last price falls to 99000
+	plan, err := c.PlacePlanOrder(ctx, types.PlacePlanOrderParams{
+		Symbol:       "BTC_USDT",
+		Vol:          d("10"),
+		Leverage:     10,
+		Side:         types.OpenLong,
+		OpenType:     types.IsolatedMargin,
+		TriggerPrice: d("99000"),
+		TriggerType:  types.LessOrEqual,
+		ExecuteCycle: types.Cycle24Hours,
+		OrderType:    types.PlanMarketOrder,
+		Trend:        types.LastPrice,
+	})
+	assert.Nil(t, err)
+
+	// limit plan orders need a price
+	param := types.PlacePlanOrderParams{
+		Symbol:       "BTC_USDT",
+		Vol:          d("10"),
+		Side:         types.OpenShort,
+		OpenType:     types.IsolatedMargin,
+		TriggerPrice: d("110000"),
+		TriggerType:  types.GreaterOrEqual,
+		ExecuteCycle: types.Cycle24Hours,
+		OrderType:    types.PlanLimitOrder,
+		Trend:        types.FairPrice,
+	}
+	_, err = c.PlacePlanOrder(ctx, param)
+	assert.ErrorIs(t, err, backtest.ErrInvalidOrder)
+
+	param.Price = ptr("110000")
+	canceled, err := c.PlacePlanOrder(ctx, param)
+	assert.Nil(t, err)
+
+	_, err = c.CancelPlanOrders(ctx, []types.CancelPlanOrderParam{{Symbol: "BTC_USDT", OrderId: canceled.Data}, {Symbol: "BTC_USDT", OrderId: 99}})
+	assert.ErrorIs(t, err, backtest.ErrUnknownOrder)
+	_, err = c.CancelPlanOrders(ctx, []types.CancelPlanOrderParam{{Symbol: "BTC_USDT", OrderId: canceled.Data}})
+	assert.Nil(t, err)
+
+	c.ApplyTicker(ticker(2000, "99500", "99510", "99505"))
+	plans, err := c.GetPlanOrders(ctx, types.GetPlanOrdersParams{States: "1"})
+	assert.Nil(t, err)
+	assert.Len(t, plans.Data, 1)
+
+	// the plan order triggers, its order fills on the next update
+	c.ApplyTicker(ticker(3000, "98900", "98910", "98905"))
+	c.ApplyTicker(ticker(4000, "98900", "98910", "98905"))
+
+	plans, err = c.GetPlanOrders(ctx, types.GetPlanOrdersParams{})
+	assert.Nil(t, err)
+	assert.Len(t, plans.Data, 2)
+	assert.Equal(t, types.PlanCancelled, plans.Data[0].State)
+	assert.Equal(t, plan.Data, plans.Data[1].Id)
+	assert.Equal(t, types.PlanExecuted, plans.Data[1].State)
+
+	order, err := c.GetOrder(ctx, plans.Data[1].OrderId)
+	assert.Nil(t, err)
+	assert.Equal(t, types.OrderCompleted, order.Data.State)
+	assert.True(t, order.Data.DealAvgPrice.Equal(d("98910")))
+
+	// the stop-loss and take-profit prices of an opening order protect the volume it
+	// fills, closing orders refuse them
+	_, err = c.SubmitOrder(ctx, types.NewOrderParam{Symbol: "BTC_USDT", Vol: d("5"), Side: types.CloseLong, Type: types.MarketOrder, OpenType: types.IsolatedMargin, StopLossPrice: ptr("98000")})
+	assert.ErrorIs(t, err, ErrUnsupportedOrder)
+
+	open, err := c.SubmitOrder(ctx, types.NewOrderParam{Symbol: "BTC_USDT", Vol: d("5"), Leverage: 10, Side: types.OpenLong, Type: types.MarketOrder, OpenType: types.IsolatedMargin, StopLossPrice: ptr("98000"), TakeProfitPrice: ptr("100000")})
+	assert.Nil(t, err)
+	c.ApplyTicker(ticker(5000, "98900", "98910", "98905"))
+
+	stops, err := c.GetStopOrders(ctx, types.GetStopOrdersParams{})
+	assert.Nil(t, err)
+	assert.Len(t, stops.Data, 1)
+	stop := stops.Data[0]
+	assert.Equal(t, open.Data, stop.OrderId)
+	assert.True(t, stop.Vol.Equal(d("5")))
+	assert.NotZero(t, stop.PositionId)
+
+	_, err = c.ChangeStopPlanPrice(ctx, types.ChangeStopPlanPriceParams{StopPlanOrderId: stop.Id, StopLossPrice: ptr("98500")})
+	assert.Nil(t, err)
+
+	order, err = c.GetOrder(ctx, open.Data)
+	assert.Nil(t, err)
+	assert.True(t, order.Data.StopLossPrice.Equal(d("98500")))
+	assert.True(t, order.Data.TakeProfitPrice.IsZero())
+
+	// the stop-loss closes the volume of the stop order with a market order
+	c.ApplyTicker(ticker(6000, "98400", "98410", "98405"))
+	c.ApplyTicker(ticker(7000, "98400", "98410", "98405"))
+
+	stops, err = c.GetStopOrders(ctx, types.GetStopOrdersParams{IsFinished: 1})
+	assert.Nil(t, err)
+	assert.Len(t, stops.Data, 1)
+	stop = stops.Data[0]
+	assert.Equal(t, types.StopExecuted, stop.State)
+	assert.Equal(t, stopLossSide, stop.TriggerSide)
+	assert.True(t, stop.RealityVol.Equal(d("5")))
+
+	order, err = c.GetOrder(ctx, stop.PlaceOrderId)
+	assert.Nil(t, err)
+	assert.Equal(t, types.CloseLong, order.Data.Side)
+	assert.True(t, order.Data.DealAvgPrice.Equal(d("98400")))
+
+	positions, err := c.GetOpenPositions(ctx, types.GetOpenPositionsParams{})
+	assert.Nil(t, err)
+	assert.Len(t, positions.Data, 1)
+	assert.True(t, positions.Data[0].HoldVol.Equal(d("10")))
+
+	// the records are built from the deals
+	fees, err := c.GetFeeDetails(ctx, types.GetFeeDetailsParams{Symbol: "BTC_USDT"})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, fees.Data.TotalCount)
+	assert.Equal(t, stop.PlaceOrderId, fees.Data.ResultList[0].OrderId)
+
+	var pages []int
+	err = c.WalkOrderDeals(ctx, types.GetOrderDealsParams{Symbol: "BTC_USDT", PageSize: 2}, func(deals []*types.Deal) error {
+		pages = append(pages, len(deals))
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 1}, pages)
+
+	funding, err := c.GetFundingRecords(ctx, types.GetFundingRecordsParams{})
+	assert.Nil(t, err)
+	assert.Empty(t, funding.Data.ResultList)
+
+	_, err = c.GetProfitRate(ctx, types.TotalProfitRate)
+	assert.ErrorIs(t, err, ErrUnsupportedOrder)
+
+	// untriggered plan orders expire with their cycle, after a restart
+	_, err = c.PlacePlanOrder(ctx, param)
+	assert.Nil(t, err)
+
+	c, err = NewContractAccount(cfg)
+	assert.Nil(t, err)
+
+	c.ApplyTicker(ticker(7000+24*3600*1000, "98400", "98410", "98405"))
+	plans, err = c.GetPlanOrders(ctx, types.GetPlanOrdersParams{States: "4"})
+	assert.Nil(t, err)
+	assert.Len(t, plans.Data, 1)
+
+	stops, err = c.GetStopOrders(ctx, types.GetStopOrdersParams{})
+	assert.Nil(t, err)
+	assert.Len(t, stops.Data, 1)
+}
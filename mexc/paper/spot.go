@@ -0,0 +1,231 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package paper
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/jl1/nexapi/mexc/backtest"
+	"github.com/jl1/nexapi/mexc/orderbook"
+	"github.com/jl1/nexapi/mexc/rules"
+	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
+	"github.com/jl1/nexapi/mexc/spot/websocketmarket"
+	spotwstypes "github.com/jl1/nexapi/mexc/spot/websocketmarket/types"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// ErrUnsupported is returned by the methods of SpotOrderClient the paper account
+// can not simulate.
+var ErrUnsupported = errors.New("unsupported by the paper account")
+
+type SpotAccountCfg struct {
+	// Logger
+	Logger *slog.Logger
+
+	// StatePath is the file the state is saved to, it is restored from it at start
+	// when the file exists
+	StatePath string `validate:"required"`
+	// Balances are the initial free balances by asset, ignored when the state is
+	// restored
+	Balances map[string]decimal.Decimal
+	// Fee is the fee of the symbols missing from Fees, see backtest.FetchTradeFees
+	// to use the fees of the live account
+	Fee  *types.TradeFee
+	Fees map[string]*types.TradeFee
+	// Latency defaults to no latency
+	Latency backtest.LatencyModel
+	// Slippage applies to the orders matched against the book ticker, see
+	// backtest.ExchangeCfg
+	Slippage backtest.SlippageModel
+	// Rules rejects the orders violating the trading rules like SpotAccountClient
+	Rules rules.Provider
+	// Symbols are returned by GetSelfSymbols, the symbols tradable through the API
+	Symbols []string
+}
+
+// SpotAccount is a paper spot account with the methods of SpotAccountClient, see
+// SpotOrderClient. Orders are matched by the simulated exchange of the backtest
+// package.
+type SpotAccount struct {
+	*backtest.Exchange
+
+	logger    *slog.Logger
+	statePath string
+
+	mu sync.Mutex
+	// saved is the revision of the exchange last saved
+	saved    int64
+	mxDeduct bool
+	books    map[string]*orderbook.Book
+	symbols  []string
+}
+
+func NewSpotAccount(cfg *SpotAccountCfg) (*SpotAccount, error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := loadFile(cfg.StatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := backtest.NewExchange(&backtest.ExchangeCfg{
+		Logger:   cfg.Logger,
+		Balances: cfg.Balances,
+		State:    state,
+		Fee:      cfg.Fee,
+		Fees:     cfg.Fees,
+		Latency:  cfg.Latency,
+		Slippage: cfg.Slippage,
+		Rules:    cfg.Rules,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a := &SpotAccount{
+		Exchange:  x,
+		logger:    cfg.Logger,
+		statePath: cfg.StatePath,
+		books:     make(map[string]*orderbook.Book),
+		symbols:   cfg.Symbols,
+		// a restored state is saved already
+		saved: x.Revision(),
+	}
+
+	if a.logger == nil {
+		a.logger = slog.Default()
+	}
+
+	if state == nil {
+		a.saved = -1
+		if err := a.Save(); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// Save writes the state to the state file if it changed since the last save. It is
+// called after every order request and every market update which changed the state.
+func (a *SpotAccount) Save() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	revision := a.Revision()
+	if revision == a.saved {
+		return nil
+	}
+
+	data, err := a.MarshalState()
+	if err != nil {
+		return err
+	}
+
+	if err := saveFile(a.statePath, data); err != nil {
+		return err
+	}
+	a.saved = revision
+
+	return nil
+}
+
+func (a *SpotAccount) CreateOrder(ctx context.Context, param types.CreateOrderParam) (*types.CreateOrderResp, error) {
+	ret, err := a.Exchange.CreateOrder(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, a.Save()
+}
+
+func (a *SpotAccount) CancelOrder(ctx context.Context, param types.CancelOrderParam) (*types.Order, error) {
+	ret, err := a.Exchange.CancelOrder(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, a.Save()
+}
+
+// ApplyBookTicker matches the orders against a push of the book ticker topic.
+func (a *SpotAccount) ApplyBookTicker(bt *spotwstypes.BookTicker) {
+	a.Exchange.ApplyBookTicker(bt)
+	a.saveUpdate()
+}
+
+// ApplyDepth updates the book of the symbol of d with a push of a depth topic and
+// matches the orders against it.
+func (a *SpotAccount) ApplyDepth(d *spotwstypes.Depth) {
+	a.mu.Lock()
+	book, ok := a.books[d.Symbol]
+	if !ok {
+		book = &orderbook.Book{}
+		a.books[d.Symbol] = book
+	}
+	book.ApplySpotDepth(d)
+	a.Exchange.ApplyBook(d.Symbol, d.EventTime, book)
+	a.mu.Unlock()
+
+	a.saveUpdate()
+}
+
+func (a *SpotAccount) saveUpdate() {
+	if err := a.Save(); err != nil {
+		a.logger.Warn("paper spot state not saved", "path", a.statePath, "error", err)
+	}
+}
+
+// Watch subscribes cli to the book ticker of symbols, or to their 20 best levels
+// when depth is set, and matches the orders against the pushes.
+func (a *SpotAccount) Watch(cli *websocketmarket.SpotMarketStreamClient, symbols []string, depth bool) error {
+	var topics []string
+	for _, v := range symbols {
+		var topic string
+		var err error
+		if depth {
+			topic, err = cli.GetLimitDepthTopic(v, 20)
+		} else {
+			topic, err = cli.GetBookTickerTopic(v)
+		}
+		if err != nil {
+			return err
+		}
+
+		cli.AddListener(topic, func(e any) {
+			switch e := e.(type) {
+			case *spotwstypes.BookTicker:
+				a.ApplyBookTicker(e)
+			case *spotwstypes.Depth:
+				a.ApplyDepth(e)
+			}
+		})
+		topics = append(topics, topic)
+	}
+
+	return cli.Subscribe(topics)
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package paper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// SpotOrderClient has the methods of spotaccount.SpotAccountClient, it is
+// implemented by it and by SpotAccount. The paper account can not transfer to the
+// futures account, convert dust to MX or report a KYC level, these methods return
+// ErrUnsupported.
+type SpotOrderClient interface {
+	GetAccountInfo(ctx context.Context) (*types.AccountInfo, error)
+	Transfer(ctx context.Context, param types.TransferParam) error
+	QueryOrder(ctx context.Context, param types.QueryOrderParam) (*types.Order, error)
+	CancelOrder(ctx context.Context, param types.CancelOrderParam) (*types.Order, error)
+	CreateOrder(ctx context.Context, param types.CreateOrderParam) (*types.CreateOrderResp, error)
+	GetTradeFee(ctx context.Context, param types.GetTradeFeeParam) (*types.TradeFee, error)
+	GetSelfSymbols(ctx context.Context) (*types.SelfSymbols, error)
+	GetKYCStatus(ctx context.Context) (*types.KYC, error)
+	GetMxDeduct(ctx context.Context) (*types.MxDeduct, error)
+	SetMxDeduct(ctx context.Context, param types.MxDeductParam) (*types.MxDeduct, error)
+	GetConvertibleAssets(ctx context.Context) ([]*types.ConvertibleAsset, error)
+	Convert(ctx context.Context, param types.ConvertParam) (*types.ConvertResp, error)
+	GetConvertHistory(ctx context.Context, param types.GetConvertHistoryParam) (*types.ConvertHistory, error)
+	SelectDust(ctx context.Context, threshold decimal.Decimal) ([]string, error)
+	ConvertDust(ctx context.Context, assets []string) (*types.ConvertResp, error)
+}
+
+// Transfer returns ErrUnsupported, the paper account has no futures account.
+func (a *SpotAccount) Transfer(_ context.Context, param types.TransferParam) error {
+	return fmt.Errorf("%w: transfer of %s", ErrUnsupported, param.Asset)
+}
+
+// GetSelfSymbols returns SpotAccountCfg.Symbols.
+func (a *SpotAccount) GetSelfSymbols(_ context.Context) (*types.SelfSymbols, error) {
+	return &types.SelfSymbols{Data: append([]string{}, a.symbols...), Msg: "success"}, nil
+}
+
+// GetKYCStatus returns ErrUnsupported.
+func (a *SpotAccount) GetKYCStatus(_ context.Context) (*types.KYC, error) {
+	return nil, fmt.Errorf("%w: KYC status", ErrUnsupported)
+}
+
+// GetMxDeduct returns the setting of SetMxDeduct, it does not change the simulated
+// fees and is not saved.
+func (a *SpotAccount) GetMxDeduct(_ context.Context) (*types.MxDeduct, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ret := &types.MxDeduct{Msg: "success", Timestamp: a.Now().UnixMilli()}
+	ret.Data.MxDeductEnable = a.mxDeduct
+
+	return ret, nil
+}
+
+func (a *SpotAccount) SetMxDeduct(ctx context.Context, param types.MxDeductParam) (*types.MxDeduct, error) {
+	a.mu.Lock()
+	a.mxDeduct = param.MxDeductEnable
+	a.mu.Unlock()
+
+	return a.GetMxDeduct(ctx)
+}
+
+// GetConvertibleAssets returns ErrUnsupported, and so do the other dust methods.
+func (a *SpotAccount) GetConvertibleAssets(_ context.Context) ([]*types.ConvertibleAsset, error) {
+	return nil, fmt.Errorf("%w: dust conversion", ErrUnsupported)
+}
+
+func (a *SpotAccount) Convert(_ context.Context, _ types.ConvertParam) (*types.ConvertResp, error) {
+	return nil, fmt.Errorf("%w: dust conversion", ErrUnsupported)
+}
+
+func (a *SpotAccount) GetConvertHistory(_ context.Context, _ types.GetConvertHistoryParam) (*types.ConvertHistory, error) {
+	return nil, fmt.Errorf("%w: dust conversion", ErrUnsupported)
+}
+
+func (a *SpotAccount) SelectDust(_ context.Context, _ decimal.Decimal) ([]string, error) {
+	return nil, fmt.Errorf("%w: dust conversion", ErrUnsupported)
+}
+
+func (a *SpotAccount) ConvertDust(_ context.Context, _ []string) (*types.ConvertResp, error) {
+	return nil, fmt.Errorf("%w: dust conversion", ErrUnsupported)
+}
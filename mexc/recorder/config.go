@@ -0,0 +1,348 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recorder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jl1/nexapi/mexc/archive"
+	contractmd "github.com/jl1/nexapi/mexc/contract/marketdata"
+	contractutils "github.com/jl1/nexapi/mexc/contract/utils"
+	contractws "github.com/jl1/nexapi/mexc/contract/websocketmarket"
+	spotmd "github.com/jl1/nexapi/mexc/spot/marketdata"
+	spotutils "github.com/jl1/nexapi/mexc/spot/utils"
+	spotws "github.com/jl1/nexapi/mexc/spot/websocketmarket"
+	spotwstypes "github.com/jl1/nexapi/mexc/spot/websocketmarket/types"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrUnknownStream = errors.New("unknown stream")
+
+// DefaultHTTPTimeout bounds the REST snapshot requests.
+const DefaultHTTPTimeout = 10 * time.Second
+
+// MaxSpotSubscriptions is the number of topics MEXC accepts on one spot
+// connection, the spot topics are split across as many feeds as needed.
+const MaxSpotSubscriptions = 30
+
+// Config is the YAML configuration of the recorder daemon:
+//
+//	debug: false           # log the raw stream messages
+//	output:
+//	  dir: data
+//	  format: jsonl        # csv, jsonl or parquet
+//	  compression: zstd    # none, gzip or zstd
+//	  max_bytes: 268435456
+//	  max_age: 1h
+//	spot:
+//	  symbols: [BTCUSDT, ETHUSDT]
+//	  streams: [deals, book_ticker, depth, depth.20, kline.Min1]
+//	  depth: {interval: 10s, limit: 100}
+//	  tickers: {interval: 1m}
+//	contract:
+//	  symbols: [BTC_USDT]
+//	  streams: [deal, ticker, depth, kline.Min1, funding_rate]
+//	  depth: {interval: 10s, limit: 100}
+//	  tickers: {interval: 1m}
+//	  funding: {interval: 1m}
+//
+// Spot streams are deals, book_ticker, depth for the depth updates, depth.<5|10|20>
+// for snapshots of the best levels and kline.<interval>. Contract streams are deal,
+// ticker, depth, kline.<interval> and funding_rate. Snapshots are taken with the REST
+// API for the markets that configure them.
+type Config struct {
+	Debug    bool          `yaml:"debug"`
+	Output   OutputConfig  `yaml:"output"`
+	Spot     *MarketConfig `yaml:"spot"`
+	Contract *MarketConfig `yaml:"contract"`
+}
+
+type OutputConfig struct {
+	Dir string `yaml:"dir" validate:"required"`
+	// Format defaults to jsonl
+	Format string `yaml:"format" validate:"omitempty,oneof=csv jsonl parquet"`
+	// Compression defaults to zstd
+	Compression string        `yaml:"compression" validate:"omitempty,oneof=none gzip zstd"`
+	MaxBytes    int64         `yaml:"max_bytes" validate:"gte=0"`
+	MaxAge      time.Duration `yaml:"max_age" validate:"gte=0"`
+}
+
+type MarketConfig struct {
+	// StreamURL and RestURL default to the public endpoints of the market
+	StreamURL string          `yaml:"stream_url"`
+	RestURL   string          `yaml:"rest_url"`
+	Symbols   []string        `yaml:"symbols" validate:"required,min=1"`
+	Streams   []string        `yaml:"streams"`
+	Depth     *SnapshotConfig `yaml:"depth"`
+	Tickers   *SnapshotConfig `yaml:"tickers"`
+	// Funding is only supported by contracts
+	Funding *SnapshotConfig `yaml:"funding"`
+}
+
+type SnapshotConfig struct {
+	Interval time.Duration `yaml:"interval" validate:"gt=0"`
+	// Limit is the number of depth levels, zero for the default of the API
+	Limit int `yaml:"limit" validate:"gte=0"`
+}
+
+// LoadConfig reads a YAML configuration, unknown keys are rejected to catch typos.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseConfig(data)
+}
+
+func ParseConfig(data []byte) (*Config, error) {
+	var c Config
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil {
+		return nil, err
+	}
+
+	if c.Output.Format == "" {
+		c.Output.Format = string(archive.JSONL)
+	}
+
+	if c.Output.Compression == "" {
+		c.Output.Compression = string(archive.Zstd)
+	}
+
+	validator := mexcutils.NewValidator()
+	if err := validator.Struct(&c); err != nil {
+		return nil, err
+	}
+
+	if c.Spot == nil && c.Contract == nil {
+		return nil, errors.New("no market to record")
+	}
+
+	if c.Spot != nil && c.Spot.Funding != nil {
+		return nil, errors.New("funding snapshots are only supported by contracts")
+	}
+
+	return &c, nil
+}
+
+// RecorderCfg builds the clients of the configured markets, streams are opened by
+// Recorder.Run.
+func (c *Config) RecorderCfg(logger *slog.Logger) (*RecorderCfg, error) {
+	cfg := &RecorderCfg{
+		Logger: logger,
+		Output: archive.WriterCfg{
+			Dir:         c.Output.Dir,
+			Format:      archive.Format(c.Output.Format),
+			Compression: archive.Compression(c.Output.Compression),
+			MaxBytes:    c.Output.MaxBytes,
+			MaxAge:      c.Output.MaxAge,
+		},
+	}
+
+	if cfg.Output.Compression == "none" {
+		cfg.Output.Compression = archive.NoCompression
+	}
+
+	httpClient := &http.Client{Timeout: DefaultHTTPTimeout}
+
+	if m := c.Spot; m != nil {
+		if len(m.Streams) > 0 {
+			feeds, err := spotFeeds(m, c.Debug, logger)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Feeds = append(cfg.Feeds, feeds...)
+		}
+
+		if m.Depth != nil || m.Tickers != nil {
+			cli, err := spotmd.NewSpotMarketDataClient(&spotutils.SpotClientCfg{
+				Logger:     logger,
+				BaseURL:    or(m.RestURL, spotutils.BaseURL),
+				HTTPClient: httpClient,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			if m.Depth != nil {
+				cfg.Pollers = append(cfg.Pollers, SpotDepthPoller(cli, m.Symbols, m.Depth.Limit, m.Depth.Interval))
+			}
+			if m.Tickers != nil {
+				cfg.Pollers = append(cfg.Pollers, SpotTickersPoller(cli, m.Symbols, m.Tickers.Interval))
+			}
+		}
+	}
+
+	if m := c.Contract; m != nil {
+		if len(m.Streams) > 0 {
+			feed, err := contractFeed(m, c.Debug, logger)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Feeds = append(cfg.Feeds, feed)
+		}
+
+		if m.Depth != nil || m.Tickers != nil || m.Funding != nil {
+			cli, err := contractmd.NewContractMarketDataClient(&contractutils.ContractClientCfg{
+				Logger:     logger,
+				BaseURL:    or(m.RestURL, contractutils.BaseURL),
+				HTTPClient: httpClient,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			if m.Depth != nil {
+				cfg.Pollers = append(cfg.Pollers, ContractDepthPoller(cli, m.Symbols, m.Depth.Limit, m.Depth.Interval))
+			}
+			if m.Tickers != nil {
+				cfg.Pollers = append(cfg.Pollers, ContractTickersPoller(cli, m.Symbols, m.Tickers.Interval))
+			}
+			if m.Funding != nil {
+				cfg.Pollers = append(cfg.Pollers, ContractFundingPoller(cli, m.Symbols, m.Funding.Interval))
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// spotFeeds returns one feed per MaxSpotSubscriptions topics, each with its own
+// connection.
+func spotFeeds(m *MarketConfig, debug bool, logger *slog.Logger) ([]*Feed, error) {
+	newClient := func() (*spotws.SpotMarketStreamClient, error) {
+		return spotws.NewSpotMarketStreamClient(&spotws.SpotMarketStreamCfg{
+			Debug:         debug,
+			Logger:        logger,
+			BaseURL:       or(m.StreamURL, spotws.SpotMarketStreamBaseURL),
+			AutoReconnect: true,
+		})
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var topics []string
+	for _, symbol := range m.Symbols {
+		for _, name := range m.Streams {
+			var topic string
+
+			kind, param, _ := strings.Cut(name, ".")
+			switch {
+			case name == "deals":
+				topic, err = cli.GetDealsTopic(symbol)
+			case name == "book_ticker":
+				topic, err = cli.GetBookTickerTopic(symbol)
+			case name == "depth":
+				topic, err = cli.GetIncreaseDepthTopic(symbol)
+			case kind == "depth":
+				var level int
+				if level, err = strconv.Atoi(param); err == nil {
+					topic, err = cli.GetLimitDepthTopic(symbol, level)
+				}
+			case kind == "kline" && param != "":
+				topic, err = cli.GetKlineTopic(symbol, spotwstypes.KlineInterval(param))
+			default:
+				err = fmt.Errorf("%w: spot %s", ErrUnknownStream, name)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			topics = append(topics, topic)
+		}
+	}
+
+	var feeds []*Feed
+	for len(topics) > 0 {
+		n := min(len(topics), MaxSpotSubscriptions)
+		if len(feeds) > 0 {
+			if cli, err = newClient(); err != nil {
+				return nil, err
+			}
+		}
+
+		feeds = append(feeds, &Feed{Market: Spot, Client: cli, Topics: topics[:n:n]})
+		topics = topics[n:]
+	}
+
+	return feeds, nil
+}
+
+func contractFeed(m *MarketConfig, debug bool, logger *slog.Logger) (*Feed, error) {
+	cli, err := contractws.NewContractMarketStreamClient(&contractws.ContractMarketStreamCfg{
+		Debug:         debug,
+		Logger:        logger,
+		BaseURL:       or(m.StreamURL, contractws.ContractMarketStreamBaseURL),
+		AutoReconnect: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{Market: Contract, Client: cli}
+	for _, symbol := range m.Symbols {
+		for _, name := range m.Streams {
+			var topic string
+
+			kind, param, _ := strings.Cut(name, ".")
+			switch {
+			case name == "deal":
+				topic, err = cli.GetDealTopic(symbol)
+			case name == "ticker":
+				topic, err = cli.GetTickerTopic(symbol)
+			case name == "depth":
+				topic, err = cli.GetDepthTopic(symbol)
+			case name == "funding_rate":
+				topic, err = cli.GetFundingRateTopic(symbol)
+			case kind == "kline" && param != "":
+				topic, err = cli.GetKlineTopic(symbol, contractutils.KlineInterval(param))
+			default:
+				err = fmt.Errorf("%w: contract %s", ErrUnknownStream, name)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			feed.Topics = append(feed.Topics, topic)
+		}
+	}
+
+	return feed, nil
+}
+
+func or(v, fallback string) string {
+	if v == "" {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	"github.com/jl1/nexapi/mexc/stream"
+)
+
+var ErrEmptyResponse = errors.New("empty response")
+
+// SpotSnapshotClient is implemented by marketdata.SpotMarketDataClient.
+type SpotSnapshotClient interface {
+	GetOrderbook(ctx context.Context, param spottypes.GetOrderbookParams) (*spottypes.Orderbook, error)
+	GetTickerForAllSymbols(ctx context.Context) ([]*spottypes.Ticker, error)
+}
+
+// ContractSnapshotClient is implemented by marketdata.ContractMarketDataClient.
+type ContractSnapshotClient interface {
+	GetDepth(ctx context.Context, param contracttypes.GetDepthParams) (*contracttypes.GetDepthResp, error)
+	GetTickerForAllSymbols(ctx context.Context) (*contracttypes.GetAllTickersResp, error)
+	GetFundingRate(ctx context.Context, param contracttypes.GetFundingRateParams) (*contracttypes.GetFundingRateResp, error)
+}
+
+// SpotDepthPoller snapshots the order book of every symbol, limit is the number of
+// levels, zero for the default of the API.
+func SpotDepthPoller(cli SpotSnapshotClient, symbols []string, limit int, interval time.Duration) *Poller {
+	return &Poller{
+		Market:   Spot,
+		Name:     "depth",
+		Interval: interval,
+		Poll: func(ctx context.Context) ([]*stream.Message, error) {
+			return eachSymbol(ctx, symbols, func(symbol string) (*stream.Message, error) {
+				ob, err := cli.GetOrderbook(ctx, spottypes.GetOrderbookParams{Symbol: symbol, Limit: limit})
+				if err != nil {
+					return nil, err
+				}
+				return snapshot("depth", symbol, 0, ob)
+			})
+		},
+	}
+}
+
+// SpotTickersPoller snapshots the 24 hour tickers of symbols with a single request.
+func SpotTickersPoller(cli SpotSnapshotClient, symbols []string, interval time.Duration) *Poller {
+	return &Poller{
+		Market:   Spot,
+		Name:     "ticker",
+		Interval: interval,
+		Poll: func(ctx context.Context) ([]*stream.Message, error) {
+			tickers, err := cli.GetTickerForAllSymbols(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			wanted := set(symbols)
+			var ret []*stream.Message
+			for _, v := range tickers {
+				if !wanted[v.Symbol] {
+					continue
+				}
+
+				msg, err := snapshot("ticker", v.Symbol, v.CloseTime, v)
+				if err != nil {
+					return ret, err
+				}
+				ret = append(ret, msg)
+			}
+
+			return ret, nil
+		},
+	}
+}
+
+func ContractDepthPoller(cli ContractSnapshotClient, symbols []string, limit int, interval time.Duration) *Poller {
+	return &Poller{
+		Market:   Contract,
+		Name:     "depth",
+		Interval: interval,
+		Poll: func(ctx context.Context) ([]*stream.Message, error) {
+			return eachSymbol(ctx, symbols, func(symbol string) (*stream.Message, error) {
+				resp, err := cli.GetDepth(ctx, contracttypes.GetDepthParams{Symbol: symbol, Limit: limit})
+				if err != nil {
+					return nil, err
+				}
+				if resp.Data == nil {
+					return nil, fmt.Errorf("%w: depth of %s", ErrEmptyResponse, symbol)
+				}
+				return snapshot("depth", symbol, resp.Data.Timestamp, resp.Data)
+			})
+		},
+	}
+}
+
+func ContractTickersPoller(cli ContractSnapshotClient, symbols []string, interval time.Duration) *Poller {
+	return &Poller{
+		Market:   Contract,
+		Name:     "ticker",
+		Interval: interval,
+		Poll: func(ctx context.Context) ([]*stream.Message, error) {
+			resp, err := cli.GetTickerForAllSymbols(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			wanted := set(symbols)
+			var ret []*stream.Message
+			for _, v := range resp.Data {
+				if !wanted[v.Symbol] {
+					continue
+				}
+
+				msg, err := snapshot("ticker", v.Symbol, v.Timestamp, v)
+				if err != nil {
+					return ret, err
+				}
+				ret = append(ret, msg)
+			}
+
+			return ret, nil
+		},
+	}
+}
+
+func ContractFundingPoller(cli ContractSnapshotClient, symbols []string, interval time.Duration) *Poller {
+	return &Poller{
+		Market:   Contract,
+		Name:     "funding",
+		Interval: interval,
+		Poll: func(ctx context.Context) ([]*stream.Message, error) {
+			return eachSymbol(ctx, symbols, func(symbol string) (*stream.Message, error) {
+				resp, err := cli.GetFundingRate(ctx, contracttypes.GetFundingRateParams{Symbol: symbol})
+				if err != nil {
+					return nil, err
+				}
+				if resp.Data == nil {
+					return nil, fmt.Errorf("%w: funding rate of %s", ErrEmptyResponse, symbol)
+				}
+				return snapshot("funding", symbol, resp.Data.Timestamp, resp.Data)
+			})
+		},
+	}
+}
+
+// eachSymbol takes a snapshot per symbol, a failed symbol does not prevent the others
+// from being recorded and the last error is returned.
+func eachSymbol(ctx context.Context, symbols []string, fn func(symbol string) (*stream.Message, error)) ([]*stream.Message, error) {
+	var (
+		ret     []*stream.Message
+		lastErr error
+	)
+
+	for _, v := range symbols {
+		if ctx.Err() != nil {
+			return ret, ctx.Err()
+		}
+
+		msg, err := fn(v)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ret = append(ret, msg)
+	}
+
+	return ret, lastErr
+}
+
+func snapshot(name, symbol string, exchangeTime int64, v any) (*stream.Message, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stream.Message{
+		Topic:    name + "@" + symbol,
+		Symbol:   symbol,
+		Time:     exchangeTime,
+		Received: time.Now().UnixMilli(),
+		Data:     data,
+	}, nil
+}
+
+func set(values []string) map[string]bool {
+	ret := make(map[string]bool, len(values))
+	for _, v := range values {
+		ret[v] = true
+	}
+	return ret
+}
@@ -0,0 +1,301 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package recorder records MEXC market streams and periodic REST snapshots to
+// rotating archive files, see cmd/mexc-recorder for the daemon.
+//
+// Every stream push and snapshot is written as a Record holding the raw JSON of the
+// exchange, the exchange time when known and the local receive time, so that it can
+// be decoded again by the websocketmarket clients or the REST types. Stream outages
+// are logged and written as Gap records.
+package recorder
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jl1/nexapi/mexc/archive"
+	"github.com/jl1/nexapi/mexc/stream"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+)
+
+var (
+	Spot     = "spot"
+	Contract = "contract"
+)
+
+// Sources of records.
+var (
+	SourceStream = "stream"
+	SourceREST   = "rest"
+)
+
+// Record is a stream message or a REST snapshot. The topic of a snapshot is the
+// name of the snapshot and the symbol, like depth@BTCUSDT.
+type Record struct {
+	Market string `json:"market"`
+	Source string `json:"source"`
+	stream.Message
+}
+
+// Gap is an outage of a stream in milliseconds, its messages were not recorded.
+type Gap struct {
+	Market string `json:"market"`
+	Start  int64  `json:"start"`
+	End    int64  `json:"end"`
+	Error  string `json:"error"`
+}
+
+// StreamClient is implemented by the spot and contract websocketmarket clients.
+type StreamClient interface {
+	Open() error
+	Close() error
+	Subscribe(topics []string) error
+	OnMessage(fn func(*stream.Message))
+	OnOutage(fn func(*stream.Outage))
+}
+
+// Feed is a stream to record.
+type Feed struct {
+	Market string       `validate:"required"`
+	Client StreamClient `validate:"required"`
+	Topics []string     `validate:"required,min=1"`
+}
+
+// Poller takes a REST snapshot every Interval.
+type Poller struct {
+	Market   string        `validate:"required"`
+	Name     string        `validate:"required"`
+	Interval time.Duration `validate:"gt=0"`
+	// Poll returns the snapshots as messages, with their receive time set
+	Poll func(ctx context.Context) ([]*stream.Message, error) `validate:"required"`
+}
+
+type RecorderCfg struct {
+	// Logger
+	Logger *slog.Logger
+
+	// Output is the configuration of the files, the prefix of each file is set by
+	// the recorder: <market>-stream, <market>-rest or gaps
+	Output  archive.WriterCfg `validate:"-"`
+	Feeds   []*Feed           `validate:"dive"`
+	Pollers []*Poller         `validate:"dive"`
+	// OpenRetryDelay is the delay between two attempts to open a feed, defaults to
+	// DefaultOpenRetryDelay
+	OpenRetryDelay time.Duration `validate:"gte=0"`
+}
+
+const DefaultOpenRetryDelay = 5 * time.Second
+
+type Recorder struct {
+	logger         *slog.Logger
+	output         archive.WriterCfg
+	feeds          []*Feed
+	pollers        []*Poller
+	openRetryDelay time.Duration
+
+	mu      sync.Mutex
+	records map[string]*archive.Writer[Record]
+	gaps    *archive.Writer[Gap]
+	closed  bool
+}
+
+func NewRecorder(cfg *RecorderCfg) (*Recorder, error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		logger:         cfg.Logger,
+		output:         cfg.Output,
+		feeds:          cfg.Feeds,
+		pollers:        cfg.Pollers,
+		openRetryDelay: cfg.OpenRetryDelay,
+		records:        make(map[string]*archive.Writer[Record]),
+	}
+
+	if r.logger == nil {
+		r.logger = slog.Default()
+	}
+
+	if r.openRetryDelay == 0 {
+		r.openRetryDelay = DefaultOpenRetryDelay
+	}
+
+	// validates the output configuration before anything runs
+	output := r.output
+	output.Prefix = "gaps"
+	if r.gaps, err = archive.NewWriter[Gap](&output); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Run records until ctx is done, then closes the feeds and the files.
+func (r *Recorder) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+
+	for _, v := range r.feeds {
+		f := v
+		f.Client.OnMessage(func(msg *stream.Message) {
+			r.write(f.Market, SourceStream, msg)
+		})
+		f.Client.OnOutage(func(o *stream.Outage) {
+			r.gap(f.Market, o)
+		})
+
+		if err := f.Client.Subscribe(f.Topics); err != nil {
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.open(ctx, f)
+		}()
+	}
+
+	for _, v := range r.pollers {
+		p := v
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.poll(ctx, p)
+		}()
+	}
+
+	<-ctx.Done()
+
+	var errs []error
+	for _, f := range r.feeds {
+		errs = append(errs, f.Client.Close())
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	for _, w := range r.records {
+		errs = append(errs, w.Close())
+	}
+	errs = append(errs, r.gaps.Close())
+
+	return errors.Join(errs...)
+}
+
+// open opens f, retrying until it succeeds or ctx is done. The streams reconnect by
+// themselves once opened.
+func (r *Recorder) open(ctx context.Context, f *Feed) {
+	for {
+		err := f.Client.Open()
+		if err == nil {
+			r.logger.Info("recording stream", "market", f.Market, "topics", len(f.Topics))
+			return
+		}
+
+		r.logger.Error("failed to open stream", "market", f.Market, "error", err, "retry_in", r.openRetryDelay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.openRetryDelay):
+		}
+	}
+}
+
+func (r *Recorder) poll(ctx context.Context, p *Poller) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		msgs, err := p.Poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Warn("snapshot failed", "market", p.Market, "name", p.Name, "error", err)
+		}
+
+		for _, v := range msgs {
+			r.write(p.Market, SourceREST, v)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Recorder) write(market, source string, msg *stream.Message) {
+	w, err := r.writer(market + "-" + source)
+	if w == nil && err == nil {
+		// a message received while closing
+		return
+	}
+	if err == nil {
+		err = w.Write(&Record{Market: market, Source: source, Message: *msg})
+	}
+
+	if err != nil {
+		r.logger.Error("failed to record message", "market", market, "topic", msg.Topic, "error", err)
+	}
+}
+
+func (r *Recorder) gap(market string, o *stream.Outage) {
+	g := &Gap{Market: market, Start: o.Start, End: o.End}
+	if o.Err != nil {
+		g.Error = o.Err.Error()
+	}
+
+	r.logger.Warn("stream gap", "market", market, "start", time.UnixMilli(o.Start), "end", time.UnixMilli(o.End), "error", g.Error)
+
+	if err := r.gaps.Write(g); err != nil {
+		r.logger.Error("failed to record gap", "market", market, "error", err)
+	}
+}
+
+// writer returns the writer of prefix, or nil once the recorder is closed.
+func (r *Recorder) writer(prefix string) (*archive.Writer[Record], error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, nil
+	}
+
+	if w, ok := r.records[prefix]; ok {
+		return w, nil
+	}
+
+	output := r.output
+	output.Prefix = prefix
+
+	w, err := archive.NewWriter[Record](&output)
+	if err != nil {
+		return nil, err
+	}
+	r.records[prefix] = w
+
+	return w, nil
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recorder
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jl1/nexapi/mexc/archive"
+	"github.com/jl1/nexapi/mexc/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStream struct {
+	mu        sync.Mutex
+	fails     int
+	opened    chan struct{}
+	topics    []string
+	onMessage func(*stream.Message)
+	onOutage  func(*stream.Outage)
+}
+
+func (f *fakeStream) Open() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.fails > 0 {
+		f.fails--
+		return errors.New("dial failed")
+	}
+	close(f.opened)
+	return nil
+}
+
+func (f *fakeStream) Close() error                       { return nil }
+func (f *fakeStream) Subscribe(topics []string) error    { f.topics = topics; return nil }
+func (f *fakeStream) OnMessage(fn func(*stream.Message)) { f.onMessage = fn }
+func (f *fakeStream) OnOutage(fn func(*stream.Outage))   { f.onOutage = fn }
+
+func TestRecorder(t *testing.T) {
+	dir := t.TempDir()
+	feed := &fakeStream{fails: 1, opened: make(chan struct{})}
+
+	polled := make(chan struct{}, 10)
+	r, err := NewRecorder(&RecorderCfg{
+		Output: archive.WriterCfg{Dir: dir, Format: archive.JSONL, Compression: archive.Gzip},
+		Feeds:  []*Feed{{Market: Spot, Client: feed, Topics: []string{"spot@public.aggre.deals.v3.api.pb@100ms@BTCUSDT"}}},
+		Pollers: []*Poller{{
+			Market:   Contract,
+			Name:     "depth",
+			Interval: time.Hour,
+			Poll: func(ctx context.Context) ([]*stream.Message, error) {
+				defer func() { polled <- struct{}{} }()
+				return []*stream.Message{{Topic: "depth@BTC_USDT", Symbol: "BTC_USDT", Time: 1, Received: 2, Data: []byte(`{"version":1}`)}}, nil
+			},
+		}},
+		OpenRetryDelay: time.Millisecond,
+	})
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Run(ctx) }()
+
+	select {
+	case <-feed.opened:
+	case <-time.After(5 * time.Second):
+		t.Fatal("feed not opened")
+	}
+	<-polled
+
+	assert.Equal(t, []string{"spot@public.aggre.deals.v3.api.pb@100ms@BTCUSDT"}, feed.topics)
+	feed.onMessage(&stream.Message{Topic: "spot@public.aggre.deals.v3.api.pb@100ms@BTCUSDT", Symbol: "BTCUSDT", Time: 3, Received: 4, Data: []byte(`{"deals":[]}`)})
+	feed.onOutage(&stream.Outage{Start: 5, End: 6, Err: errors.New("reset")})
+
+	cancel()
+	assert.Nil(t, <-done)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Nil(t, err)
+	sort.Strings(files)
+	assert.Len(t, files, 3)
+
+	records, err := archive.ReadFile[Record](files[0])
+	assert.Nil(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, Contract, records[0].Market)
+		assert.Equal(t, SourceREST, records[0].Source)
+		assert.Equal(t, int64(1), records[0].Time)
+		assert.JSONEq(t, `{"version":1}`, string(records[0].Data))
+	}
+
+	gaps, err := archive.ReadFile[Gap](files[1])
+	assert.Nil(t, err)
+	assert.Equal(t, []*Gap{{Market: Spot, Start: 5, End: 6, Error: "reset"}}, gaps)
+
+	records, err = archive.ReadFile[Record](files[2])
+	assert.Nil(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, SourceStream, records[0].Source)
+		assert.Equal(t, "BTCUSDT", records[0].Symbol)
+		assert.Equal(t, int64(4), records[0].Received)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	data, err := os.ReadFile("../../cmd/mexc-recorder/recorder.example.yaml")
+	assert.Nil(t, err)
+
+	c, err := ParseConfig(data)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Hour, c.Output.MaxAge)
+	assert.Equal(t, 100, c.Spot.Depth.Limit)
+
+	cfg, err := c.RecorderCfg(nil)
+	assert.Nil(t, err)
+	assert.Len(t, cfg.Feeds, 2)
+	assert.Len(t, cfg.Feeds[0].Topics, 10)
+	assert.Contains(t, cfg.Feeds[0].Topics, "spot@public.limit.depth.v3.api.pb@ETHUSDT@20")
+	assert.Contains(t, cfg.Feeds[1].Topics, "kline@BTC_USDT@Min1")
+	assert.Len(t, cfg.Pollers, 5)
+
+	c, err = ParseConfig([]byte("output: {dir: data}\nspot: {symbols: [A, B, C, D, E, F, G, H, I, J, K, L, M, N, O, P], streams: [deals, depth]}"))
+	assert.Nil(t, err)
+	cfg, err = c.RecorderCfg(nil)
+	assert.Nil(t, err)
+	if assert.Len(t, cfg.Feeds, 2) {
+		assert.Len(t, cfg.Feeds[0].Topics, MaxSpotSubscriptions)
+		assert.Len(t, cfg.Feeds[1].Topics, 2)
+		assert.NotSame(t, cfg.Feeds[0].Client, cfg.Feeds[1].Client)
+	}
+
+	c, err = ParseConfig([]byte("output: {dir: data}\nspot: {symbols: [BTCUSDT], streams: [trades]}"))
+	assert.Nil(t, err)
+	_, err = c.RecorderCfg(nil)
+	assert.ErrorIs(t, err, ErrUnknownStream)
+
+	_, err = ParseConfig([]byte("output: {dir: data, typo: 1}\nspot: {symbols: [BTCUSDT]}"))
+	assert.NotNil(t, err)
+
+	_, err = ParseConfig([]byte("output: {dir: data}\nspot: {symbols: [BTCUSDT], funding: {interval: 1m}}"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replay
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the time, strategies that take a Clock run the same against live data
+// with SystemClock and against recorded data with the clock of a Replayer.
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SimClock is the clock of a replay, it is set to the receive time of every record
+// before the record is dispatched, so that it reads the time the live consumer saw.
+type SimClock struct {
+	now atomic.Int64
+}
+
+func (c *SimClock) Now() time.Time {
+	return time.UnixMilli(c.now.Load())
+}
+
+// UnixMilli returns the current time in milliseconds.
+func (c *SimClock) UnixMilli() int64 {
+	return c.now.Load()
+}
+
+func (c *SimClock) set(ms int64) {
+	c.now.Store(ms)
+}
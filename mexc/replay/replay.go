@@ -0,0 +1,404 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package replay replays the files of a recorder through the listeners of the
+// websocketmarket clients, so that code written against live streams runs unchanged
+// against recorded data. Records are replayed in the order they were received, ties
+// are broken by file and position, so a replay of the same files always produces
+// the same events. The clock of the replay reads the receive time of the current
+// record.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	contractws "github.com/jl1/nexapi/mexc/contract/websocketmarket"
+	"github.com/jl1/nexapi/mexc/orderbook"
+	"github.com/jl1/nexapi/mexc/recorder"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	spotws "github.com/jl1/nexapi/mexc/spot/websocketmarket"
+	spotwstypes "github.com/jl1/nexapi/mexc/spot/websocketmarket/types"
+	"github.com/jl1/nexapi/mexc/stream"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+const (
+	// RealTime replays records at the pace they were received.
+	RealTime = 1.0
+	// AsFastAsPossible replays records without waiting.
+	AsFastAsPossible = 0.0
+)
+
+var (
+	ErrNoClient        = errors.New("no client for the market")
+	ErrUnknownSnapshot = errors.New("unknown snapshot")
+)
+
+type ReplayerCfg struct {
+	// Logger
+	Logger *slog.Logger
+
+	// Files written by a recorder, see FindFiles
+	Files []string `validate:"required,min=1"`
+	// Spot and Contract receive the stream records of their market, records of a
+	// market without client are skipped. The clients do not need to be opened.
+	Spot     *spotws.SpotMarketStreamClient
+	Contract *contractws.ContractMarketStreamClient
+	// Speed is a multiple of the recorded pace, RealTime, 10 for ten times faster,
+	// or AsFastAsPossible
+	Speed float64 `validate:"gte=0"`
+	// Start and End limit the replay to the records received between them, in
+	// milliseconds, zero for no limit
+	Start int64 `validate:"gte=0"`
+	End   int64 `validate:"gte=0"`
+	// Books maintains an order book per symbol from the depth snapshots and updates,
+	// a book is dropped until the next snapshot when update versions are missing
+	Books bool
+	// ContractSizes converts contract volumes of the books to base coins, see
+	// ContractDetail.ContractSize, volumes stay in contracts for missing symbols
+	ContractSizes map[string]decimal.Decimal
+}
+
+// Stats counts the records of a replay.
+type Stats struct {
+	Records int
+	Gaps    int
+	// Skipped counts the records without client or that failed to decode
+	Skipped int
+}
+
+type bookKey struct {
+	market string
+	symbol string
+}
+
+type book struct {
+	*orderbook.Book
+	version int64
+}
+
+type Replayer struct {
+	logger        *slog.Logger
+	files         []string
+	spot          *spotws.SpotMarketStreamClient
+	contract      *contractws.ContractMarketStreamClient
+	speed         float64
+	start, end    int64
+	books         map[bookKey]*book
+	contractSizes map[string]decimal.Decimal
+
+	clock     *SimClock
+	snapshots *stream.Emitter
+	onGap     []func(*recorder.Gap)
+}
+
+func NewReplayer(cfg *ReplayerCfg) (*Replayer, error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Replayer{
+		logger:        cfg.Logger,
+		files:         cfg.Files,
+		spot:          cfg.Spot,
+		contract:      cfg.Contract,
+		speed:         cfg.Speed,
+		start:         cfg.Start,
+		end:           cfg.End,
+		contractSizes: cfg.ContractSizes,
+		clock:         &SimClock{},
+		snapshots:     &stream.Emitter{},
+	}
+
+	if r.logger == nil {
+		r.logger = slog.Default()
+	}
+
+	if cfg.Books {
+		r.books = make(map[bookKey]*book)
+	}
+
+	return r, nil
+}
+
+// Clock returns the simulated clock of the replay.
+func (r *Replayer) Clock() *SimClock {
+	return r.clock
+}
+
+// AddSnapshotListener registers a listener of the REST snapshots of market, the topic
+// is the name of the snapshot and the symbol, like depth@BTCUSDT. Spot depth
+// snapshots emit *spottypes.Orderbook and tickers *spottypes.Ticker, contract depth
+// snapshots emit *contracttypes.Depth, tickers *contracttypes.Ticker and funding
+// snapshots *contracttypes.FundingRate.
+func (r *Replayer) AddSnapshotListener(market, topic string, listener stream.Listener) {
+	r.snapshots.AddListener(market+"/"+topic, listener)
+}
+
+// OnGap registers fn to be called when a stream outage ends, the books of its market
+// are dropped until the next snapshot.
+func (r *Replayer) OnGap(fn func(*recorder.Gap)) {
+	r.onGap = append(r.onGap, fn)
+}
+
+// Book returns the order book of symbol, or nil until a snapshot was replayed. The
+// book is updated before the listeners of a depth event run and must only be used
+// from listeners.
+func (r *Replayer) Book(market, symbol string) *orderbook.Book {
+	if b, ok := r.books[bookKey{market, symbol}]; ok {
+		return b.Book
+	}
+	return nil
+}
+
+// Run replays the files until their end or until ctx is done. Listeners run on the
+// calling goroutine.
+func (r *Replayer) Run(ctx context.Context) (*Stats, error) {
+	m, closeAll, err := newMerger(r.files)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAll()
+
+	stats := &Stats{}
+
+	var (
+		first    int64
+		wallFrom time.Time
+	)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		v, err := m.next()
+		if err != nil {
+			return stats, err
+		}
+		if v == nil {
+			return stats, nil
+		}
+
+		if v.time < r.start || (r.end > 0 && v.time > r.end) {
+			continue
+		}
+
+		if r.speed > 0 {
+			if wallFrom.IsZero() {
+				first, wallFrom = v.time, time.Now()
+			}
+
+			due := wallFrom.Add(time.Duration(float64(time.Duration(v.time-first)*time.Millisecond) / r.speed))
+			if wait := time.Until(due); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return stats, ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		r.clock.set(v.time)
+
+		if v.gap != nil {
+			stats.Gaps++
+			r.gap(v.gap)
+			continue
+		}
+
+		stats.Records++
+		if err := r.dispatch(v.rec); err != nil {
+			stats.Skipped++
+			r.logger.Warn("record skipped", "market", v.rec.Market, "topic", v.rec.Topic, "error", err)
+		}
+	}
+}
+
+func (r *Replayer) gap(g *recorder.Gap) {
+	for k := range r.books {
+		if k.market == g.Market {
+			delete(r.books, k)
+		}
+	}
+
+	for _, fn := range r.onGap {
+		fn(g)
+	}
+}
+
+func (r *Replayer) dispatch(rec *recorder.Record) error {
+	if rec.Source == recorder.SourceREST {
+		return r.snapshot(rec)
+	}
+
+	msg := &rec.Message
+
+	switch rec.Market {
+	case recorder.Spot:
+		if r.spot == nil {
+			return fmt.Errorf("%w: %s", ErrNoClient, rec.Market)
+		}
+
+		event, err := r.spot.Decode(msg)
+		if err != nil {
+			return err
+		}
+		if depth, ok := event.(*spotwstypes.Depth); ok {
+			r.applySpotDepth(depth)
+		}
+
+		r.spot.Emit(msg.Topic, event)
+	case recorder.Contract:
+		if r.contract == nil {
+			return fmt.Errorf("%w: %s", ErrNoClient, rec.Market)
+		}
+
+		event, err := r.contract.Decode(msg)
+		if err != nil {
+			return err
+		}
+		if depth, ok := event.(*contracttypes.Depth); ok {
+			r.applyContractDepth(msg.Symbol, depth, false)
+		}
+
+		r.contract.Emit(msg.Topic, event)
+	default:
+		return fmt.Errorf("%w: %s", ErrNoClient, rec.Market)
+	}
+
+	return nil
+}
+
+func (r *Replayer) snapshot(rec *recorder.Record) error {
+	name, _, _ := strings.Cut(rec.Topic, "@")
+
+	var event any
+	switch rec.Market + "/" + name {
+	case recorder.Spot + "/depth":
+		event = &spottypes.Orderbook{}
+	case recorder.Spot + "/ticker":
+		event = &spottypes.Ticker{}
+	case recorder.Contract + "/depth":
+		event = &contracttypes.Depth{}
+	case recorder.Contract + "/ticker":
+		event = &contracttypes.Ticker{}
+	case recorder.Contract + "/funding":
+		event = &contracttypes.FundingRate{}
+	default:
+		return fmt.Errorf("%w: %s %s", ErrUnknownSnapshot, rec.Market, rec.Topic)
+	}
+
+	if err := json.Unmarshal(rec.Data, event); err != nil {
+		return err
+	}
+
+	switch v := event.(type) {
+	case *spottypes.Orderbook:
+		if r.books != nil {
+			b, err := orderbook.FromSpotOrderbook(v)
+			if err != nil {
+				return err
+			}
+			r.books[bookKey{recorder.Spot, rec.Symbol}] = &book{Book: b, version: v.LastUpdateID}
+		}
+	case *contracttypes.Depth:
+		r.applyContractDepth(rec.Symbol, v, true)
+	}
+
+	r.snapshots.Emit(rec.Market+"/"+rec.Topic, event)
+
+	return nil
+}
+
+// applySpotDepth applies snapshots and the updates newer than the book, the book is
+// dropped until the next snapshot when versions are missing.
+func (r *Replayer) applySpotDepth(d *spotwstypes.Depth) {
+	if r.books == nil {
+		return
+	}
+
+	version, _ := strconv.ParseInt(d.Version, 10, 64)
+	from := version
+	if d.FromVersion != "" {
+		from, _ = strconv.ParseInt(d.FromVersion, 10, 64)
+	}
+	key := bookKey{recorder.Spot, d.Symbol}
+
+	b, ok := r.books[key]
+	switch {
+	case d.Snapshot:
+		b = &book{Book: &orderbook.Book{}}
+		r.books[key] = b
+	case !ok || version <= b.version:
+		return
+	case from > b.version+1:
+		r.versionGap(key, b.version, from)
+		return
+	}
+
+	b.ApplySpotDepth(d)
+	b.version = version
+}
+
+func (r *Replayer) applyContractDepth(symbol string, d *contracttypes.Depth, snapshot bool) {
+	if r.books == nil {
+		return
+	}
+
+	size, ok := r.contractSizes[symbol]
+	if !ok {
+		size = decimal.NewFromInt(1)
+	}
+
+	key := bookKey{recorder.Contract, symbol}
+	if snapshot {
+		r.books[key] = &book{Book: orderbook.FromContractDepth(d, size), version: d.Version}
+		return
+	}
+
+	b, ok := r.books[key]
+	switch {
+	case !ok || d.Version <= b.version:
+		return
+	case d.Version > b.version+1:
+		r.versionGap(key, b.version, d.Version)
+		return
+	}
+
+	b.ApplyContractDepth(d, size)
+	b.version = d.Version
+}
+
+// versionGap drops the book of key like a stream outage does, the updates after
+// version were not recorded.
+func (r *Replayer) versionGap(key bookKey, version, next int64) {
+	delete(r.books, key)
+	r.logger.Warn("book dropped on a version gap", "market", key.market, "symbol", key.symbol, "version", version, "next", next)
+}
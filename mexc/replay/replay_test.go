@@ -0,0 +1,233 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jl1/nexapi/mexc/archive"
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	contractws "github.com/jl1/nexapi/mexc/contract/websocketmarket"
+	"github.com/jl1/nexapi/mexc/recorder"
+	spotws "github.com/jl1/nexapi/mexc/spot/websocketmarket"
+	spotwstypes "github.com/jl1/nexapi/mexc/spot/websocketmarket/types"
+	"github.com/jl1/nexapi/mexc/stream"
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func record(market, source, topic, symbol string, received int64, data string) *recorder.Record {
+	return &recorder.Record{Market: market, Source: source, Message: stream.Message{
+		Topic: topic, Symbol: symbol, Time: received - 1, Received: received, Data: []byte(data),
+	}}
+}
+
+// writeFiles records a session with a spot book, deals and an outage, and a contract
+// book, split across the files a recorder writes.
+func writeFiles(t *testing.T, dir string) {
+	write := func(prefix string, records ...*recorder.Record) {
+		w, err := archive.NewWriter[recorder.Record](&archive.WriterCfg{Dir: dir, Prefix: prefix, Format: archive.JSONL, Compression: archive.Zstd})
+		assert.Nil(t, err)
+		assert.Nil(t, w.Write(records...))
+		assert.Nil(t, w.Close())
+	}
+
+	write("spot-rest",
+		record(recorder.Spot, recorder.SourceREST, "depth@BTCUSDT", "BTCUSDT", 1000, `{"lastUpdateId":10,"bids":[["100","1"]],"asks":[["101","1"]]}`),
+	)
+	write("spot-stream",
+		// older than the snapshot
+		record(recorder.Spot, recorder.SourceStream, "spot@public.aggre.depth.v3.api.pb@100ms@BTCUSDT", "BTCUSDT", 1000, `{"asks":[],"bids":[{"p":"100","v":"0"}],"r":"9"}`),
+		record(recorder.Spot, recorder.SourceStream, "spot@public.aggre.deals.v3.api.pb@100ms@BTCUSDT", "BTCUSDT", 1100, `{"deals":[{"S":1,"p":"101","t":1099,"v":"0.5"}]}`),
+		record(recorder.Spot, recorder.SourceStream, "spot@public.aggre.depth.v3.api.pb@100ms@BTCUSDT", "BTCUSDT", 1200, `{"asks":[{"p":"101","v":"0.5"}],"bids":[],"r":"11"}`),
+		record(recorder.Spot, recorder.SourceStream, "spot@public.aggre.depth.v3.api.pb@100ms@BTCUSDT", "BTCUSDT", 1600, `{"asks":[{"p":"102","v":"1"}],"bids":[],"r":"12"}`),
+	)
+	write("contract-rest",
+		record(recorder.Contract, recorder.SourceREST, "depth@BTC_USDT", "BTC_USDT", 1050, `{"asks":[[60001,10,1]],"bids":[[60000,20,2]],"version":5,"timestamp":1049}`),
+	)
+	write("contract-stream",
+		record(recorder.Contract, recorder.SourceStream, "depth@BTC_USDT", "BTC_USDT", 1300, `{"asks":[[60001,0,0]],"bids":[],"version":6}`),
+	)
+
+	w, err := archive.NewWriter[recorder.Gap](&archive.WriterCfg{Dir: dir, Prefix: "gaps", Format: archive.JSONL})
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write(&recorder.Gap{Market: recorder.Spot, Start: 1300, End: 1500, Error: "reset"}))
+	assert.Nil(t, w.Close())
+}
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir)
+
+	files, err := FindFiles(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 5)
+
+	spot, err := spotws.NewSpotMarketStreamClient(&spotws.SpotMarketStreamCfg{BaseURL: spotws.SpotMarketStreamBaseURL})
+	assert.Nil(t, err)
+	contract, err := contractws.NewContractMarketStreamClient(&contractws.ContractMarketStreamCfg{BaseURL: contractws.ContractMarketStreamBaseURL})
+	assert.Nil(t, err)
+
+	r, err := NewReplayer(&ReplayerCfg{
+		Files:         files,
+		Spot:          spot,
+		Contract:      contract,
+		Books:         true,
+		ContractSizes: map[string]decimal.Decimal{"BTC_USDT": decimal.MustParse("0.0001")},
+	})
+	assert.Nil(t, err)
+
+	var log []string
+	at := func(event string) {
+		log = append(log, r.Clock().Now().Format("05.000")+" "+event)
+	}
+
+	r.AddSnapshotListener(recorder.Contract, "depth@BTC_USDT", func(e any) {
+		at("contract snapshot")
+		assert.Equal(t, int64(5), e.(*contracttypes.Depth).Version)
+	})
+	spot.AddListener("spot@public.aggre.deals.v3.api.pb@100ms@BTCUSDT", func(e any) {
+		at("deals " + e.(*spotwstypes.Deals).Deals[0].Price.String())
+	})
+	spot.AddListener("spot@public.aggre.depth.v3.api.pb@100ms@BTCUSDT", func(e any) {
+		at("depth " + e.(*spotwstypes.Depth).Version)
+
+		// the book is updated before the listeners run
+		if e.(*spotwstypes.Depth).Version == "11" {
+			ask, ok := r.Book(recorder.Spot, "BTCUSDT").BestAsk()
+			assert.True(t, ok)
+			assert.Equal(t, "0.5", ask.Qty.String())
+		}
+	})
+	contract.AddListener("depth@BTC_USDT", func(e any) {
+		at("contract depth")
+		assert.Empty(t, r.Book(recorder.Contract, "BTC_USDT").Asks)
+	})
+	r.OnGap(func(g *recorder.Gap) {
+		at("gap " + g.Market)
+	})
+
+	stats, err := r.Run(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, &Stats{Records: 7, Gaps: 1}, stats)
+
+	assert.Equal(t, []string{
+		"01.000 depth 9",
+		"01.050 contract snapshot",
+		"01.100 deals 101",
+		"01.200 depth 11",
+		"01.300 contract depth",
+		"01.500 gap spot",
+		"01.600 depth 12",
+	}, log)
+
+	// the spot book was dropped by the gap, the update that followed it is ignored
+	assert.Nil(t, r.Book(recorder.Spot, "BTCUSDT"))
+
+	bid, ok := r.Book(recorder.Contract, "BTC_USDT").BestBid()
+	assert.True(t, ok)
+	assert.True(t, bid.Qty.Equal(decimal.MustParse("0.002")))
+}
+
+func TestReplayVersionGap(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := archive.NewWriter[recorder.Record](&archive.WriterCfg{Dir: dir, Prefix: "contract-stream", Format: archive.JSONL})
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write(
+		record(recorder.Contract, recorder.SourceREST, "depth@BTC_USDT", "BTC_USDT", 1000, `{"asks":[[60001,10,1]],"bids":[[60000,20,2]],"version":5}`),
+		record(recorder.Contract, recorder.SourceStream, "depth@BTC_USDT", "BTC_USDT", 1100, `{"asks":[[60001,0,0]],"bids":[],"version":6}`),
+		// version 7 is missing
+		record(recorder.Contract, recorder.SourceStream, "depth@BTC_USDT", "BTC_USDT", 1200, `{"asks":[],"bids":[[60000,0,0]],"version":8}`),
+		record(recorder.Contract, recorder.SourceStream, "depth@BTC_USDT", "BTC_USDT", 1300, `{"asks":[],"bids":[[59999,1,1]],"version":9}`),
+	))
+	assert.Nil(t, w.Close())
+
+	w, err = archive.NewWriter[recorder.Record](&archive.WriterCfg{Dir: dir, Prefix: "spot-stream", Format: archive.JSONL})
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write(
+		record(recorder.Spot, recorder.SourceREST, "depth@BTCUSDT", "BTCUSDT", 1000, `{"lastUpdateId":10,"bids":[["100","1"]],"asks":[["101","1"]]}`),
+		record(recorder.Spot, recorder.SourceStream, "spot@public.aggre.depth.v3.api.pb@100ms@BTCUSDT", "BTCUSDT", 1100, `{"asks":[],"bids":[{"p":"99","v":"1"}],"fromVersion":"11","r":"13"}`),
+		// versions 14 to 15 are missing
+		record(recorder.Spot, recorder.SourceStream, "spot@public.aggre.depth.v3.api.pb@100ms@BTCUSDT", "BTCUSDT", 1200, `{"asks":[],"bids":[{"p":"98","v":"1"}],"fromVersion":"16","r":"17"}`),
+	))
+	assert.Nil(t, w.Close())
+
+	files, err := FindFiles(dir)
+	assert.Nil(t, err)
+
+	spot, err := spotws.NewSpotMarketStreamClient(&spotws.SpotMarketStreamCfg{BaseURL: spotws.SpotMarketStreamBaseURL})
+	assert.Nil(t, err)
+	contract, err := contractws.NewContractMarketStreamClient(&contractws.ContractMarketStreamCfg{BaseURL: contractws.ContractMarketStreamBaseURL})
+	assert.Nil(t, err)
+
+	r, err := NewReplayer(&ReplayerCfg{Files: files, Spot: spot, Contract: contract, Books: true})
+	assert.Nil(t, err)
+
+	var versions []int64
+	contract.AddListener("depth@BTC_USDT", func(e any) {
+		if b := r.Book(recorder.Contract, "BTC_USDT"); b != nil {
+			versions = append(versions, e.(*contracttypes.Depth).Version)
+		}
+	})
+	spot.AddListener("spot@public.aggre.depth.v3.api.pb@100ms@BTCUSDT", func(e any) {
+		if e.(*spotwstypes.Depth).Version == "13" {
+			bid, ok := r.Book(recorder.Spot, "BTCUSDT").BestBid()
+			assert.True(t, ok)
+			assert.Equal(t, "100", bid.Price.String())
+		}
+	})
+
+	stats, err := r.Run(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, stats.Skipped)
+
+	// the books are dropped at the missing versions until the next snapshot
+	assert.Equal(t, []int64{6}, versions)
+	assert.Nil(t, r.Book(recorder.Contract, "BTC_USDT"))
+	assert.Nil(t, r.Book(recorder.Spot, "BTCUSDT"))
+}
+
+func TestReplaySpeed(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir)
+
+	files, err := FindFiles(dir)
+	assert.Nil(t, err)
+
+	// the records span 600ms, ten times faster is 60ms
+	r, err := NewReplayer(&ReplayerCfg{Files: files, Speed: 10, Start: 1000, End: 1600})
+	assert.Nil(t, err)
+
+	begin := time.Now()
+	stats, err := r.Run(context.Background())
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, time.Since(begin), 60*time.Millisecond)
+
+	// without clients the stream records are skipped
+	assert.Equal(t, 7, stats.Records)
+	assert.Equal(t, 5, stats.Skipped)
+	assert.Equal(t, int64(1600), r.Clock().UnixMilli())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = r.Run(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replay
+
+import (
+	"container/heap"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jl1/nexapi/mexc/archive"
+	"github.com/jl1/nexapi/mexc/recorder"
+)
+
+// FindFiles returns the files written by a recorder in dir.
+func FindFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	for _, v := range entries {
+		name := v.Name()
+		if v.Type().IsRegular() && (isGapFile(name) || strings.HasPrefix(name, recorder.Spot+"-") || strings.HasPrefix(name, recorder.Contract+"-")) {
+			ret = append(ret, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(ret)
+
+	return ret, nil
+}
+
+func isGapFile(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), "gaps-")
+}
+
+// item is a record or a gap, gaps are replayed at their end, when the outage was
+// noticed.
+type item struct {
+	time int64
+	rec  *recorder.Record
+	gap  *recorder.Gap
+}
+
+// cursor reads the items of one file.
+type cursor struct {
+	// index of the file, breaks ties between files so that the order is deterministic
+	index int
+	head  *item
+	read  func() (*item, error)
+	close func() error
+}
+
+func openCursor(index int, path string) (*cursor, error) {
+	c := &cursor{index: index}
+
+	if isGapFile(path) {
+		r, err := archive.OpenReader[recorder.Gap](path)
+		if err != nil {
+			return nil, err
+		}
+
+		c.close = r.Close
+		c.read = func() (*item, error) {
+			v, err := r.Read()
+			if err != nil {
+				return nil, err
+			}
+			return &item{time: v.End, gap: v}, nil
+		}
+	} else {
+		r, err := archive.OpenReader[recorder.Record](path)
+		if err != nil {
+			return nil, err
+		}
+
+		c.close = r.Close
+		c.read = func() (*item, error) {
+			v, err := r.Read()
+			if err != nil {
+				return nil, err
+			}
+			return &item{time: v.Received, rec: v}, nil
+		}
+	}
+
+	return c, nil
+}
+
+// advance loads the next item into head, head is nil at the end of the file.
+func (c *cursor) advance() error {
+	v, err := c.read()
+	if errors.Is(err, io.EOF) {
+		c.head = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.head = v
+	return nil
+}
+
+// merger returns the items of several files by time, then by file and position in
+// the file.
+type merger []*cursor
+
+func (m merger) Len() int { return len(m) }
+
+func (m merger) Less(i, j int) bool {
+	if m[i].head.time != m[j].head.time {
+		return m[i].head.time < m[j].head.time
+	}
+	return m[i].index < m[j].index
+}
+
+func (m merger) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+
+func (m *merger) Push(x any) { *m = append(*m, x.(*cursor)) }
+
+func (m *merger) Pop() any {
+	old := *m
+	v := old[len(old)-1]
+	*m = old[:len(old)-1]
+	return v
+}
+
+func newMerger(paths []string) (*merger, func() error, error) {
+	m := &merger{}
+	var cursors []*cursor
+
+	closeAll := func() error {
+		var errs []error
+		for _, v := range cursors {
+			errs = append(errs, v.close())
+		}
+		return errors.Join(errs...)
+	}
+
+	for i, path := range paths {
+		c, err := openCursor(i, path)
+		if err != nil {
+			_ = closeAll()
+			return nil, nil, err
+		}
+		cursors = append(cursors, c)
+
+		if err := c.advance(); err != nil {
+			_ = closeAll()
+			return nil, nil, err
+		}
+		if c.head != nil {
+			*m = append(*m, c)
+		}
+	}
+	heap.Init(m)
+
+	return m, closeAll, nil
+}
+
+// next returns the next item, or nil after the last one.
+func (m *merger) next() (*item, error) {
+	if m.Len() == 0 {
+		return nil, nil
+	}
+
+	c := (*m)[0]
+	v := c.head
+
+	if err := c.advance(); err != nil {
+		return nil, err
+	}
+	if c.head == nil {
+		heap.Pop(m)
+	} else {
+		heap.Fix(m, 0)
+	}
+
+	return v, nil
+}
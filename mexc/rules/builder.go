@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rules
+
+import (
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// FromSpotSymbol builds the rules of a spot symbol. The precisions of the symbol are used
+// first, and the PRICE_FILTER, LOT_SIZE, MIN_NOTIONAL and NOTIONAL filters override them
+// when present.
+func FromSpotSymbol(info *spottypes.SymbolInfo) *SymbolRules {
+	r := &SymbolRules{
+		Symbol:      info.Symbol,
+		TickSize:    decimal.New(1, int32(info.QuotePrecision)),
+		StepSize:    decimal.New(1, int32(info.BaseAssetPrecision)),
+		MinNotional: info.QuoteAmountPrecision,
+		MaxNotional: info.MaxQuoteAmount,
+	}
+
+	// despite its name, baseSizePrecision is the minimum quantity of an order
+	if info.BaseSizePrecision.IsPositive() {
+		r.MinQty = info.BaseSizePrecision
+	}
+
+	// set overrides dst with v when the filter carries it
+	set := func(dst *decimal.Decimal, v decimal.Decimal) {
+		if !v.IsZero() {
+			*dst = v
+		}
+	}
+
+	for _, f := range info.Filters {
+		switch f.FilterType {
+		case spottypes.PriceFilter:
+			set(&r.TickSize, f.TickSize)
+			set(&r.MinPrice, f.MinPrice)
+			set(&r.MaxPrice, f.MaxPrice)
+		case spottypes.LotSizeFilter:
+			set(&r.StepSize, f.StepSize)
+			set(&r.MinQty, f.MinQty)
+			set(&r.MaxQty, f.MaxQty)
+		case spottypes.MinNotionalFilter, spottypes.NotionalFilter:
+			set(&r.MinNotional, f.MinNotional)
+			set(&r.MaxNotional, f.MaxNotional)
+		}
+	}
+
+	return r
+}
+
+// FromExchangeInfo builds the rules of every symbol of info.
+func FromExchangeInfo(info *spottypes.ExchangeInfo) Set {
+	ret := make(Set, len(info.Symbols))
+	for _, v := range info.Symbols {
+		ret[v.Symbol] = FromSpotSymbol(v)
+	}
+
+	return ret
+}
+
+// FromContractDetail builds the rules of a contract, quantities are counted in contracts.
+func FromContractDetail(detail *contracttypes.ContractDetail) *SymbolRules {
+	return &SymbolRules{
+		Symbol:   detail.Symbol,
+		TickSize: detail.PriceUnit,
+		StepSize: detail.VolUnit,
+		MinQty:   detail.MinVol,
+		MaxQty:   detail.MaxVol,
+	}
+}
+
+// FromContractDetails builds the rules of every contract of details.
+func FromContractDetails(details []*contracttypes.ContractDetail) Set {
+	ret := make(Set, len(details))
+	for _, v := range details {
+		ret[v.Symbol] = FromContractDetail(v)
+	}
+
+	return ret
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package rules validates and rounds prices and quantities with the trading rules
+// published by MEXC for spot symbols and contracts.
+package rules
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+var (
+	ErrTickSize    = errors.New("price is not a multiple of the tick size")
+	ErrPriceRange  = errors.New("price is out of range")
+	ErrStepSize    = errors.New("quantity is not a multiple of the step size")
+	ErrQtyRange    = errors.New("quantity is out of range")
+	ErrNotional    = errors.New("notional is out of range")
+	ErrUnknownRule = errors.New("no trading rules for symbol")
+)
+
+// SymbolRules holds the trading rules of a symbol, a zero value means no restriction.
+// Quantities of contracts are counted in contracts.
+type SymbolRules struct {
+	Symbol string
+
+	TickSize decimal.Decimal
+	MinPrice decimal.Decimal
+	MaxPrice decimal.Decimal
+
+	StepSize decimal.Decimal
+	MinQty   decimal.Decimal
+	MaxQty   decimal.Decimal
+
+	MinNotional decimal.Decimal
+	MaxNotional decimal.Decimal
+}
+
+// Provider looks up the trading rules of a symbol.
+type Provider interface {
+	GetSymbolRules(symbol string) (*SymbolRules, bool)
+}
+
+// Set is a Provider backed by a map keyed by symbol.
+type Set map[string]*SymbolRules
+
+func (s Set) GetSymbolRules(symbol string) (*SymbolRules, bool) {
+	r, ok := s[symbol]
+	return r, ok
+}
+
+// outOfRange reports whether v is outside of [min, max], zero bounds are ignored.
+func outOfRange(v, min, max decimal.Decimal) bool {
+	return (min.IsPositive() && v.LessThan(min)) || (max.IsPositive() && v.GreaterThan(max))
+}
+
+func (r *SymbolRules) ValidatePrice(price decimal.Decimal) error {
+	if !price.IsPositive() || outOfRange(price, r.MinPrice, r.MaxPrice) {
+		return fmt.Errorf("%w: %s price %s, min %s, max %s", ErrPriceRange, r.Symbol, price, r.MinPrice, r.MaxPrice)
+	}
+
+	if !price.IsMultipleOf(r.TickSize) {
+		return fmt.Errorf("%w: %s price %s, tick size %s", ErrTickSize, r.Symbol, price, r.TickSize)
+	}
+
+	return nil
+}
+
+func (r *SymbolRules) ValidateQty(qty decimal.Decimal) error {
+	if !qty.IsPositive() || outOfRange(qty, r.MinQty, r.MaxQty) {
+		return fmt.Errorf("%w: %s quantity %s, min %s, max %s", ErrQtyRange, r.Symbol, qty, r.MinQty, r.MaxQty)
+	}
+
+	if !qty.IsMultipleOf(r.StepSize) {
+		return fmt.Errorf("%w: %s quantity %s, step size %s", ErrStepSize, r.Symbol, qty, r.StepSize)
+	}
+
+	return nil
+}
+
+func (r *SymbolRules) ValidateNotional(notional decimal.Decimal) error {
+	if outOfRange(notional, r.MinNotional, r.MaxNotional) {
+		return fmt.Errorf("%w: %s notional %s, min %s, max %s", ErrNotional, r.Symbol, notional, r.MinNotional, r.MaxNotional)
+	}
+
+	return nil
+}
+
+// Validate checks a limit order of qty at price.
+func (r *SymbolRules) Validate(price, qty decimal.Decimal) error {
+	if err := r.ValidatePrice(price); err != nil {
+		return err
+	}
+
+	if err := r.ValidateQty(qty); err != nil {
+		return err
+	}
+
+	return r.ValidateNotional(price.Mul(qty))
+}
+
+// RoundPrice rounds price to the nearest multiple of the tick size.
+func (r *SymbolRules) RoundPrice(price decimal.Decimal) decimal.Decimal {
+	return price.RoundStep(r.TickSize)
+}
+
+// FloorPrice rounds price down to a multiple of the tick size.
+func (r *SymbolRules) FloorPrice(price decimal.Decimal) decimal.Decimal {
+	return price.FloorStep(r.TickSize)
+}
+
+// CeilPrice rounds price up to a multiple of the tick size.
+func (r *SymbolRules) CeilPrice(price decimal.Decimal) decimal.Decimal {
+	return price.CeilStep(r.TickSize)
+}
+
+// RoundQty rounds qty to the nearest multiple of the step size.
+func (r *SymbolRules) RoundQty(qty decimal.Decimal) decimal.Decimal {
+	return qty.RoundStep(r.StepSize)
+}
+
+// FloorQty rounds qty down to a multiple of the step size, so that an order never
+// exceeds the available balance.
+func (r *SymbolRules) FloorQty(qty decimal.Decimal) decimal.Decimal {
+	return qty.FloorStep(r.StepSize)
+}
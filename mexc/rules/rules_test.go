@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rules
+
+import (
+	"encoding/json"
+	"testing"
+
+	contracttypes "github.com/jl1/nexapi/mexc/contract/marketdata/types"
+	spottypes "github.com/jl1/nexapi/mexc/spot/marketdata/types"
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var d = decimal.MustParse
+
+func TestValidate(t *testing.T) {
+	r := &SymbolRules{
+		Symbol:      "BTCUSDT",
+		TickSize:    d("0.01"),
+		StepSize:    d("0.000001"),
+		MinNotional: d("5"),
+		MaxNotional: d("2000000"),
+	}
+
+	assert.Nil(t, r.Validate(d("60000.01"), d("0.001")))
+	assert.ErrorIs(t, r.Validate(d("60000.001"), d("0.001")), ErrTickSize)
+	assert.ErrorIs(t, r.Validate(d("60000"), d("0.0000015")), ErrStepSize)
+	assert.ErrorIs(t, r.Validate(d("60000"), d("0.00001")), ErrNotional)
+	assert.ErrorIs(t, r.Validate(d("-1"), d("0.001")), ErrPriceRange)
+	assert.ErrorIs(t, r.Validate(d("60000"), d("0")), ErrQtyRange)
+}
+
+func TestRound(t *testing.T) {
+	r := &SymbolRules{
+		TickSize: d("0.01"),
+		StepSize: d("0.001"),
+	}
+
+	assert.Equal(t, "1.23", r.RoundPrice(d("1.2349")).String())
+	assert.Equal(t, "1.24", r.RoundPrice(d("1.235")).String())
+	assert.Equal(t, "1.23", r.FloorPrice(d("1.2399")).String())
+	assert.Equal(t, "1.24", r.CeilPrice(d("1.2301")).String())
+	assert.Equal(t, "0.300", r.FloorQty(d("0.1").Add(d("0.2"))).String())
+	assert.Equal(t, "0.124", r.RoundQty(d("0.1235")).String())
+
+	assert.Equal(t, "1.2345", (&SymbolRules{}).RoundPrice(d("1.2345")).String())
+}
+
+func TestFromSpotSymbol(t *testing.T) {
+	var info spottypes.ExchangeInfo
+	err := json.Unmarshal([]byte(`{"symbols":[{
+		"symbol":"BTCUSDT",
+		"baseAssetPrecision":6,
+		"quotePrecision":2,
+		"quoteAmountPrecision":"5",
+		"baseSizePrecision":"0.0001",
+		"maxQuoteAmount":"2000000",
+		"filters":[{"filterType":"LOT_SIZE","minQty":"0.0002","maxQty":"100","stepSize":"0.0001"}]
+	}]}`), &info)
+	assert.Nil(t, err)
+
+	set := FromExchangeInfo(&info)
+
+	r, ok := set.GetSymbolRules("BTCUSDT")
+	assert.True(t, ok)
+	assert.Equal(t, "0.01", r.TickSize.String())
+	assert.Equal(t, "0.0001", r.StepSize.String())
+	assert.Equal(t, "0.0002", r.MinQty.String())
+	assert.Equal(t, "100", r.MaxQty.String())
+	assert.Equal(t, "5", r.MinNotional.String())
+	assert.Equal(t, "2000000", r.MaxNotional.String())
+
+	_, ok = set.GetSymbolRules("ETHUSDT")
+	assert.False(t, ok)
+}
+
+func TestFromSpotSymbolWithoutFilters(t *testing.T) {
+	// BTCUSDT as returned by exchangeInfo, without any filter
+	var info spottypes.ExchangeInfo
+	err := json.Unmarshal([]byte(`{"symbols":[{
+		"symbol":"BTCUSDT",
+		"status":"1",
+		"baseAsset":"BTC",
+		"baseAssetPrecision":8,
+		"quoteAsset":"USDT",
+		"quotePrecision":2,
+		"quoteAssetPrecision":2,
+		"baseCommissionPrecision":8,
+		"quoteCommissionPrecision":8,
+		"orderTypes":["LIMIT","MARKET","LIMIT_MAKER"],
+		"isSpotTradingAllowed":true,
+		"permissions":["SPOT"],
+		"quoteAmountPrecision":"1.000000000000000000000000000000",
+		"baseSizePrecision":"0.000001",
+		"maxQuoteAmount":"2000000.000000000000000000000000000000",
+		"makerCommission":"0",
+		"takerCommission":"0.0005",
+		"quoteAmountPrecisionMarket":"1.000000000000000000000000000000",
+		"maxQuoteAmountMarket":"100000.000000000000000000000000000000",
+		"filters":[]
+	}]}`), &info)
+	assert.Nil(t, err)
+
+	r := FromSpotSymbol(info.Symbols[0])
+	assert.Equal(t, "0.00000001", r.StepSize.String())
+	assert.Equal(t, "0.000001", r.MinQty.String())
+
+	assert.Nil(t, r.ValidateQty(d("0.00015")))
+	assert.True(t, r.RoundQty(d("0.00015")).Equal(d("0.00015")))
+	assert.ErrorIs(t, r.ValidateQty(d("0.0000005")), ErrQtyRange)
+}
+
+func TestFromContractDetail(t *testing.T) {
+	r := FromContractDetail(&contracttypes.ContractDetail{
+		Symbol:    "BTC_USDT",
+		PriceUnit: d("0.1"),
+		VolUnit:   d("1"),
+		MinVol:    d("1"),
+		MaxVol:    d("1000000"),
+	})
+
+	assert.Nil(t, r.Validate(d("60000.1"), d("10")))
+	assert.ErrorIs(t, r.Validate(d("60000.1"), d("1.5")), ErrStepSize)
+	assert.ErrorIs(t, r.Validate(d("60000.05"), d("1")), ErrTickSize)
+}
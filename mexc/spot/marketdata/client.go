@@ -26,6 +26,8 @@ import (
 	"github.com/go-playground/validator"
 	"github.com/jl1/nexapi/mexc/spot/marketdata/types"
 	spotutils "github.com/jl1/nexapi/mexc/spot/utils"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+	"github.com/jl1/nexapi/utils/decimal"
 	"github.com/valyala/fastjson"
 )
 
@@ -42,7 +44,7 @@ func NewSpotMarketDataClient(cfg *spotutils.SpotClientCfg) (*SpotMarketDataClien
 		return nil, err
 	}
 
-	validator := validator.New()
+	validator := mexcutils.NewValidator()
 
 	return &SpotMarketDataClient{
 		SpotClient: cli,
@@ -297,15 +299,23 @@ func (s *SpotMarketDataClient) GetKlines(ctx context.Context, param types.GetKli
 			return nil, fmt.Errorf("unknown kline value: %s", v.String())
 		}
 
+		var prices [8]decimal.Decimal
+		for _, i := range []int{1, 2, 3, 4, 5, 7} {
+			prices[i], err = mexcutils.ParseDecimal(kline[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		ret = append(ret, &types.Kline{
 			OpenTime:         kline[0].GetInt64(),
-			OpenPrice:        kline[1].String(),
-			HighPrice:        kline[2].String(),
-			LowPrice:         kline[3].String(),
-			ClosePrice:       kline[4].String(),
-			Volume:           kline[5].String(),
+			OpenPrice:        prices[1],
+			HighPrice:        prices[2],
+			LowPrice:         prices[3],
+			ClosePrice:       prices[4],
+			Volume:           prices[5],
 			CloseTime:        kline[6].GetInt64(),
-			QuoteAssetVolume: kline[7].String(),
+			QuoteAssetVolume: prices[7],
 		})
 	}
 
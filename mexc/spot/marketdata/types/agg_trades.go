@@ -17,6 +17,8 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type GetAggTradesParam struct {
 	Symbol    string `url:"symbol" validate:"required"`
 	StartTime int64  `url:"startTime,omitempty" validate:"omitempty"`
@@ -25,12 +27,12 @@ type GetAggTradesParam struct {
 }
 
 type AggTrade struct {
-	A  int    `json:"a"` // Aggregate tradeId
-	F  int    `json:"f"` // First tradeId
-	L  int    `json:"l"` // Last tradeId
-	P  string `json:"p"` // Price
-	Q  string `json:"q"` // Quantity
-	T  int64  `json:"T"` // Timestamp
-	M  bool   `json:"m"` // Was the buyer the maker?
-	Ma bool   `json:"M"` // Was the trade the best price match?
+	A  int             `json:"a"` // Aggregate tradeId
+	F  int             `json:"f"` // First tradeId
+	L  int             `json:"l"` // Last tradeId
+	P  decimal.Decimal `json:"p"` // Price
+	Q  decimal.Decimal `json:"q"` // Quantity
+	T  int64           `json:"T"` // Timestamp
+	M  bool            `json:"m"` // Was the buyer the maker?
+	Ma bool            `json:"M"` // Was the trade the best price match?
 }
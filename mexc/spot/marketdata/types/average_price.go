@@ -17,11 +17,13 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type GetAvgPriceParam struct {
 	Symbol string `url:"symbol" validate:"required"`
 }
 
 type AvgPrice struct {
-	Mins  int64  `json:"mins"`
-	Price string `json:"price"`
+	Mins  int64           `json:"mins"`
+	Price decimal.Decimal `json:"price"`
 }
@@ -17,14 +17,16 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type GetBookTickerParam struct {
 	Symbol string `url:"symbol" validate:"omitempty"`
 }
 
 type BookTicker struct {
-	Symbol   string `json:"symbol"`
-	BidPrice string `json:"bidPrice"`
-	BidQty   string `json:"bidQty"`
-	AskPrice string `json:"askPrice"`
-	AskQty   string `json:"askQty"`
+	Symbol   string          `json:"symbol"`
+	BidPrice decimal.Decimal `json:"bidPrice"`
+	BidQty   decimal.Decimal `json:"bidQty"`
+	AskPrice decimal.Decimal `json:"askPrice"`
+	AskQty   decimal.Decimal `json:"askQty"`
 }
@@ -17,6 +17,8 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type GetExchangeInfoParam struct {
 	Symbol string
 }
@@ -26,59 +28,74 @@ type GetExchangeInfoParams struct {
 }
 
 type ExchangeInfo struct {
-	Timezone   string `json:"timezone"`
-	ServerTime int64  `json:"serverTime"`
-	RateLimits []struct {
-		RateLimitType string `json:"rateLimitType"`
-		Interval      string `json:"interval"`
-		IntervalNum   int    `json:"intervalNum"`
-		Limit         int    `json:"limit"`
-	} `json:"rateLimits"`
-	Symbols []struct {
-		Symbol                   string   `json:"symbol"`
-		Status                   string   `json:"status"`
-		BaseAsset                string   `json:"baseAsset"`
-		BaseAssetPrecision       int      `json:"baseAssetPrecision"`
-		QuoteAsset               string   `json:"quoteAsset"`
-		QuotePrecision           int      `json:"quotePrecision"`
-		QuoteAssetPrecision      int      `json:"quoteAssetPrecision"`
-		BaseCommissionPrecision  int      `json:"baseCommissionPrecision"`
-		QuoteCommissionPrecision int      `json:"quoteCommissionPrecision"`
-		OrderTypes               []string `json:"orderTypes"`
-		IsSpotTradingAllowed     bool     `json:"isSpotTradingAllowed"`
-		IsMarginTradingAllowed   bool     `json:"isMarginTradingAllowed"`
-		QuoteAmountPrecision     string   `json:"quoteAmountPrecision"`
-		BaseSizePrecision        string   `json:"baseSizePrecision"`
-		Permissions              []string `json:"permissions"`
-		Filters                  []struct {
-			FilterType            string `json:"filterType"`
-			MinPrice              string `json:"minPrice,omitempty"`
-			MaxPrice              string `json:"maxPrice,omitempty"`
-			TickSize              string `json:"tickSize,omitempty"`
-			MinQty                string `json:"minQty,omitempty"`
-			MaxQty                string `json:"maxQty,omitempty"`
-			StepSize              string `json:"stepSize,omitempty"`
-			Limit                 int    `json:"limit,omitempty"`
-			MinTrailingAboveDelta int    `json:"minTrailingAboveDelta,omitempty"`
-			MaxTrailingAboveDelta int    `json:"maxTrailingAboveDelta,omitempty"`
-			MinTrailingBelowDelta int    `json:"minTrailingBelowDelta,omitempty"`
-			MaxTrailingBelowDelta int    `json:"maxTrailingBelowDelta,omitempty"`
-			BidMultiplierUp       string `json:"bidMultiplierUp,omitempty"`
-			BidMultiplierDown     string `json:"bidMultiplierDown,omitempty"`
-			AskMultiplierUp       string `json:"askMultiplierUp,omitempty"`
-			AskMultiplierDown     string `json:"askMultiplierDown,omitempty"`
-			AvgPriceMins          int    `json:"avgPriceMins,omitempty"`
-			MinNotional           string `json:"minNotional,omitempty"`
-			ApplyMinToMarket      bool   `json:"applyMinToMarket,omitempty"`
-			MaxNotional           string `json:"maxNotional,omitempty"`
-			ApplyMaxToMarket      bool   `json:"applyMaxToMarket,omitempty"`
-			MaxNumOrders          int    `json:"maxNumOrders,omitempty"`
-			MaxNumAlgoOrders      int    `json:"maxNumAlgoOrders,omitempty"`
-		} `json:"filters"`
-		MaxQuoteAmount             string `json:"maxQuoteAmount"`
-		MakerCommission            string `json:"makerCommission"`
-		TakerCommission            string `json:"takerCommission"`
-		QuoteAmountPrecisionMarket string `json:"quoteAmountPrecisionMarket"`
-		MaxQuoteAmountMarket       string `json:"maxQuoteAmountMarket"`
-	} `json:"symbols"`
+	Timezone   string        `json:"timezone"`
+	ServerTime int64         `json:"serverTime"`
+	RateLimits []*RateLimit  `json:"rateLimits"`
+	Symbols    []*SymbolInfo `json:"symbols"`
+}
+
+type RateLimit struct {
+	RateLimitType string `json:"rateLimitType"`
+	Interval      string `json:"interval"`
+	IntervalNum   int    `json:"intervalNum"`
+	Limit         int    `json:"limit"`
+}
+
+type SymbolInfo struct {
+	Symbol                     string          `json:"symbol"`
+	Status                     string          `json:"status"`
+	BaseAsset                  string          `json:"baseAsset"`
+	BaseAssetPrecision         int             `json:"baseAssetPrecision"`
+	QuoteAsset                 string          `json:"quoteAsset"`
+	QuotePrecision             int             `json:"quotePrecision"`
+	QuoteAssetPrecision        int             `json:"quoteAssetPrecision"`
+	BaseCommissionPrecision    int             `json:"baseCommissionPrecision"`
+	QuoteCommissionPrecision   int             `json:"quoteCommissionPrecision"`
+	OrderTypes                 []string        `json:"orderTypes"`
+	IsSpotTradingAllowed       bool            `json:"isSpotTradingAllowed"`
+	IsMarginTradingAllowed     bool            `json:"isMarginTradingAllowed"`
+	QuoteAmountPrecision       decimal.Decimal `json:"quoteAmountPrecision"`
+	BaseSizePrecision          decimal.Decimal `json:"baseSizePrecision"`
+	Permissions                []string        `json:"permissions"`
+	Filters                    []*SymbolFilter `json:"filters"`
+	MaxQuoteAmount             decimal.Decimal `json:"maxQuoteAmount"`
+	MakerCommission            decimal.Decimal `json:"makerCommission"`
+	TakerCommission            decimal.Decimal `json:"takerCommission"`
+	QuoteAmountPrecisionMarket decimal.Decimal `json:"quoteAmountPrecisionMarket"`
+	MaxQuoteAmountMarket       decimal.Decimal `json:"maxQuoteAmountMarket"`
+}
+
+type SymbolFilterType = string
+
+var (
+	PriceFilter       SymbolFilterType = "PRICE_FILTER"
+	LotSizeFilter     SymbolFilterType = "LOT_SIZE"
+	MinNotionalFilter SymbolFilterType = "MIN_NOTIONAL"
+	NotionalFilter    SymbolFilterType = "NOTIONAL"
+)
+
+type SymbolFilter struct {
+	FilterType            SymbolFilterType `json:"filterType"`
+	MinPrice              decimal.Decimal  `json:"minPrice,omitempty"`
+	MaxPrice              decimal.Decimal  `json:"maxPrice,omitempty"`
+	TickSize              decimal.Decimal  `json:"tickSize,omitempty"`
+	MinQty                decimal.Decimal  `json:"minQty,omitempty"`
+	MaxQty                decimal.Decimal  `json:"maxQty,omitempty"`
+	StepSize              decimal.Decimal  `json:"stepSize,omitempty"`
+	Limit                 int              `json:"limit,omitempty"`
+	MinTrailingAboveDelta int              `json:"minTrailingAboveDelta,omitempty"`
+	MaxTrailingAboveDelta int              `json:"maxTrailingAboveDelta,omitempty"`
+	MinTrailingBelowDelta int              `json:"minTrailingBelowDelta,omitempty"`
+	MaxTrailingBelowDelta int              `json:"maxTrailingBelowDelta,omitempty"`
+	BidMultiplierUp       decimal.Decimal  `json:"bidMultiplierUp,omitempty"`
+	BidMultiplierDown     decimal.Decimal  `json:"bidMultiplierDown,omitempty"`
+	AskMultiplierUp       decimal.Decimal  `json:"askMultiplierUp,omitempty"`
+	AskMultiplierDown     decimal.Decimal  `json:"askMultiplierDown,omitempty"`
+	AvgPriceMins          int              `json:"avgPriceMins,omitempty"`
+	MinNotional           decimal.Decimal  `json:"minNotional,omitempty"`
+	ApplyMinToMarket      bool             `json:"applyMinToMarket,omitempty"`
+	MaxNotional           decimal.Decimal  `json:"maxNotional,omitempty"`
+	ApplyMaxToMarket      bool             `json:"applyMaxToMarket,omitempty"`
+	MaxNumOrders          int              `json:"maxNumOrders,omitempty"`
+	MaxNumAlgoOrders      int              `json:"maxNumAlgoOrders,omitempty"`
 }
@@ -19,6 +19,7 @@ package types
 
 import (
 	spotutils "github.com/jl1/nexapi/mexc/spot/utils"
+	"github.com/jl1/nexapi/utils/decimal"
 )
 
 type GetKlineParam struct {
@@ -30,12 +31,12 @@ type GetKlineParam struct {
 }
 
 type Kline struct {
-	OpenTime         int64  `json:"openTime"`
-	OpenPrice        string `json:"openPrice"`
-	HighPrice        string `json:"highPrice"`
-	LowPrice         string `json:"lowPrice"`
-	ClosePrice       string `json:"closePrice"`
-	Volume           string `json:"volume"`
-	CloseTime        int64  `json:"closeTime"`
-	QuoteAssetVolume string `json:"quoteAssetVolume"`
+	OpenTime         int64           `json:"openTime"`
+	OpenPrice        decimal.Decimal `json:"openPrice"`
+	HighPrice        decimal.Decimal `json:"highPrice"`
+	LowPrice         decimal.Decimal `json:"lowPrice"`
+	ClosePrice       decimal.Decimal `json:"closePrice"`
+	Volume           decimal.Decimal `json:"volume"`
+	CloseTime        int64           `json:"closeTime"`
+	QuoteAssetVolume decimal.Decimal `json:"quoteAssetVolume"`
 }
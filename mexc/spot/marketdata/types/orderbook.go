@@ -17,13 +17,15 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type GetOrderbookParams struct {
 	Symbol string `url:"symbol" validate:"required"`
 	Limit  int    `url:"limit,omitempty" validate:"omitempty,max=5000"`
 }
 
 type Orderbook struct {
-	LastUpdateID int64      `json:"lastUpdateId"`
-	Bids         [][]string `json:"bids"`
-	Asks         [][]string `json:"asks"`
+	LastUpdateID int64               `json:"lastUpdateId"`
+	Bids         [][]decimal.Decimal `json:"bids"`
+	Asks         [][]decimal.Decimal `json:"asks"`
 }
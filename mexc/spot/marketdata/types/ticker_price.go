@@ -17,11 +17,13 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type GetTickerPriceForSymbolParam struct {
 	Symbol string `url:"symbol" validate:"required"`
 }
 
 type TickerPrice struct {
-	Symbol string `json:"symbol"`
-	Price  string `json:"price"`
+	Symbol string          `json:"symbol"`
+	Price  decimal.Decimal `json:"price"`
 }
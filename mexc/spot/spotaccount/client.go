@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/go-playground/validator"
+	"github.com/jl1/nexapi/mexc/rules"
 	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
 	spotutils "github.com/jl1/nexapi/mexc/spot/utils"
 	mexcutils "github.com/jl1/nexapi/mexc/utils"
@@ -38,6 +39,8 @@ type SpotAccountClient struct {
 
 	// validate struct fields
 	validate *validator.Validate
+	// validate orders locally when set
+	symbolRules rules.Provider
 }
 
 type SpotAccountClientCfg struct {
@@ -50,10 +53,13 @@ type SpotAccountClientCfg struct {
 	Secret     string `validate:"required"`
 	RecvWindow int
 	HTTPClient *http.Client
+	// SymbolRules is optional, when set CreateOrder rejects orders violating
+	// the trading rules of the symbol before sending them
+	SymbolRules rules.Provider
 }
 
 func NewSpotAccountClient(cfg *SpotAccountClientCfg) (*SpotAccountClient, error) {
-	validator := validator.New()
+	validator := mexcutils.NewValidator()
 
 	err := validator.Struct(cfg)
 	if err != nil {
@@ -74,8 +80,9 @@ func NewSpotAccountClient(cfg *SpotAccountClientCfg) (*SpotAccountClient, error)
 	}
 
 	return &SpotAccountClient{
-		SpotClient: cli,
-		validate:   validator,
+		SpotClient:  cli,
+		validate:    validator,
+		symbolRules: cfg.SymbolRules,
 	}, nil
 }
 
@@ -224,6 +231,58 @@ func (s *SpotAccountClient) QueryOrder(ctx context.Context, param types.QueryOrd
 	return &ret, nil
 }
 
+// CancelOrder cancels an open order, the returned order has the canceled status.
+func (s *SpotAccountClient) CancelOrder(ctx context.Context, param types.CancelOrderParam) (*types.Order, error) {
+	req := spotutils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    "/api/v3/order",
+		Method:  http.MethodDelete,
+	}
+
+	headers, err := s.GenAuthHeaders(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	query := types.CancelOrderParams{
+		CancelOrderParam: param,
+		DefaultParam: mexcutils.DefaultParam{
+			RecvWindow: s.GetRecvWindow(),
+			Timestamp:  time.Now().UnixMilli(),
+		},
+	}
+
+	err = s.validate.Struct(query)
+	if err != nil {
+		return nil, err
+	}
+
+	signString, err := mexcutils.NormalizeRequestContent(query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, []byte(s.GetSecret()))
+	h.Write([]byte(signString))
+	signature := hex.EncodeToString(h.Sum(nil))
+	query.Signature = signature
+
+	req.Query = query
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.Order
+	if err = json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
 func (s *SpotAccountClient) CreateOrder(ctx context.Context, param types.CreateOrderParam) (*types.CreateOrderResp, error) {
 	req := spotutils.HTTPRequest{
 		BaseURL: s.GetBaseURL(),
@@ -250,6 +309,13 @@ func (s *SpotAccountClient) CreateOrder(ctx context.Context, param types.CreateO
 		return nil, err
 	}
 
+	if s.symbolRules != nil {
+		err = checkOrderRules(s.symbolRules, param)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	signString, err := mexcutils.NormalizeRequestContent(query, nil)
 	if err != nil {
 		return nil, err
@@ -274,3 +340,399 @@ func (s *SpotAccountClient) CreateOrder(ctx context.Context, param types.CreateO
 
 	return &createOrderResp, nil
 }
+
+func (s *SpotAccountClient) GetTradeFee(ctx context.Context, param types.GetTradeFeeParam) (*types.TradeFee, error) {
+	req := spotutils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    "/api/v3/tradeFee",
+		Method:  http.MethodGet,
+	}
+
+	headers, err := s.GenAuthHeaders(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	query := types.GetTradeFeeParams{
+		GetTradeFeeParam: param,
+		DefaultParam: mexcutils.DefaultParam{
+			RecvWindow: s.GetRecvWindow(),
+			Timestamp:  time.Now().UnixMilli(),
+		},
+	}
+
+	err = s.validate.Struct(query)
+	if err != nil {
+		return nil, err
+	}
+
+	signString, err := mexcutils.NormalizeRequestContent(query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, []byte(s.GetSecret()))
+	h.Write([]byte(signString))
+	signature := hex.EncodeToString(h.Sum(nil))
+	query.Signature = signature
+
+	req.Query = query
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.TradeFee
+	if err = json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *SpotAccountClient) GetSelfSymbols(ctx context.Context) (*types.SelfSymbols, error) {
+	req := spotutils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    "/api/v3/selfSymbols",
+		Method:  http.MethodGet,
+	}
+
+	headers, err := s.GenAuthHeaders(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	query := mexcutils.DefaultParam{
+		RecvWindow: s.GetRecvWindow(),
+		Timestamp:  time.Now().UnixMilli(),
+	}
+
+	err = s.validate.Struct(query)
+	if err != nil {
+		return nil, err
+	}
+
+	signString, err := mexcutils.NormalizeRequestContent(query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, []byte(s.GetSecret()))
+	h.Write([]byte(signString))
+	signature := hex.EncodeToString(h.Sum(nil))
+	query.Signature = signature
+
+	req.Query = query
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.SelfSymbols
+	if err = json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *SpotAccountClient) GetKYCStatus(ctx context.Context) (*types.KYC, error) {
+	req := spotutils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    "/api/v3/kyc/status",
+		Method:  http.MethodGet,
+	}
+
+	headers, err := s.GenAuthHeaders(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	query := mexcutils.DefaultParam{
+		RecvWindow: s.GetRecvWindow(),
+		Timestamp:  time.Now().UnixMilli(),
+	}
+
+	err = s.validate.Struct(query)
+	if err != nil {
+		return nil, err
+	}
+
+	signString, err := mexcutils.NormalizeRequestContent(query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, []byte(s.GetSecret()))
+	h.Write([]byte(signString))
+	signature := hex.EncodeToString(h.Sum(nil))
+	query.Signature = signature
+
+	req.Query = query
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.KYC
+	if err = json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *SpotAccountClient) GetMxDeduct(ctx context.Context) (*types.MxDeduct, error) {
+	req := spotutils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    "/api/v3/mxDeduct/enable",
+		Method:  http.MethodGet,
+	}
+
+	headers, err := s.GenAuthHeaders(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	query := mexcutils.DefaultParam{
+		RecvWindow: s.GetRecvWindow(),
+		Timestamp:  time.Now().UnixMilli(),
+	}
+
+	err = s.validate.Struct(query)
+	if err != nil {
+		return nil, err
+	}
+
+	signString, err := mexcutils.NormalizeRequestContent(query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, []byte(s.GetSecret()))
+	h.Write([]byte(signString))
+	signature := hex.EncodeToString(h.Sum(nil))
+	query.Signature = signature
+
+	req.Query = query
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.MxDeduct
+	if err = json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *SpotAccountClient) SetMxDeduct(ctx context.Context, param types.MxDeductParam) (*types.MxDeduct, error) {
+	req := spotutils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    "/api/v3/mxDeduct/enable",
+		Method:  http.MethodPost,
+	}
+
+	headers, err := s.GenAuthHeaders(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	query := types.MxDeductParams{
+		MxDeductParam: param,
+		DefaultParam: mexcutils.DefaultParam{
+			RecvWindow: s.GetRecvWindow(),
+			Timestamp:  time.Now().UnixMilli(),
+		},
+	}
+
+	err = s.validate.Struct(query)
+	if err != nil {
+		return nil, err
+	}
+
+	signString, err := mexcutils.NormalizeRequestContent(query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, []byte(s.GetSecret()))
+	h.Write([]byte(signString))
+	signature := hex.EncodeToString(h.Sum(nil))
+	query.Signature = signature
+
+	req.Query = query
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.MxDeduct
+	if err = json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *SpotAccountClient) GetConvertibleAssets(ctx context.Context) ([]*types.ConvertibleAsset, error) {
+	req := spotutils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    "/api/v3/capital/convert/list",
+		Method:  http.MethodGet,
+	}
+
+	headers, err := s.GenAuthHeaders(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	query := mexcutils.DefaultParam{
+		RecvWindow: s.GetRecvWindow(),
+		Timestamp:  time.Now().UnixMilli(),
+	}
+
+	err = s.validate.Struct(query)
+	if err != nil {
+		return nil, err
+	}
+
+	signString, err := mexcutils.NormalizeRequestContent(query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, []byte(s.GetSecret()))
+	h.Write([]byte(signString))
+	signature := hex.EncodeToString(h.Sum(nil))
+	query.Signature = signature
+
+	req.Query = query
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*types.ConvertibleAsset
+	if err = json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (s *SpotAccountClient) Convert(ctx context.Context, param types.ConvertParam) (*types.ConvertResp, error) {
+	req := spotutils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    "/api/v3/capital/convert",
+		Method:  http.MethodPost,
+	}
+
+	headers, err := s.GenAuthHeaders(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	query := types.ConvertParams{
+		ConvertParam: param,
+		DefaultParam: mexcutils.DefaultParam{
+			RecvWindow: s.GetRecvWindow(),
+			Timestamp:  time.Now().UnixMilli(),
+		},
+	}
+
+	err = s.validate.Struct(query)
+	if err != nil {
+		return nil, err
+	}
+
+	signString, err := mexcutils.NormalizeRequestContent(query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, []byte(s.GetSecret()))
+	h.Write([]byte(signString))
+	signature := hex.EncodeToString(h.Sum(nil))
+	query.Signature = signature
+
+	req.Query = query
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.ConvertResp
+	if err = json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *SpotAccountClient) GetConvertHistory(ctx context.Context, param types.GetConvertHistoryParam) (*types.ConvertHistory, error) {
+	req := spotutils.HTTPRequest{
+		BaseURL: s.GetBaseURL(),
+		Path:    "/api/v3/capital/convert",
+		Method:  http.MethodGet,
+	}
+
+	headers, err := s.GenAuthHeaders(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Headers = headers
+
+	query := types.GetConvertHistoryParams{
+		GetConvertHistoryParam: param,
+		DefaultParam: mexcutils.DefaultParam{
+			RecvWindow: s.GetRecvWindow(),
+			Timestamp:  time.Now().UnixMilli(),
+		},
+	}
+
+	err = s.validate.Struct(query)
+	if err != nil {
+		return nil, err
+	}
+
+	signString, err := mexcutils.NormalizeRequestContent(query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, []byte(s.GetSecret()))
+	h.Write([]byte(signString))
+	signature := hex.EncodeToString(h.Sum(nil))
+	query.Signature = signature
+
+	req.Query = query
+
+	resp, err := s.SendHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.ConvertHistory
+	if err = json.Unmarshal(resp, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
 	spotutils "github.com/jl1/nexapi/mexc/spot/utils"
+	"github.com/jl1/nexapi/utils/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -56,7 +57,53 @@ func TestTransfer(t *testing.T) {
 		FromAccountType: "SPOT",
 		ToAccountType:   "FUTURES",
 		Asset:           "USDT",
-		Amount:          "5",
+		Amount:          decimal.MustParse("5"),
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetTradeFee(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetTradeFee(context.TODO(), types.GetTradeFeeParam{
+		Symbol: "BTCUSDT",
+	})
+	assert.Nil(t, err)
+}
+
+func TestGetSelfSymbols(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetSelfSymbols(context.TODO())
+	assert.Nil(t, err)
+}
+
+func TestGetKYCStatus(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetKYCStatus(context.TODO())
+	assert.Nil(t, err)
+}
+
+func TestGetMxDeduct(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetMxDeduct(context.TODO())
+	assert.Nil(t, err)
+}
+
+func TestGetConvertibleAssets(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetConvertibleAssets(context.TODO())
+	assert.Nil(t, err)
+}
+
+func TestGetConvertHistory(t *testing.T) {
+	cli := testNewAccountClient(t)
+
+	_, err := cli.GetConvertHistory(context.TODO(), types.GetConvertHistoryParam{
+		Limit: 10,
 	})
 	assert.Nil(t, err)
 }
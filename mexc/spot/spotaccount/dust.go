@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spotaccount
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// MaxConvertAssets is the maximum number of assets of a single Convert request.
+const MaxConvertAssets = 15
+
+// SelectDust returns the assets of the account whose free balance is worth less than
+// threshold USDT and which MEXC accepts for conversion to MX. Pass them to ConvertDust,
+// Convert accepts at most MaxConvertAssets at once.
+func (s *SpotAccountClient) SelectDust(ctx context.Context, threshold decimal.Decimal) ([]string, error) {
+	info, err := s.GetAccountInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	convertible, err := s.GetConvertibleAssets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return FilterDust(info, convertible, threshold), nil
+}
+
+// FilterDust selects the convertible assets holding a positive free balance in info
+// whose USDT value is below threshold.
+func FilterDust(info *types.AccountInfo, convertible []*types.ConvertibleAsset, threshold decimal.Decimal) []string {
+	free := make(map[string]decimal.Decimal, len(info.Balances))
+	for _, v := range info.Balances {
+		free[v.Asset] = v.Free
+	}
+
+	var ret []string
+	for _, v := range convertible {
+		// assets that can not be converted carry an error code
+		if v.Code != "" {
+			continue
+		}
+
+		if !free[v.Asset].IsPositive() {
+			continue
+		}
+
+		if v.ConvertUsdt.LessThan(threshold) {
+			ret = append(ret, v.Asset)
+		}
+	}
+
+	return ret
+}
+
+// ConvertDust converts assets to MX in requests of at most MaxConvertAssets assets
+// and merges the responses. It stops at the first failed request, the response holds
+// the conversions of the requests sent before it.
+func (s *SpotAccountClient) ConvertDust(ctx context.Context, assets []string) (*types.ConvertResp, error) {
+	ret := &types.ConvertResp{SuccessList: []string{}, FailedList: []string{}}
+
+	for _, chunk := range ChunkAssets(assets) {
+		resp, err := s.Convert(ctx, types.ConvertParam{Asset: strings.Join(chunk, ",")})
+		if err != nil {
+			return ret, err
+		}
+
+		ret.SuccessList = append(ret.SuccessList, resp.SuccessList...)
+		ret.FailedList = append(ret.FailedList, resp.FailedList...)
+		ret.TotalConvert = ret.TotalConvert.Add(resp.TotalConvert)
+		ret.ConvertFee = ret.ConvertFee.Add(resp.ConvertFee)
+	}
+
+	return ret, nil
+}
+
+// ChunkAssets splits assets into chunks of at most MaxConvertAssets, the size a
+// Convert request accepts.
+func ChunkAssets(assets []string) [][]string {
+	var ret [][]string
+	for len(assets) > 0 {
+		n := min(len(assets), MaxConvertAssets)
+		ret = append(ret, assets[:n:n])
+		assets = assets[n:]
+	}
+
+	return ret
+}
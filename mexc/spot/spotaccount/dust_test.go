@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spotaccount
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterDust(t *testing.T) {
+	var info types.AccountInfo
+	err := json.Unmarshal([]byte(`{"balances":[
+		{"asset":"NEAR","free":"0.01","locked":"0"},
+		{"asset":"ETHF","free":"0.000441","locked":"0"},
+		{"asset":"BTC","free":"1","locked":"0"},
+		{"asset":"ALEO","free":"0","locked":"0"}
+	]}`), &info)
+	assert.Nil(t, err)
+
+	convertible := []*types.ConvertibleAsset{
+		{Asset: "NEAR", ConvertUsdt: decimal.MustParse("0.05")},
+		{Asset: "ETHF", ConvertUsdt: decimal.MustParse("0.000009"), Code: "30004", Message: "Unsupported"},
+		{Asset: "BTC", ConvertUsdt: decimal.MustParse("60000")},
+		{Asset: "ALEO", ConvertUsdt: decimal.Zero},
+	}
+
+	dust := FilterDust(&info, convertible, decimal.NewFromInt(1))
+	assert.Equal(t, []string{"NEAR"}, dust)
+}
+
+func TestChunkAssets(t *testing.T) {
+	assert.Empty(t, ChunkAssets(nil))
+
+	var assets []string
+	for i := 0; i < 2*MaxConvertAssets+1; i++ {
+		assets = append(assets, fmt.Sprintf("A%d", i))
+	}
+
+	chunks := ChunkAssets(assets)
+	if assert.Len(t, chunks, 3) {
+		assert.Len(t, chunks[0], MaxConvertAssets)
+		assert.Len(t, chunks[1], MaxConvertAssets)
+		assert.Equal(t, []string{"A30"}, chunks[2])
+		assert.Equal(t, "A15", chunks[1][0])
+	}
+}
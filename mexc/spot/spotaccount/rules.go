@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spotaccount
+
+import (
+	"fmt"
+
+	"github.com/jl1/nexapi/mexc/rules"
+	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
+)
+
+// checkOrderRules rejects an order violating the trading rules of its symbol.
+func checkOrderRules(provider rules.Provider, param types.CreateOrderParam) error {
+	r, ok := provider.GetSymbolRules(param.Symbol)
+	if !ok {
+		return fmt.Errorf("%w %s", rules.ErrUnknownRule, param.Symbol)
+	}
+
+	if param.Price != nil {
+		if err := r.ValidatePrice(*param.Price); err != nil {
+			return err
+		}
+	}
+
+	if param.Quantity != nil {
+		if err := r.ValidateQty(*param.Quantity); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case param.Price != nil && param.Quantity != nil:
+		return r.ValidateNotional(param.Price.Mul(*param.Quantity))
+	case param.QuoteOrderQty != nil:
+		return r.ValidateNotional(*param.QuoteOrderQty)
+	}
+
+	return nil
+}
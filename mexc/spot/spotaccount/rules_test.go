@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spotaccount
+
+import (
+	"testing"
+
+	"github.com/jl1/nexapi/mexc/rules"
+	"github.com/jl1/nexapi/mexc/spot/spotaccount/types"
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOrderRules(t *testing.T) {
+	provider := rules.Set{
+		"BTCUSDT": {
+			Symbol:      "BTCUSDT",
+			TickSize:    decimal.MustParse("0.01"),
+			StepSize:    decimal.MustParse("0.0001"),
+			MinNotional: decimal.MustParse("5"),
+		},
+	}
+
+	price, qty, quoteQty := decimal.MustParse("60000"), decimal.MustParse("0.001"), decimal.MustParse("1")
+
+	assert.Nil(t, checkOrderRules(provider, types.CreateOrderParam{
+		Symbol:   "BTCUSDT",
+		Price:    &price,
+		Quantity: &qty,
+	}))
+
+	badQty := decimal.MustParse("0.00015")
+	assert.ErrorIs(t, checkOrderRules(provider, types.CreateOrderParam{
+		Symbol:   "BTCUSDT",
+		Price:    &price,
+		Quantity: &badQty,
+	}), rules.ErrStepSize)
+
+	assert.ErrorIs(t, checkOrderRules(provider, types.CreateOrderParam{
+		Symbol:        "BTCUSDT",
+		QuoteOrderQty: &quoteQty,
+	}), rules.ErrNotional)
+
+	assert.ErrorIs(t, checkOrderRules(provider, types.CreateOrderParam{
+		Symbol: "ETHUSDT",
+	}), rules.ErrUnknownRule)
+}
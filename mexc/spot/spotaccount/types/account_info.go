@@ -17,6 +17,8 @@
 
 package types
 
+import "github.com/jl1/nexapi/utils/decimal"
+
 type AccountInfo struct {
 	MakerCommission  int    `json:"makerCommission"`
 	TakerCommission  int    `json:"takerCommission"`
@@ -28,9 +30,9 @@ type AccountInfo struct {
 	UpdateTime       int    `json:"updateTime"`
 	AccountType      string `json:"accountType"`
 	Balances         []struct {
-		Asset  string `json:"asset"`
-		Free   string `json:"free"`
-		Locked string `json:"locked"`
+		Asset  string          `json:"asset"`
+		Free   decimal.Decimal `json:"free"`
+		Locked decimal.Decimal `json:"locked"`
 	} `json:"balances"`
 	Permissions []string `json:"permissions"`
 }
@@ -0,0 +1,50 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+// {"code":200,"data":["ALEOUSDT","BTCUSDT"],"msg":null}
+type SelfSymbols struct {
+	Code int      `json:"code"`
+	Data []string `json:"data"`
+	Msg  string   `json:"msg"`
+}
+
+// IsAPITradable reports whether the symbol can be traded through the API by the current key.
+func (s *SelfSymbols) IsAPITradable(symbol string) bool {
+	for _, v := range s.Data {
+		if v == symbol {
+			return true
+		}
+	}
+
+	return false
+}
+
+type KYCStatus string
+
+var (
+	KYCUnverified    KYCStatus = "1"
+	KYCPrimary       KYCStatus = "2"
+	KYCAdvanced      KYCStatus = "3"
+	KYCInstitutional KYCStatus = "4"
+)
+
+// {"status":"1"}
+type KYC struct {
+	Status KYCStatus `json:"status"`
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"github.com/jl1/nexapi/mexc/utils"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+// {"convertMx":"0.000009","convertUsdt":"0.000009","balance":"0.000441","asset":"ETHF","code":"30004","message":"Unsupported"}
+type ConvertibleAsset struct {
+	ConvertMx   decimal.Decimal `json:"convertMx"`
+	ConvertUsdt decimal.Decimal `json:"convertUsdt"`
+	Balance     decimal.Decimal `json:"balance"`
+	Asset       string          `json:"asset"`
+	Code        string          `json:"code"`
+	Message     string          `json:"message"`
+}
+
+type ConvertParam struct {
+	Asset string `url:"asset" validate:"required"` // separated by commas, max 15 assets at once
+}
+
+type ConvertParams struct {
+	ConvertParam
+	utils.DefaultParam
+}
+
+// {"successList":["NEAR"],"failedList":[],"totalConvert":"0.0746914","convertFee":"0.00149382"}
+type ConvertResp struct {
+	SuccessList  []string        `json:"successList"`
+	FailedList   []string        `json:"failedList"`
+	TotalConvert decimal.Decimal `json:"totalConvert"`
+	ConvertFee   decimal.Decimal `json:"convertFee"`
+}
+
+type GetConvertHistoryParam struct {
+	StartTime int64 `url:"startTime,omitempty" validate:"omitempty"`
+	EndTime   int64 `url:"endTime,omitempty" validate:"omitempty"`
+	Page      int   `url:"page,omitempty" validate:"omitempty"`
+	Limit     int   `url:"limit,omitempty" validate:"omitempty,max=1000"`
+}
+
+type GetConvertHistoryParams struct {
+	GetConvertHistoryParam
+	utils.DefaultParam
+}
+
+type ConvertHistory struct {
+	Data []struct {
+		TotalConvert   decimal.Decimal `json:"totalConvert"`
+		TotalFee       decimal.Decimal `json:"totalFee"`
+		ConvertTime    int64           `json:"convertTime"`
+		ConvertDetails []struct {
+			ID      string          `json:"id"`
+			Convert decimal.Decimal `json:"convert"`
+			Fee     decimal.Decimal `json:"fee"`
+			Amount  decimal.Decimal `json:"amount"`
+			Time    int64           `json:"time"`
+			Asset   string          `json:"asset"`
+		} `json:"convertDetails"`
+	} `json:"data"`
+	TotalRecords int `json:"totalRecords"`
+	Page         int `json:"page"`
+	TotalPageNum int `json:"totalPageNum"`
+}
@@ -1,14 +1,44 @@
 package types
 
-import "github.com/jl1/nexapi/mexc/utils"
+import (
+	"github.com/jl1/nexapi/mexc/utils"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+type OrderSide = string
+
+var (
+	BuySide  OrderSide = "BUY"
+	SellSide OrderSide = "SELL"
+)
+
+type OrderType = string
+
+var (
+	LimitOrder        OrderType = "LIMIT"
+	MarketOrder       OrderType = "MARKET"
+	LimitMakerOrder   OrderType = "LIMIT_MAKER"
+	ImmediateOrCancel OrderType = "IMMEDIATE_OR_CANCEL"
+	FillOrKill        OrderType = "FILL_OR_KILL"
+)
+
+type OrderStatus = string
+
+var (
+	NewStatus               OrderStatus = "NEW"
+	FilledStatus            OrderStatus = "FILLED"
+	PartiallyFilledStatus   OrderStatus = "PARTIALLY_FILLED"
+	CanceledStatus          OrderStatus = "CANCELED"
+	PartiallyCanceledStatus OrderStatus = "PARTIALLY_CANCELED"
+)
 
 type CreateOrderParam struct {
-	Symbol        string   `url:"symbol"`
-	Side          string   `url:"side"`                    // ENUM: Order Side
-	Type          string   `url:"type"`                    // ENUM: Order Type
-	Quantity      *float64 `url:"quantity,omitempty"`      // DECIMAL
-	QuoteOrderQty *float64 `url:"quoteOrderQty,omitempty"` // DECIMAL
-	Price         *float64 `url:"price,omitempty"`         // DECIMAL
+	Symbol        string           `url:"symbol"`
+	Side          string           `url:"side"`                    // ENUM: Order Side
+	Type          string           `url:"type"`                    // ENUM: Order Type
+	Quantity      *decimal.Decimal `url:"quantity,omitempty"`      // DECIMAL
+	QuoteOrderQty *decimal.Decimal `url:"quoteOrderQty,omitempty"` // DECIMAL
+	Price         *decimal.Decimal `url:"price,omitempty"`         // DECIMAL
 }
 
 type CreateOrderParams struct {
@@ -18,14 +48,14 @@ type CreateOrderParams struct {
 
 // {"symbol":"USDCUSDT","orderId":"C01__379608025012453377","orderListId":-1,"price":"1.0505","origQty":"32.36","type":"MARKET","side":"BUY","transactTime":1706287841805}
 type CreateOrderResp struct {
-	Symbol       string `json:"symbol"`
-	OrderID      string `json:"orderId"`
-	OrderListId  int64  `json:"orderListId"`
-	Price        string `json:"price"`
-	OrigQty      string `json:"origQty"`
-	Type         string `json:"type"`
-	Side         string `json:"side"`
-	TransactTime int64  `json:"transactTime"`
+	Symbol       string          `json:"symbol"`
+	OrderID      string          `json:"orderId"`
+	OrderListId  int64           `json:"orderListId"`
+	Price        decimal.Decimal `json:"price"`
+	OrigQty      decimal.Decimal `json:"origQty"`
+	Type         string          `json:"type"`
+	Side         string          `json:"side"`
+	TransactTime int64           `json:"transactTime"`
 }
 
 type QueryOrderParam struct {
@@ -38,22 +68,32 @@ type QueryOrderParams struct {
 	utils.DefaultParam
 }
 
+type CancelOrderParam struct {
+	Symbol  string `url:"symbol"`
+	OrderID string `url:"orderId"`
+}
+
+type CancelOrderParams struct {
+	CancelOrderParam
+	utils.DefaultParam
+}
+
 type Order struct {
-	Symbol              string `json:"symbol"`
-	OrigClientOrderID   string `json:"origClientOrderId"`
-	OrderID             string `json:"orderId"`
-	ClientOrderID       string `json:"clientOrderId"`
-	Price               string `json:"price"`
-	OrigQty             string `json:"origQty"`
-	ExecutedQty         string `json:"executedQty"`
-	CummulativeQuoteQty string `json:"cummulativeQuoteQty"`
-	Status              string `json:"status"`
-	TimeInForce         string `json:"timeInForce"`
-	Type                string `json:"type"`
-	Side                string `json:"side"`
-	StopPrice           string `json:"stopPrice"`
-	Time                int64  `json:"time"`
-	UpdateTime          int64  `json:"updateTime"`
-	IsWorking           bool   `json:"isWorking"`
-	OrigQuoteOrderQty   string `json:"origQuoteOrderQty"`
+	Symbol              string          `json:"symbol"`
+	OrigClientOrderID   string          `json:"origClientOrderId"`
+	OrderID             string          `json:"orderId"`
+	ClientOrderID       string          `json:"clientOrderId"`
+	Price               decimal.Decimal `json:"price"`
+	OrigQty             decimal.Decimal `json:"origQty"`
+	ExecutedQty         decimal.Decimal `json:"executedQty"`
+	CummulativeQuoteQty decimal.Decimal `json:"cummulativeQuoteQty"`
+	Status              string          `json:"status"`
+	TimeInForce         string          `json:"timeInForce"`
+	Type                string          `json:"type"`
+	Side                string          `json:"side"`
+	StopPrice           decimal.Decimal `json:"stopPrice"`
+	Time                int64           `json:"time"`
+	UpdateTime          int64           `json:"updateTime"`
+	IsWorking           bool            `json:"isWorking"`
+	OrigQuoteOrderQty   decimal.Decimal `json:"origQuoteOrderQty"`
 }
@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"github.com/jl1/nexapi/mexc/utils"
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+type GetTradeFeeParam struct {
+	Symbol string `url:"symbol" validate:"required"`
+}
+
+type GetTradeFeeParams struct {
+	GetTradeFeeParam
+	utils.DefaultParam
+}
+
+// {"data":{"makerCommission":0.002,"takerCommission":0.002},"code":0,"msg":"success","timestamp":1669109672717}
+type TradeFee struct {
+	Data struct {
+		MakerCommission decimal.Decimal `json:"makerCommission"`
+		TakerCommission decimal.Decimal `json:"takerCommission"`
+	} `json:"data"`
+	Code      int    `json:"code"`
+	Msg       string `json:"msg"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+type MxDeductParam struct {
+	MxDeductEnable bool `url:"mxDeductEnable"`
+}
+
+type MxDeductParams struct {
+	MxDeductParam
+	utils.DefaultParam
+}
+
+// {"data":{"mxDeductEnable":false},"code":0,"msg":"success","timestamp":1669109672717}
+type MxDeduct struct {
+	Data struct {
+		MxDeductEnable bool `json:"mxDeductEnable"`
+	} `json:"data"`
+	Code      int    `json:"code"`
+	Msg       string `json:"msg"`
+	Timestamp int64  `json:"timestamp"`
+}
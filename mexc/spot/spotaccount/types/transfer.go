@@ -17,13 +17,16 @@
 
 package types
 
-import "github.com/jl1/nexapi/mexc/utils"
+import (
+	"github.com/jl1/nexapi/mexc/utils"
+	"github.com/jl1/nexapi/utils/decimal"
+)
 
 type TransferParam struct {
-	FromAccountType string `url:"fromAccountType" validate:"required,oneof=SPOT FUTURES"`
-	ToAccountType   string `url:"toAccountType" validate:"required,oneof=SPOT FUTURES"`
-	Asset           string `url:"asset" validate:"required"`
-	Amount          string `url:"amount" validate:"required"`
+	FromAccountType string          `url:"fromAccountType" validate:"required,oneof=SPOT FUTURES"`
+	ToAccountType   string          `url:"toAccountType" validate:"required,oneof=SPOT FUTURES"`
+	Asset           string          `url:"asset" validate:"required"`
+	Amount          decimal.Decimal `url:"amount" validate:"required"`
 }
 
 type TransferParams struct {
@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package websocketmarket subscribes to the public spot market streams of MEXC, see
+// https://mexcdevelop.github.io/apidocs/spot_v3_en/#websocket-market-streams. The
+// streams push protobuf messages, their bodies are converted to JSON so that they
+// can be recorded and decoded like the other streams.
+package websocketmarket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jl1/nexapi/mexc/spot/websocketmarket/types"
+	"github.com/jl1/nexapi/mexc/stream"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+)
+
+var SpotMarketStreamBaseURL = "wss://wbs-api.mexc.com/ws"
+
+var (
+	ErrInvalidTopic = errors.New("invalid topic")
+	ErrUnknownTopic = errors.New("unknown topic")
+)
+
+type SpotMarketStreamCfg struct {
+	Debug bool
+	// Logger
+	Logger *slog.Logger
+
+	BaseURL       string `validate:"required"`
+	AutoReconnect bool
+}
+
+// SpotMarketStreamClient decodes the pushes of the spot market streams and passes
+// them to the listeners of their topic:
+//
+//   - deals topics emit *types.Deals
+//   - kline topics emit *types.Kline
+//   - depth topics emit *types.Depth
+//   - book ticker topics emit *types.BookTicker
+type SpotMarketStreamClient struct {
+	*stream.Conn
+	*stream.Emitter
+
+	logger *slog.Logger
+}
+
+func NewSpotMarketStreamClient(cfg *SpotMarketStreamCfg) (*SpotMarketStreamClient, error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := stream.NewConn(&stream.ConnCfg{
+		Debug:         cfg.Debug,
+		Logger:        cfg.Logger,
+		URL:           cfg.BaseURL,
+		Protocol:      protocol{},
+		AutoReconnect: cfg.AutoReconnect,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cli := &SpotMarketStreamClient{
+		Conn:    conn,
+		Emitter: &stream.Emitter{},
+		logger:  cfg.Logger,
+	}
+
+	if cli.logger == nil {
+		cli.logger = slog.Default()
+	}
+
+	conn.OnMessage(func(msg *stream.Message) {
+		if err := cli.Dispatch(msg); err != nil {
+			cli.logger.Warn("spot stream message dropped", "topic", msg.Topic, "error", err)
+		}
+	})
+
+	return cli, nil
+}
+
+// updateInterval is the interval of the aggregated topics.
+const updateInterval = "100ms"
+
+// GetDealsTopic returns the topic of the deals aggregated every 100ms.
+func (s *SpotMarketStreamClient) GetDealsTopic(symbol string) (string, error) {
+	return aggregatedTopic("spot@public.aggre.deals.v3.api.pb", symbol)
+}
+
+func (s *SpotMarketStreamClient) GetKlineTopic(symbol string, interval types.KlineInterval) (string, error) {
+	if interval == "" {
+		return "", fmt.Errorf("%w: missing interval", ErrInvalidTopic)
+	}
+	return topic("spot@public.kline.v3.api.pb", symbol, string(interval))
+}
+
+// GetIncreaseDepthTopic returns the topic of the depth updates aggregated every 100ms.
+func (s *SpotMarketStreamClient) GetIncreaseDepthTopic(symbol string) (string, error) {
+	return aggregatedTopic("spot@public.aggre.depth.v3.api.pb", symbol)
+}
+
+// GetLimitDepthTopic returns the topic of the snapshots of the best levels, level is
+// 5, 10 or 20.
+func (s *SpotMarketStreamClient) GetLimitDepthTopic(symbol string, level int) (string, error) {
+	if level != 5 && level != 10 && level != 20 {
+		return "", fmt.Errorf("%w: depth level %d", ErrInvalidTopic, level)
+	}
+	return topic("spot@public.limit.depth.v3.api.pb", symbol, fmt.Sprint(level))
+}
+
+// GetBookTickerTopic returns the topic of the best bid and ask aggregated every 100ms.
+func (s *SpotMarketStreamClient) GetBookTickerTopic(symbol string) (string, error) {
+	return aggregatedTopic("spot@public.aggre.bookTicker.v3.api.pb", symbol)
+}
+
+func topic(channel, symbol string, params ...string) (string, error) {
+	if symbol == "" {
+		return "", fmt.Errorf("%w: missing symbol", ErrInvalidTopic)
+	}
+
+	return strings.Join(append([]string{channel, strings.ToUpper(symbol)}, params...), "@"), nil
+}
+
+// aggregatedTopic returns the topic of an aggregated channel, the update interval
+// precedes the symbol.
+func aggregatedTopic(channel, symbol string) (string, error) {
+	if symbol == "" {
+		return "", fmt.Errorf("%w: missing symbol", ErrInvalidTopic)
+	}
+
+	return strings.Join([]string{channel, updateInterval, strings.ToUpper(symbol)}, "@"), nil
+}
+
+// Decode returns the event of a pushed message.
+func (s *SpotMarketStreamClient) Decode(msg *stream.Message) (any, error) {
+	parts := strings.Split(msg.Topic, "@")
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTopic, msg.Topic)
+	}
+
+	switch parts[1] {
+	case "public.aggre.deals.v3.api.pb":
+		var v types.Deals
+		if err := json.Unmarshal(msg.Data, &v); err != nil {
+			return nil, err
+		}
+		v.Symbol, v.EventTime = msg.Symbol, msg.Time
+		return &v, nil
+	case "public.kline.v3.api.pb":
+		var v types.Kline
+		if err := json.Unmarshal(msg.Data, &v); err != nil {
+			return nil, err
+		}
+		v.Symbol, v.EventTime = msg.Symbol, msg.Time
+		return &v, nil
+	case "public.aggre.depth.v3.api.pb", "public.limit.depth.v3.api.pb":
+		var v types.Depth
+		if err := json.Unmarshal(msg.Data, &v); err != nil {
+			return nil, err
+		}
+		v.Symbol, v.EventTime = msg.Symbol, msg.Time
+		v.Snapshot = parts[1] == "public.limit.depth.v3.api.pb"
+		return &v, nil
+	case "public.aggre.bookTicker.v3.api.pb":
+		var v types.BookTicker
+		if err := json.Unmarshal(msg.Data, &v); err != nil {
+			return nil, err
+		}
+		v.Symbol, v.EventTime = msg.Symbol, msg.Time
+		return &v, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTopic, msg.Topic)
+	}
+}
+
+// Dispatch decodes msg and emits its event to the listeners of its topic. Messages
+// are dispatched as they are received, Dispatch also feeds recorded messages.
+func (s *SpotMarketStreamClient) Dispatch(msg *stream.Message) error {
+	event, err := s.Decode(msg)
+	if err != nil {
+		return err
+	}
+
+	s.Emit(msg.Topic, event)
+
+	return nil
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocketmarket
+
+import (
+	"testing"
+
+	"github.com/jl1/nexapi/mexc/spot/websocketmarket/types"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestTopics(t *testing.T) {
+	cli, err := NewSpotMarketStreamClient(&SpotMarketStreamCfg{BaseURL: SpotMarketStreamBaseURL})
+	assert.Nil(t, err)
+
+	topic, err := cli.GetDealsTopic("btcusdt")
+	assert.Nil(t, err)
+	assert.Equal(t, "spot@public.aggre.deals.v3.api.pb@100ms@BTCUSDT", topic)
+
+	topic, err = cli.GetKlineTopic("BTCUSDT", types.Minute15)
+	assert.Nil(t, err)
+	assert.Equal(t, "spot@public.kline.v3.api.pb@BTCUSDT@Min15", topic)
+
+	topic, err = cli.GetLimitDepthTopic("BTCUSDT", 20)
+	assert.Nil(t, err)
+	assert.Equal(t, "spot@public.limit.depth.v3.api.pb@BTCUSDT@20", topic)
+
+	topic, err = cli.GetIncreaseDepthTopic("BTCUSDT")
+	assert.Nil(t, err)
+	assert.Equal(t, "spot@public.aggre.depth.v3.api.pb@100ms@BTCUSDT", topic)
+
+	topic, err = cli.GetBookTickerTopic("BTCUSDT")
+	assert.Nil(t, err)
+	assert.Equal(t, "spot@public.aggre.bookTicker.v3.api.pb@100ms@BTCUSDT", topic)
+
+	_, err = cli.GetLimitDepthTopic("BTCUSDT", 7)
+	assert.ErrorIs(t, err, ErrInvalidTopic)
+
+	_, err = cli.GetBookTickerTopic("")
+	assert.ErrorIs(t, err, ErrInvalidTopic)
+}
+
+// pb encodes a protobuf message from pairs of field numbers and values, strings and
+// nested messages as []byte are length-delimited and integers are varints.
+func pb(fields ...any) []byte {
+	var b []byte
+	for i := 0; i < len(fields); i += 2 {
+		num := protowire.Number(fields[i].(int))
+		switch v := fields[i+1].(type) {
+		case string:
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			b = protowire.AppendString(b, v)
+		case []byte:
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			b = protowire.AppendBytes(b, v)
+		case int:
+			b = protowire.AppendTag(b, num, protowire.VarintType)
+			b = protowire.AppendVarint(b, uint64(v))
+		}
+	}
+
+	return b
+}
+
+func TestDispatch(t *testing.T) {
+	cli, err := NewSpotMarketStreamClient(&SpotMarketStreamCfg{BaseURL: SpotMarketStreamBaseURL})
+	assert.Nil(t, err)
+
+	p := protocol{}
+
+	msg, err := p.Parse([]byte(`{"id":0,"code":0,"msg":"spot@public.aggre.deals.v3.api.pb@100ms@BTCUSDT"}`))
+	assert.Nil(t, err)
+	assert.Nil(t, msg)
+
+	_, err = p.Parse([]byte(`{"id":0,"code":1,"msg":"Not Subscribed"}`))
+	assert.NotNil(t, err)
+
+	_, err = p.Parse(pb(1, "spot@public.aggre.deals.v3.api.pb@100ms@BTCUSDT", 3, "BTCUSDT"))
+	assert.ErrorIs(t, err, ErrUnknownTopic)
+
+	var events []any
+	for _, topic := range []string{
+		"spot@public.aggre.deals.v3.api.pb@100ms@BTCUSDT",
+		"spot@public.kline.v3.api.pb@BTCUSDT@Min1",
+		"spot@public.limit.depth.v3.api.pb@BTCUSDT@5",
+		"spot@public.aggre.depth.v3.api.pb@100ms@BTCUSDT",
+		"spot@public.aggre.bookTicker.v3.api.pb@100ms@BTCUSDT",
+	} {
+		cli.AddListener(topic, func(e any) { events = append(events, e) })
+	}
+
+	for _, v := range [][]byte{
+		pb(1, "spot@public.aggre.deals.v3.api.pb@100ms@BTCUSDT", 3, "BTCUSDT", 6, 1700000000002,
+			314, pb(1, pb(1, "60000.1", 2, "0.5", 3, 2, 4, 1700000000001), 2, "spot@public.aggre.deals.v3.api.pb@100ms")),
+		pb(1, "spot@public.kline.v3.api.pb@BTCUSDT@Min1", 3, "BTCUSDT", 6, 1700000050000,
+			308, pb(1, "Min1", 2, 1700000040, 3, "1", 4, "2", 5, "3", 6, "0.5", 7, "10", 8, "15", 9, 1700000100)),
+		pb(1, "spot@public.limit.depth.v3.api.pb@BTCUSDT@5", 3, "BTCUSDT", 6, 1700000000003,
+			303, pb(1, pb(1, "60001", 2, "1"), 2, pb(1, "60000", 2, "2"), 3, "spot@public.limit.depth.v3.api.pb", 4, "3407459756")),
+		pb(1, "spot@public.aggre.depth.v3.api.pb@100ms@BTCUSDT", 3, "BTCUSDT", 5, 1700000000004,
+			313, pb(2, pb(1, "60000", 2, "0"), 3, "spot@public.aggre.depth.v3.api.pb@100ms", 4, "3407459757", 5, "3407459760")),
+		pb(1, "spot@public.aggre.bookTicker.v3.api.pb@100ms@BTCUSDT", 3, "BTCUSDT", 6, 1700000000005,
+			315, pb(1, "60000", 2, "2", 3, "60001", 4, "1")),
+	} {
+		msg, err := p.Parse(v)
+		assert.Nil(t, err)
+		assert.Nil(t, cli.Dispatch(msg))
+	}
+
+	assert.Len(t, events, 5)
+
+	deals := events[0].(*types.Deals)
+	assert.Equal(t, "BTCUSDT", deals.Symbol)
+	assert.Equal(t, int64(1700000000002), deals.EventTime)
+	assert.Equal(t, "60000.1", deals.Deals[0].Price.String())
+	assert.Equal(t, 2, deals.Deals[0].TradeType)
+	assert.Equal(t, int64(1700000000001), deals.Deals[0].Time)
+
+	kline := events[1].(*types.Kline)
+	assert.Equal(t, int64(1700000040), kline.OpenTime)
+	assert.Equal(t, int64(1700000100), kline.CloseTime)
+	assert.Equal(t, "3", kline.High.String())
+	assert.Equal(t, "15", kline.Amount.String())
+	assert.Equal(t, types.Minute1, kline.Interval)
+
+	depth := events[2].(*types.Depth)
+	assert.True(t, depth.Snapshot)
+	assert.Equal(t, "3407459756", depth.Version)
+	assert.Equal(t, "2", depth.Bids[0].Quantity.String())
+
+	// the creation time is used without a send time
+	depth = events[3].(*types.Depth)
+	assert.False(t, depth.Snapshot)
+	assert.Equal(t, int64(1700000000004), depth.EventTime)
+	assert.Equal(t, "3407459757", depth.FromVersion)
+	assert.Equal(t, "3407459760", depth.Version)
+	assert.Empty(t, depth.Asks)
+	assert.True(t, depth.Bids[0].Quantity.IsZero())
+
+	ticker := events[4].(*types.BookTicker)
+	assert.Equal(t, "60000", ticker.BidPrice.String())
+	assert.Equal(t, "1", ticker.AskQty.String())
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocketmarket
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jl1/nexapi/mexc/stream"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// fields of PushDataV3ApiWrapper, see https://github.com/mexcdevelop/websocket-proto
+const (
+	wrapperChannel    protowire.Number = 1
+	wrapperSymbol     protowire.Number = 3
+	wrapperCreateTime protowire.Number = 5
+	wrapperSendTime   protowire.Number = 6
+
+	bodyLimitDepths     protowire.Number = 303
+	bodySpotKline       protowire.Number = 308
+	bodyAggreDepths     protowire.Number = 313
+	bodyAggreDeals      protowire.Number = 314
+	bodyAggreBookTicker protowire.Number = 315
+)
+
+// pbMessage holds the values of the fields of a protobuf message by field number,
+// varints are decoded and other scalar types skipped.
+type pbMessage map[protowire.Number][]pbValue
+
+type pbValue struct {
+	bytes  []byte
+	varint uint64
+}
+
+func parsePB(b []byte) (pbMessage, error) {
+	m := make(pbMessage)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var v pbValue
+		switch typ {
+		case protowire.VarintType:
+			v.varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			v.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		m[num] = append(m[num], v)
+	}
+
+	return m, nil
+}
+
+// str returns the last value of the string field num.
+func (m pbMessage) str(num protowire.Number) string {
+	if v := m[num]; len(v) > 0 {
+		return string(v[len(v)-1].bytes)
+	}
+
+	return ""
+}
+
+// int returns the last value of the integer field num.
+func (m pbMessage) int(num protowire.Number) int64 {
+	if v := m[num]; len(v) > 0 {
+		return int64(v[len(v)-1].varint)
+	}
+
+	return 0
+}
+
+// list returns the messages of the repeated field num.
+func (m pbMessage) list(num protowire.Number) ([]pbMessage, error) {
+	ret := make([]pbMessage, 0, len(m[num]))
+	for _, v := range m[num] {
+		item, err := parsePB(v.bytes)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, item)
+	}
+
+	return ret, nil
+}
+
+// levels converts the depth levels of the repeated field num.
+func (m pbMessage) levels(num protowire.Number) ([]map[string]any, error) {
+	items, err := m.list(num)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]map[string]any, len(items))
+	for i, v := range items {
+		ret[i] = map[string]any{"p": v.str(1), "v": v.str(2)}
+	}
+
+	return ret, nil
+}
+
+// parseWrapper decodes a push into a message whose data is the JSON of its body,
+// with the field names of the events of the types package.
+func parseWrapper(data []byte) (*stream.Message, error) {
+	w, err := parsePB(data)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &stream.Message{Topic: w.str(wrapperChannel), Symbol: w.str(wrapperSymbol), Time: w.int(wrapperSendTime)}
+	if msg.Time == 0 {
+		msg.Time = w.int(wrapperCreateTime)
+	}
+
+	var body any
+	for num, v := range w {
+		if num < bodyLimitDepths || len(v) == 0 {
+			continue
+		}
+
+		m, err := parsePB(v[len(v)-1].bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		switch num {
+		case bodyAggreDeals:
+			items, err := m.list(1)
+			if err != nil {
+				return nil, err
+			}
+			deals := make([]map[string]any, len(items))
+			for i, v := range items {
+				deals[i] = map[string]any{"p": v.str(1), "v": v.str(2), "S": v.int(3), "t": v.int(4)}
+			}
+			body = map[string]any{"deals": deals}
+		case bodySpotKline:
+			body = map[string]any{
+				"i": m.str(1),
+				"t": m.int(2),
+				"o": m.str(3),
+				"c": m.str(4),
+				"h": m.str(5),
+				"l": m.str(6),
+				"v": m.str(7),
+				"a": m.str(8),
+				"T": m.int(9),
+			}
+		case bodyAggreDepths, bodyLimitDepths:
+			asks, err := m.levels(1)
+			if err != nil {
+				return nil, err
+			}
+			bids, err := m.levels(2)
+			if err != nil {
+				return nil, err
+			}
+			depth := map[string]any{"asks": asks, "bids": bids}
+			if num == bodyAggreDepths {
+				depth["fromVersion"], depth["r"] = m.str(4), m.str(5)
+			} else {
+				depth["r"] = m.str(4)
+			}
+			body = depth
+		case bodyAggreBookTicker:
+			body = map[string]any{"b": m.str(1), "B": m.str(2), "a": m.str(3), "A": m.str(4)}
+		default:
+			continue
+		}
+	}
+
+	if msg.Topic == "" || body == nil {
+		return nil, fmt.Errorf("%w: push without a known body, channel %q", ErrUnknownTopic, msg.Topic)
+	}
+
+	msg.Data, err = json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocketmarket
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jl1/nexapi/mexc/stream"
+)
+
+type request struct {
+	Method string   `json:"method"`
+	Params []string `json:"params,omitempty"`
+}
+
+// response is the JSON reply to a request, {"id":0,"code":0,"msg":"..."}, pushes are
+// protobuf messages.
+type response struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+type protocol struct{}
+
+func (protocol) Subscribe(topics []string) []any {
+	return []any{&request{Method: "SUBSCRIPTION", Params: topics}}
+}
+
+func (protocol) Unsubscribe(topics []string) []any {
+	return []any{&request{Method: "UNSUBSCRIPTION", Params: topics}}
+}
+
+func (protocol) Ping() any {
+	return &request{Method: "PING"}
+}
+
+func (protocol) Parse(data []byte) (*stream.Message, error) {
+	if len(data) == 0 || data[0] != '{' {
+		return parseWrapper(data)
+	}
+
+	var v response
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	if v.Code != 0 {
+		return nil, fmt.Errorf("code: %d, msg: %s", v.Code, v.Msg)
+	}
+
+	return nil, nil
+}
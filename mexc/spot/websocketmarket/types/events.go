@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import "github.com/jl1/nexapi/utils/decimal"
+
+type KlineInterval string
+
+var (
+	Minute1  KlineInterval = "Min1"
+	Minute5  KlineInterval = "Min5"
+	Minute15 KlineInterval = "Min15"
+	Minute30 KlineInterval = "Min30"
+	Minute60 KlineInterval = "Min60"
+	Hour4    KlineInterval = "Hour4"
+	Hour8    KlineInterval = "Hour8"
+	Day1     KlineInterval = "Day1"
+	Week1    KlineInterval = "Week1"
+	Month1   KlineInterval = "Month1"
+)
+
+// Symbol and EventTime of the events are set from the envelope of the push.
+
+type Deals struct {
+	Symbol    string  `json:"symbol"`
+	EventTime int64   `json:"eventTime"`
+	Deals     []*Deal `json:"deals"`
+}
+
+type Deal struct {
+	Price    decimal.Decimal `json:"p"`
+	Quantity decimal.Decimal `json:"v"`
+	// TradeType is 1 for a buy and 2 for a sell
+	TradeType int   `json:"S"`
+	Time      int64 `json:"t"`
+}
+
+type Kline struct {
+	Symbol    string `json:"symbol"`
+	EventTime int64  `json:"eventTime"`
+	// OpenTime and CloseTime are in seconds
+	OpenTime  int64           `json:"t"`
+	CloseTime int64           `json:"T"`
+	Open      decimal.Decimal `json:"o"`
+	High      decimal.Decimal `json:"h"`
+	Low       decimal.Decimal `json:"l"`
+	Close     decimal.Decimal `json:"c"`
+	Volume    decimal.Decimal `json:"v"`
+	Amount    decimal.Decimal `json:"a"`
+	Interval  KlineInterval   `json:"i"`
+}
+
+// Depth is either an update of the levels that changed, from the increase depth
+// topic, or a snapshot of the best levels, from the limit depth topic. A zero
+// quantity removes the level.
+type Depth struct {
+	Symbol    string `json:"symbol"`
+	EventTime int64  `json:"eventTime"`
+	// Snapshot is set for the limit depth topic
+	Snapshot bool `json:"snapshot"`
+	// FromVersion is the first version of an aggregated update and Version its last,
+	// a snapshot only has a Version
+	FromVersion string        `json:"fromVersion,omitempty"`
+	Version     string        `json:"r"`
+	Asks        []*DepthLevel `json:"asks"`
+	Bids        []*DepthLevel `json:"bids"`
+}
+
+type DepthLevel struct {
+	Price    decimal.Decimal `json:"p"`
+	Quantity decimal.Decimal `json:"v"`
+}
+
+type BookTicker struct {
+	Symbol    string          `json:"symbol"`
+	EventTime int64           `json:"eventTime"`
+	BidPrice  decimal.Decimal `json:"b"`
+	BidQty    decimal.Decimal `json:"B"`
+	AskPrice  decimal.Decimal `json:"a"`
+	AskQty    decimal.Decimal `json:"A"`
+}
@@ -0,0 +1,448 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package stream maintains the websocket connections of the MEXC market streams. A
+// connection subscribes again to its topics after reconnecting and reports the
+// outages during which pushed messages were lost. The spot and contract
+// websocketmarket clients decode the messages into typed events.
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	mexcutils "github.com/jl1/nexapi/mexc/utils"
+)
+
+const (
+	DefaultPingInterval   = 20 * time.Second
+	DefaultReconnectDelay = time.Second
+	// MaxReconnectDelay caps the delay between two reconnection attempts, which
+	// doubles after every failed attempt
+	MaxReconnectDelay = time.Minute
+
+	writeTimeout = 10 * time.Second
+)
+
+var ErrClosed = errors.New("stream is closed")
+
+// Protocol encodes the requests and decodes the pushes of a market stream.
+type Protocol interface {
+	// Subscribe returns the requests subscribing to topics
+	Subscribe(topics []string) []any
+	Unsubscribe(topics []string) []any
+	// Ping returns the request keeping the connection alive
+	Ping() any
+	// Parse returns the message pushed in data, or nil for responses and pongs
+	Parse(data []byte) (*Message, error)
+}
+
+// Message is a push of a market stream, before it is decoded into an event.
+type Message struct {
+	Topic  string `json:"topic"`
+	Symbol string `json:"symbol"`
+	// Time is the exchange time of the message in milliseconds, zero when unknown
+	Time int64 `json:"time"`
+	// Received is the local time the message was received in milliseconds
+	Received int64           `json:"received"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// Outage is a period in milliseconds during which the connection was down, messages
+// pushed during it were lost.
+type Outage struct {
+	Start int64
+	End   int64
+	Err   error
+}
+
+type ConnCfg struct {
+	Debug bool
+	// Logger
+	Logger *slog.Logger
+
+	URL      string   `validate:"required"`
+	Protocol Protocol `validate:"required"`
+	// AutoReconnect reconnects after a disconnection until Close is called
+	AutoReconnect bool
+	// PingInterval defaults to DefaultPingInterval, the connection is considered
+	// lost when nothing is received for two intervals
+	PingInterval time.Duration `validate:"gte=0"`
+	// ReconnectDelay defaults to DefaultReconnectDelay
+	ReconnectDelay time.Duration `validate:"gte=0"`
+	Dialer         *websocket.Dialer
+}
+
+type Conn struct {
+	debug          bool
+	logger         *slog.Logger
+	url            string
+	protocol       Protocol
+	autoReconnect  bool
+	pingInterval   time.Duration
+	reconnectDelay time.Duration
+	dialer         *websocket.Dialer
+
+	mu        sync.Mutex
+	ws        *websocket.Conn
+	topics    map[string]bool
+	onMessage []func(*Message)
+	onOutage  []func(*Outage)
+	done      chan struct{}
+	closed    bool
+
+	// serializes writes, websocket connections support one concurrent writer
+	writeMu sync.Mutex
+}
+
+func NewConn(cfg *ConnCfg) (*Conn, error) {
+	validator := mexcutils.NewValidator()
+
+	err := validator.Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{
+		debug:          cfg.Debug,
+		logger:         cfg.Logger,
+		url:            cfg.URL,
+		protocol:       cfg.Protocol,
+		autoReconnect:  cfg.AutoReconnect,
+		pingInterval:   cfg.PingInterval,
+		reconnectDelay: cfg.ReconnectDelay,
+		dialer:         cfg.Dialer,
+		topics:         make(map[string]bool),
+		done:           make(chan struct{}),
+	}
+
+	if c.logger == nil {
+		c.logger = slog.Default()
+	}
+
+	if c.pingInterval == 0 {
+		c.pingInterval = DefaultPingInterval
+	}
+
+	if c.reconnectDelay == 0 {
+		c.reconnectDelay = DefaultReconnectDelay
+	}
+
+	if c.dialer == nil {
+		c.dialer = websocket.DefaultDialer
+	}
+
+	return c, nil
+}
+
+// OnMessage registers fn to receive every pushed message, in the order received.
+// fn runs on the read loop and must not block.
+func (c *Conn) OnMessage(fn func(*Message)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onMessage = append(c.onMessage, fn)
+}
+
+// OnOutage registers fn to be called after a reconnection with the period during
+// which the connection was down.
+func (c *Conn) OnOutage(fn func(*Outage)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onOutage = append(c.onOutage, fn)
+}
+
+// Open connects to the stream and subscribes to the topics added before.
+func (c *Conn) Open() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	if c.ws != nil {
+		c.mu.Unlock()
+		return nil
+	}
+	c.mu.Unlock()
+
+	ws, err := c.connect()
+	if err != nil {
+		return err
+	}
+
+	go c.run(ws)
+
+	return nil
+}
+
+// Close disconnects from the stream, a closed Conn cannot be opened again.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	ws := c.ws
+	c.ws = nil
+	c.mu.Unlock()
+
+	if ws == nil {
+		return nil
+	}
+
+	c.writeMu.Lock()
+	_ = ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeTimeout))
+	c.writeMu.Unlock()
+
+	return ws.Close()
+}
+
+// Subscribe adds topics to the subscriptions, they are sent at once when connected
+// and again after every reconnection.
+func (c *Conn) Subscribe(topics []string) error {
+	c.mu.Lock()
+	var added []string
+	for _, v := range topics {
+		if !c.topics[v] {
+			c.topics[v] = true
+			added = append(added, v)
+		}
+	}
+	ws := c.ws
+	c.mu.Unlock()
+
+	if ws == nil || len(added) == 0 {
+		return nil
+	}
+
+	return c.send(ws, c.protocol.Subscribe(added)...)
+}
+
+func (c *Conn) Unsubscribe(topics []string) error {
+	c.mu.Lock()
+	var removed []string
+	for _, v := range topics {
+		if c.topics[v] {
+			delete(c.topics, v)
+			removed = append(removed, v)
+		}
+	}
+	ws := c.ws
+	c.mu.Unlock()
+
+	if ws == nil || len(removed) == 0 {
+		return nil
+	}
+
+	return c.send(ws, c.protocol.Unsubscribe(removed)...)
+}
+
+// Topics returns the subscribed topics.
+func (c *Conn) Topics() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := make([]string, 0, len(c.topics))
+	for v := range c.topics {
+		ret = append(ret, v)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+// connect dials the stream and subscribes to the current topics.
+func (c *Conn) connect() (*websocket.Conn, error) {
+	ws, _, err := c.dialer.Dial(c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		_ = ws.Close()
+		return nil, ErrClosed
+	}
+	c.ws = ws
+	c.mu.Unlock()
+
+	topics := c.Topics()
+
+	if len(topics) > 0 {
+		if err := c.send(ws, c.protocol.Subscribe(topics)...); err != nil {
+			// ws has no read loop, Open must not take it for an open connection
+			c.mu.Lock()
+			if c.ws == ws {
+				c.ws = nil
+			}
+			c.mu.Unlock()
+			_ = ws.Close()
+			return nil, err
+		}
+	}
+
+	if c.debug {
+		c.logger.Info("stream connected", "url", c.url, "topics", len(topics))
+	}
+
+	return ws, nil
+}
+
+// run reads ws until it fails, then reconnects if enabled.
+func (c *Conn) run(ws *websocket.Conn) {
+	for {
+		err := c.read(ws)
+
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+
+		start := time.Now()
+		c.logger.Warn("stream disconnected", "url", c.url, "error", err)
+
+		c.mu.Lock()
+		if c.ws == ws {
+			c.ws = nil
+		}
+		c.mu.Unlock()
+		_ = ws.Close()
+
+		if !c.autoReconnect {
+			return
+		}
+
+		if ws = c.reconnect(); ws == nil {
+			return
+		}
+
+		outage := &Outage{Start: start.UnixMilli(), End: time.Now().UnixMilli(), Err: err}
+		c.logger.Warn("stream reconnected", "url", c.url, "down", time.Since(start))
+
+		c.mu.Lock()
+		listeners := c.onOutage
+		c.mu.Unlock()
+		for _, fn := range listeners {
+			fn(outage)
+		}
+	}
+}
+
+// reconnect dials until it succeeds or the Conn is closed, then it returns nil.
+func (c *Conn) reconnect() *websocket.Conn {
+	delay := c.reconnectDelay
+
+	for {
+		select {
+		case <-c.done:
+			return nil
+		case <-time.After(delay):
+		}
+
+		ws, err := c.connect()
+		if err == nil {
+			return ws
+		}
+		if errors.Is(err, ErrClosed) {
+			return nil
+		}
+
+		c.logger.Warn("stream reconnection failed", "url", c.url, "error", err, "retry_in", delay)
+		delay = min(delay*2, MaxReconnectDelay)
+	}
+}
+
+// read dispatches the messages of ws and keeps it alive until it fails.
+func (c *Conn) read(ws *websocket.Conn) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go c.keepalive(ws, stop)
+
+	for {
+		_ = ws.SetReadDeadline(time.Now().Add(2 * c.pingInterval))
+
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return err
+		}
+		received := time.Now().UnixMilli()
+
+		msg, err := c.protocol.Parse(data)
+		if err != nil {
+			c.logger.Warn("stream message dropped", "url", c.url, "error", err, "data", string(data))
+			continue
+		}
+		if msg == nil {
+			if c.debug {
+				c.logger.Info("stream response", "url", c.url, "data", string(data))
+			}
+			continue
+		}
+		msg.Received = received
+
+		c.mu.Lock()
+		listeners := c.onMessage
+		c.mu.Unlock()
+		for _, fn := range listeners {
+			fn(msg)
+		}
+	}
+}
+
+func (c *Conn) keepalive(ws *websocket.Conn, stop chan struct{}) {
+	ticker := time.NewTicker(c.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := c.send(ws, c.protocol.Ping()); err != nil {
+				c.logger.Warn("stream ping failed", "url", c.url, "error", err)
+			}
+		}
+	}
+}
+
+func (c *Conn) send(ws *websocket.Conn, requests ...any) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	for _, v := range requests {
+		if c.debug {
+			c.logger.Info("stream request", "url", c.url, "request", v)
+		}
+
+		_ = ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := ws.WriteJSON(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+type testProtocol struct{}
+
+func (testProtocol) Subscribe(topics []string) []any {
+	return []any{map[string]any{"sub": topics}}
+}
+
+func (testProtocol) Unsubscribe(topics []string) []any {
+	return []any{map[string]any{"unsub": topics}}
+}
+
+func (testProtocol) Ping() any {
+	return map[string]any{"ping": true}
+}
+
+func (testProtocol) Parse(data []byte) (*Message, error) {
+	var v struct {
+		Topic string          `json:"topic"`
+		Data  json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if v.Topic == "" {
+		return nil, nil
+	}
+	return &Message{Topic: v.Topic, Data: v.Data}, nil
+}
+
+func TestConnReconnect(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+		conns    int
+	)
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+
+		mu.Lock()
+		conns++
+		n := conns
+		mu.Unlock()
+
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		requests = append(requests, strings.TrimSpace(string(data)))
+		mu.Unlock()
+
+		_ = ws.WriteMessage(websocket.TextMessage, []byte(`{"ok":true}`))
+		_ = ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"topic":"deals","data":{"n":%d}}`, n)))
+
+		// the first connection drops after its push
+		if n == 1 {
+			return
+		}
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewConn(&ConnCfg{
+		URL:            "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Protocol:       testProtocol{},
+		AutoReconnect:  true,
+		ReconnectDelay: 10 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+
+	messages := make(chan *Message, 10)
+	outages := make(chan *Outage, 10)
+	c.OnMessage(func(m *Message) { messages <- m })
+	c.OnOutage(func(o *Outage) { outages <- o })
+
+	assert.Nil(t, c.Subscribe([]string{"deals"}))
+	assert.Nil(t, c.Open())
+
+	for i := 1; i <= 2; i++ {
+		select {
+		case m := <-messages:
+			assert.Equal(t, "deals", m.Topic)
+			assert.JSONEq(t, fmt.Sprintf(`{"n":%d}`, i), string(m.Data))
+			assert.Positive(t, m.Received)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no message")
+		}
+	}
+
+	select {
+	case o := <-outages:
+		assert.LessOrEqual(t, o.Start, o.End)
+		assert.NotNil(t, o.Err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no outage")
+	}
+
+	assert.Nil(t, c.Close())
+	assert.ErrorIs(t, c.Open(), ErrClosed)
+
+	// subscriptions are sent again after reconnecting
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{`{"sub":["deals"]}`, `{"sub":["deals"]}`}, requests)
+}
+
+// slowProtocol delays the subscriptions, so that a connection dropped by the server
+// is already reset when they are sent.
+type slowProtocol struct {
+	testProtocol
+}
+
+func (p slowProtocol) Subscribe(topics []string) []any {
+	time.Sleep(100 * time.Millisecond)
+	return p.testProtocol.Subscribe(topics)
+}
+
+func TestConnDroppedAfterUpgrade(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		conns int
+	)
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		conns++
+		mu.Unlock()
+
+		// reset the connection at once
+		if tcp, ok := ws.UnderlyingConn().(*net.TCPConn); ok {
+			_ = tcp.SetLinger(0)
+		}
+		_ = ws.Close()
+	}))
+	defer srv.Close()
+
+	c, err := NewConn(&ConnCfg{
+		URL:      "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Protocol: slowProtocol{},
+	})
+	assert.Nil(t, err)
+	defer c.Close()
+
+	assert.Nil(t, c.Subscribe([]string{"deals"}))
+
+	// a failed subscription leaves the Conn closed, Open dials again
+	assert.NotNil(t, c.Open())
+	c.mu.Lock()
+	assert.Nil(t, c.ws)
+	c.mu.Unlock()
+
+	assert.NotNil(t, c.Open())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, conns)
+}
+
+func TestEmitter(t *testing.T) {
+	var e Emitter
+	var got []any
+
+	e.Emit("a", 0)
+	e.AddListener("a", func(v any) { got = append(got, v) })
+	e.AddListener("b", func(v any) { got = append(got, v) })
+	e.Emit("a", 1)
+	e.Emit("b", 2)
+	e.RemoveListeners("a")
+	e.Emit("a", 3)
+
+	assert.Equal(t, []any{1, 2}, got)
+}
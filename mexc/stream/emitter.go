@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import "sync"
+
+// Listener receives the events of a topic, see the websocketmarket clients for the
+// type of the events of each topic.
+type Listener func(e any)
+
+// Emitter dispatches events to the listeners of their topic. The zero value is ready
+// to use.
+type Emitter struct {
+	mu        sync.RWMutex
+	listeners map[string][]Listener
+}
+
+func (e *Emitter) AddListener(topic string, listener Listener) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.listeners == nil {
+		e.listeners = make(map[string][]Listener)
+	}
+	e.listeners[topic] = append(e.listeners[topic], listener)
+}
+
+// RemoveListeners removes all the listeners of topic.
+func (e *Emitter) RemoveListeners(topic string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.listeners, topic)
+}
+
+func (e *Emitter) Emit(topic string, event any) {
+	e.mu.RLock()
+	listeners := e.listeners[topic]
+	e.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
@@ -0,0 +1,35 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/valyala/fastjson"
+)
+
+// ParseDecimal reads a decimal from a JSON number or string.
+func ParseDecimal(v *fastjson.Value) (decimal.Decimal, error) {
+	var d decimal.Decimal
+	if v == nil {
+		return d, nil
+	}
+
+	err := d.UnmarshalJSON(v.MarshalTo(nil))
+
+	return d, err
+}
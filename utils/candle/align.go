@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package candle
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+)
+
+var ErrInvalidInterval = errors.New("interval must be a positive multiple of a millisecond")
+
+// Aligner maps a time to the candle containing it. Candles are aligned on the wall
+// clock of Location, which defaults to UTC: a 12h candle starts at midnight and noon,
+// a 1d candle at midnight and a 1w candle on Monday at midnight. Intervals which are
+// multiples of a day follow daylight saving time changes, so such a candle may last
+// 23 or 25 hours.
+type Aligner struct {
+	interval time.Duration
+	loc      *time.Location
+}
+
+func NewAligner(interval time.Duration, loc *time.Location) (Aligner, error) {
+	if interval < time.Millisecond || interval%time.Millisecond != 0 {
+		return Aligner{}, ErrInvalidInterval
+	}
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return Aligner{interval: interval, loc: loc}, nil
+}
+
+func (a Aligner) Interval() time.Duration {
+	return a.interval
+}
+
+// Start returns the open time of the candle containing ts, both in milliseconds.
+func (a Aligner) Start(ts int64) int64 {
+	if a.interval%day == 0 {
+		return a.dayStart(ts)
+	}
+
+	t := time.UnixMilli(ts).In(a.loc)
+	_, offset := t.Zone()
+	local := ts + int64(offset)*1000
+
+	d := a.interval.Milliseconds()
+	return floorDiv(local, d)*d - int64(offset)*1000
+}
+
+// Next returns the open time of the candle following the one opened at start.
+func (a Aligner) Next(start int64) int64 {
+	if a.interval%day == 0 {
+		t := time.UnixMilli(start).In(a.loc)
+		return time.Date(t.Year(), t.Month(), t.Day()+int(a.interval/day), 0, 0, 0, 0, a.loc).UnixMilli()
+	}
+
+	return start + a.interval.Milliseconds()
+}
+
+// CloseTime returns the last millisecond of the candle opened at start.
+func (a Aligner) CloseTime(start int64) int64 {
+	return a.Next(start) - 1
+}
+
+func (a Aligner) dayStart(ts int64) int64 {
+	t := time.UnixMilli(ts).In(a.loc)
+
+	// count civil days since 1970-01-01, or since Monday 1970-01-05 for weeks
+	days := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+	var anchor int64
+	if a.interval%week == 0 {
+		anchor = 4
+	}
+
+	n := int64(a.interval / day)
+	start := time.Unix((floorDiv(days-anchor, n)*n+anchor)*86400, 0).UTC()
+
+	return time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, a.loc).UnixMilli()
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
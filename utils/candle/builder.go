@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package candle
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jl1/nexapi/utils/decimal"
+)
+
+var ErrLateTrade = errors.New("trade is older than the current candle")
+
+// Builder builds candles from trades received in time order. A candle is passed to
+// the close callback when the first trade of a later candle arrives, or on Flush.
+// Builder is not safe for concurrent use.
+type Builder struct {
+	aligner  Aligner
+	onClose  func(*Candle)
+	fillGaps bool
+	cur      *Candle
+}
+
+// NewBuilder calls onClose with every closed candle. When fillGaps is set, candles
+// without trades are emitted too, flat at the previous close with no volume.
+func NewBuilder(a Aligner, fillGaps bool, onClose func(*Candle)) *Builder {
+	return &Builder{aligner: a, onClose: onClose, fillGaps: fillGaps}
+}
+
+// Add adds a trade of qty at price at ts in milliseconds. Trades older than the
+// current candle are rejected with ErrLateTrade.
+func (b *Builder) Add(ts int64, price, qty decimal.Decimal) error {
+	start := b.aligner.Start(ts)
+
+	if b.cur != nil && start < b.cur.OpenTime {
+		return fmt.Errorf("%w: %d before %d", ErrLateTrade, ts, b.cur.OpenTime)
+	}
+
+	if b.cur != nil && start > b.cur.OpenTime {
+		last := b.cur
+		b.onClose(last)
+		b.cur = nil
+
+		if b.fillGaps {
+			for t := b.aligner.Next(last.OpenTime); t < start; t = b.aligner.Next(t) {
+				b.onClose(&Candle{
+					OpenTime:  t,
+					CloseTime: b.aligner.CloseTime(t),
+					Open:      last.Close,
+					High:      last.Close,
+					Low:       last.Close,
+					Close:     last.Close,
+				})
+			}
+		}
+	}
+
+	if b.cur == nil {
+		b.cur = &Candle{
+			OpenTime:  start,
+			CloseTime: b.aligner.CloseTime(start),
+			Open:      price,
+			High:      price,
+			Low:       price,
+		}
+	}
+
+	b.cur.High = decimal.Max(b.cur.High, price)
+	b.cur.Low = decimal.Min(b.cur.Low, price)
+	b.cur.Close = price
+	b.cur.Volume = b.cur.Volume.Add(qty)
+	b.cur.QuoteVolume = b.cur.QuoteVolume.Add(price.Mul(qty))
+	b.cur.Trades++
+
+	return nil
+}
+
+// Current returns a copy of the candle being built, nil before the first trade.
+func (b *Builder) Current() *Candle {
+	if b.cur == nil {
+		return nil
+	}
+
+	c := *b.cur
+	return &c
+}
+
+// Flush closes the candle being built, if any.
+func (b *Builder) Flush() {
+	if b.cur != nil {
+		b.onClose(b.cur)
+		b.cur = nil
+	}
+}
@@ -0,0 +1,35 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package candle provides an exchange independent OHLCV candle.
+package candle
+
+import "github.com/jl1/nexapi/utils/decimal"
+
+// Candle times are in milliseconds, CloseTime is the last millisecond of the candle.
+type Candle struct {
+	OpenTime    int64           `json:"openTime"`
+	CloseTime   int64           `json:"closeTime"`
+	Open        decimal.Decimal `json:"open"`
+	High        decimal.Decimal `json:"high"`
+	Low         decimal.Decimal `json:"low"`
+	Close       decimal.Decimal `json:"close"`
+	Volume      decimal.Decimal `json:"volume"`
+	QuoteVolume decimal.Decimal `json:"quoteVolume"`
+	// Trades is the number of trades, zero when unknown
+	Trades int64 `json:"trades,omitempty"`
+}
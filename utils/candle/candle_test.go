@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package candle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var d = decimal.MustParse
+
+func ms(s string) int64 {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t.UnixMilli()
+}
+
+func TestAligner(t *testing.T) {
+	a, err := NewAligner(3*time.Minute, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, ms("2024-01-01T00:03:00Z"), a.Start(ms("2024-01-01T00:05:59Z")))
+	assert.Equal(t, ms("2024-01-01T00:05:59.999Z"), a.CloseTime(ms("2024-01-01T00:03:00Z")))
+
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	assert.Nil(t, err)
+
+	a, err = NewAligner(24*time.Hour, shanghai)
+	assert.Nil(t, err)
+	assert.Equal(t, ms("2024-01-01T16:00:00Z"), a.Start(ms("2024-01-02T03:00:00Z")))
+
+	a, err = NewAligner(12*time.Hour, shanghai)
+	assert.Nil(t, err)
+	assert.Equal(t, ms("2024-01-02T04:00:00Z"), a.Start(ms("2024-01-02T05:00:00Z")))
+
+	// 2024-01-03 is a Wednesday
+	a, err = NewAligner(7*24*time.Hour, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, ms("2024-01-01T00:00:00Z"), a.Start(ms("2024-01-03T12:00:00Z")))
+	assert.Equal(t, ms("2024-01-08T00:00:00Z"), a.Next(ms("2024-01-01T00:00:00Z")))
+
+	// the day of the switch to summer time lasts 23 hours
+	ny, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+	a, err = NewAligner(24*time.Hour, ny)
+	assert.Nil(t, err)
+	start := a.Start(ms("2024-03-10T12:00:00Z"))
+	assert.Equal(t, ms("2024-03-10T05:00:00Z"), start)
+	assert.Equal(t, ms("2024-03-11T04:00:00Z"), a.Next(start))
+
+	_, err = NewAligner(time.Microsecond, nil)
+	assert.ErrorIs(t, err, ErrInvalidInterval)
+}
+
+func TestResample(t *testing.T) {
+	a, err := NewAligner(3*time.Minute, nil)
+	assert.Nil(t, err)
+
+	minute := int64(60000)
+	var src []*Candle
+	for i, v := range [][4]string{
+		{"10", "12", "9", "11"},
+		{"11", "15", "10", "14"},
+		{"14", "14", "8", "9"},
+		{"9", "10", "9", "10"},
+	} {
+		src = append(src, &Candle{
+			OpenTime: int64(i) * minute, CloseTime: int64(i+1)*minute - 1,
+			Open: d(v[0]), High: d(v[1]), Low: d(v[2]), Close: d(v[3]),
+			Volume: d("1.5"), QuoteVolume: d("15"), Trades: 2,
+		})
+	}
+
+	out := Resample(src, a)
+	assert.Len(t, out, 2)
+	assert.Equal(t, int64(0), out[0].OpenTime)
+	assert.Equal(t, 3*minute-1, out[0].CloseTime)
+	assert.Equal(t, "10", out[0].Open.String())
+	assert.Equal(t, "15", out[0].High.String())
+	assert.Equal(t, "8", out[0].Low.String())
+	assert.Equal(t, "9", out[0].Close.String())
+	assert.Equal(t, "4.5", out[0].Volume.String())
+	assert.Equal(t, "45", out[0].QuoteVolume.String())
+	assert.Equal(t, int64(6), out[0].Trades)
+	assert.Equal(t, "10", out[1].Close.String())
+}
+
+func TestBuilder(t *testing.T) {
+	a, err := NewAligner(time.Minute, nil)
+	assert.Nil(t, err)
+
+	var closed []*Candle
+	b := NewBuilder(a, true, func(c *Candle) {
+		closed = append(closed, c)
+	})
+
+	assert.Nil(t, b.Add(1000, d("10"), d("1")))
+	assert.Nil(t, b.Add(2000, d("12"), d("2")))
+	assert.Equal(t, "12", b.Current().Close.String())
+	assert.Nil(t, b.Add(120000, d("11"), d("1")))
+	assert.ErrorIs(t, b.Add(1000, d("11"), d("1")), ErrLateTrade)
+	b.Flush()
+
+	assert.Len(t, closed, 3)
+	assert.Equal(t, "12", closed[0].High.String())
+	assert.Equal(t, "34", closed[0].QuoteVolume.String())
+	assert.Equal(t, int64(2), closed[0].Trades)
+	// no trade in the second minute
+	assert.Equal(t, int64(60000), closed[1].OpenTime)
+	assert.Equal(t, "12", closed[1].Open.String())
+	assert.True(t, closed[1].Volume.IsZero())
+	assert.Equal(t, int64(120000), closed[2].OpenTime)
+	assert.Nil(t, b.Current())
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package candle
+
+import "github.com/jl1/nexapi/utils/decimal"
+
+// Resample merges candles ordered by open time into candles of the interval of a,
+// which must be a multiple of the source interval. The first and the last candles
+// may be partial when the source does not cover them entirely.
+func Resample(candles []*Candle, a Aligner) []*Candle {
+	var (
+		ret []*Candle
+		cur *Candle
+	)
+
+	for _, v := range candles {
+		start := a.Start(v.OpenTime)
+
+		if cur == nil || cur.OpenTime != start {
+			cur = &Candle{
+				OpenTime:  start,
+				CloseTime: a.CloseTime(start),
+				Open:      v.Open,
+				High:      v.High,
+				Low:       v.Low,
+			}
+			ret = append(ret, cur)
+		}
+
+		cur.High = decimal.Max(cur.High, v.High)
+		cur.Low = decimal.Min(cur.Low, v.Low)
+		cur.Close = v.Close
+		cur.Volume = cur.Volume.Add(v.Volume)
+		cur.QuoteVolume = cur.QuoteVolume.Add(v.QuoteVolume)
+		cur.Trades += v.Trades
+	}
+
+	return ret
+}
@@ -0,0 +1,463 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package decimal provides an exact fixed-point decimal number for prices, quantities
+// and rates. Decimals keep the text received from an exchange and never use exponent
+// notation when encoded to JSON or to URL query values.
+package decimal
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxExponent bounds the exponent accepted by Parse, larger ones would allocate huge
+// numbers or overflow the scale.
+const maxExponent = 1000
+
+var (
+	ten = big.NewInt(10)
+
+	// Zero is the zero decimal.
+	Zero = Decimal{}
+)
+
+// Decimal is an immutable decimal number, unscaled * 10^-scale. The zero value is 0.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+
+	// raw is the text the decimal was parsed from
+	raw string
+	// quoted reports whether raw was a JSON string
+	quoted bool
+}
+
+// New returns unscaled * 10^-scale.
+func New(unscaled int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{unscaled: new(big.Int).Mul(big.NewInt(unscaled), pow10(-scale))}
+	}
+
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+}
+
+// NewFromInt returns i as a decimal.
+func NewFromInt(i int64) Decimal {
+	return New(i, 0)
+}
+
+// NewFromFloat returns the shortest decimal representation of f.
+func NewFromFloat(f float64) Decimal {
+	d, err := Parse(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		// NaN and infinities have no decimal representation
+		return Zero
+	}
+	d.raw = ""
+
+	return d
+}
+
+// Parse parses s, which may carry a sign and an exponent of at most 1000 in absolute
+// value. An empty string is 0.
+func Parse(s string) (Decimal, error) {
+	if s == "" {
+		return Zero, nil
+	}
+
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Zero, fmt.Errorf("invalid decimal %q", s)
+		}
+		if e > maxExponent || e < -maxExponent {
+			return Zero, fmt.Errorf("invalid decimal %q: exponent out of [-%d, %d]", s, maxExponent, maxExponent)
+		}
+		mantissa, exp = s[:i], e
+	}
+
+	var scale int64
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		scale = int64(len(mantissa) - i - 1)
+		mantissa = mantissa[:i] + mantissa[i+1:]
+	}
+
+	if mantissa == "" || mantissa == "-" || mantissa == "+" {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+	for i, c := range mantissa {
+		if (c < '0' || c > '9') && !(i == 0 && (c == '-' || c == '+')) {
+			return Zero, fmt.Errorf("invalid decimal %q", s)
+		}
+	}
+
+	unscaled, ok := new(big.Int).SetString(mantissa, 10)
+	if !ok {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	scale -= exp
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(int32(-scale)))
+		scale = 0
+	}
+
+	return Decimal{unscaled: unscaled, scale: int32(scale), raw: s}, nil
+}
+
+// MustParse is like Parse but panics on invalid input.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(ten, big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) value() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+
+	return d.unscaled
+}
+
+// rescale returns the unscaled value of d at scale, which must not be smaller than d.scale.
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale == d.scale {
+		return d.value()
+	}
+
+	return new(big.Int).Mul(d.value(), pow10(scale-d.scale))
+}
+
+func align(d1, d2 Decimal) (*big.Int, *big.Int, int32) {
+	scale := max(d1.scale, d2.scale)
+	return d1.rescale(scale), d2.rescale(scale), scale
+}
+
+// Raw returns the text the decimal was parsed from, or its plain representation when it
+// was computed.
+func (d Decimal) Raw() string {
+	if d.raw != "" {
+		return d.raw
+	}
+
+	return d.String()
+}
+
+// String returns the plain representation of d, without exponent. The decimal places
+// are kept, so "1.50" stays "1.50".
+func (d Decimal) String() string {
+	return d.StringFixed(d.scale)
+}
+
+// StringFixed returns d rounded to places decimals.
+func (d Decimal) StringFixed(places int32) string {
+	if places < 0 {
+		places = 0
+	}
+
+	r := d.Round(places)
+	digits := new(big.Int).Abs(r.rescale(places)).String()
+
+	if places > 0 {
+		if len(digits) <= int(places) {
+			digits = strings.Repeat("0", int(places)-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-int(places)] + "." + digits[len(digits)-int(places):]
+	}
+
+	if r.Sign() < 0 {
+		return "-" + digits
+	}
+
+	return digits
+}
+
+// Float64 returns the nearest float64 of d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.StringFixed(d.scale), 64)
+	return f
+}
+
+// IntPart returns the integer part of d, truncated toward zero.
+func (d Decimal) IntPart() int64 {
+	return new(big.Int).Quo(d.value(), pow10(d.scale)).Int64()
+}
+
+// Scale returns the number of decimal places of d.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+func (d Decimal) IsPositive() bool {
+	return d.Sign() > 0
+}
+
+func (d Decimal) IsNegative() bool {
+	return d.Sign() < 0
+}
+
+// Cmp returns -1, 0 or +1 when d is less than, equal to or greater than d2.
+func (d Decimal) Cmp(d2 Decimal) int {
+	v1, v2, _ := align(d, d2)
+	return v1.Cmp(v2)
+}
+
+func (d Decimal) Equal(d2 Decimal) bool {
+	return d.Cmp(d2) == 0
+}
+
+func (d Decimal) LessThan(d2 Decimal) bool {
+	return d.Cmp(d2) < 0
+}
+
+func (d Decimal) LessThanOrEqual(d2 Decimal) bool {
+	return d.Cmp(d2) <= 0
+}
+
+func (d Decimal) GreaterThan(d2 Decimal) bool {
+	return d.Cmp(d2) > 0
+}
+
+func (d Decimal) GreaterThanOrEqual(d2 Decimal) bool {
+	return d.Cmp(d2) >= 0
+}
+
+func (d Decimal) Add(d2 Decimal) Decimal {
+	v1, v2, scale := align(d, d2)
+	return Decimal{unscaled: new(big.Int).Add(v1, v2), scale: scale}
+}
+
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	v1, v2, scale := align(d, d2)
+	return Decimal{unscaled: new(big.Int).Sub(v1, v2), scale: scale}
+}
+
+func (d Decimal) Mul(d2 Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.value(), d2.value()), scale: d.scale + d2.scale}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.value()), scale: d.scale}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{unscaled: new(big.Int).Abs(d.value()), scale: d.scale}
+}
+
+// Div returns d / d2 rounded half away from zero to places decimals. It panics when d2 is 0.
+func (d Decimal) Div(d2 Decimal, places int32) Decimal {
+	if d2.IsZero() {
+		panic("decimal division by zero")
+	}
+
+	// d / d2 = (u1 * 10^(places - s1 + s2) / u2) * 10^-places
+	num, den := new(big.Int).Set(d.value()), new(big.Int).Set(d2.value())
+	if exp := places - d.scale + d2.scale; exp >= 0 {
+		num.Mul(num, pow10(exp))
+	} else {
+		den.Mul(den, pow10(-exp))
+	}
+
+	return Decimal{unscaled: roundQuo(num, den), scale: places}
+}
+
+// roundQuo returns num / den rounded half away from zero.
+func roundQuo(num, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	if new(big.Int).Abs(new(big.Int).Lsh(r, 1)).Cmp(new(big.Int).Abs(den)) >= 0 {
+		if num.Sign()*den.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	return q
+}
+
+// Round rounds d half away from zero to places decimals.
+func (d Decimal) Round(places int32) Decimal {
+	if places >= d.scale {
+		return d
+	}
+
+	return Decimal{unscaled: roundQuo(d.value(), pow10(d.scale-places)), scale: places}
+}
+
+// Truncate drops the decimals of d beyond places.
+func (d Decimal) Truncate(places int32) Decimal {
+	if places >= d.scale {
+		return d
+	}
+
+	return Decimal{unscaled: new(big.Int).Quo(d.value(), pow10(d.scale-places)), scale: places}
+}
+
+// IsMultipleOf reports whether d is an integer multiple of step. Every decimal is a
+// multiple of a zero or negative step.
+func (d Decimal) IsMultipleOf(step Decimal) bool {
+	if step.Sign() <= 0 {
+		return true
+	}
+
+	v, s, _ := align(d, step)
+	return new(big.Int).Rem(v, s).Sign() == 0
+}
+
+// RoundStep rounds d half away from zero to a multiple of step.
+func (d Decimal) RoundStep(step Decimal) Decimal {
+	if step.Sign() <= 0 {
+		return d
+	}
+
+	v, s, _ := align(d, step)
+	return Decimal{unscaled: roundQuo(v, s), scale: 0}.Mul(step)
+}
+
+// FloorStep rounds d down to a multiple of step.
+func (d Decimal) FloorStep(step Decimal) Decimal {
+	if step.Sign() <= 0 {
+		return d
+	}
+
+	v, s, _ := align(d, step)
+	// Div is the Euclidean division, which floors for a positive divisor
+	return Decimal{unscaled: new(big.Int).Div(v, s), scale: 0}.Mul(step)
+}
+
+// CeilStep rounds d up to a multiple of step.
+func (d Decimal) CeilStep(step Decimal) Decimal {
+	if step.Sign() <= 0 {
+		return d
+	}
+
+	v, s, _ := align(d, step)
+	q, m := new(big.Int).DivMod(v, s, new(big.Int))
+	if m.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+
+	return Decimal{unscaled: q, scale: 0}.Mul(step)
+}
+
+// Min returns the smallest of the decimals.
+func Min(first Decimal, rest ...Decimal) Decimal {
+	ret := first
+	for _, v := range rest {
+		if v.LessThan(ret) {
+			ret = v
+		}
+	}
+
+	return ret
+}
+
+// Max returns the largest of the decimals.
+func Max(first Decimal, rest ...Decimal) Decimal {
+	ret := first
+	for _, v := range rest {
+		if v.GreaterThan(ret) {
+			ret = v
+		}
+	}
+
+	return ret
+}
+
+// MarshalJSON encodes d as a plain JSON number, or as a JSON string when d was decoded
+// from one, so that responses are encoded back the way the exchange sent them.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if d.quoted {
+		return []byte(strconv.Quote(d.String())), nil
+	}
+
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON accepts JSON numbers and strings, null and "" decode to 0.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = Zero
+		return nil
+	}
+
+	quoted := len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"'
+	if quoted {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return err
+		}
+		data = []byte(s)
+	}
+
+	v, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+	v.quoted = quoted
+
+	*d = v
+
+	return nil
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	v, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = v
+
+	return nil
+}
+
+// EncodeValues implements the query.Encoder interface of go-querystring.
+func (d Decimal) EncodeValues(key string, v *url.Values) error {
+	v.Set(key, d.String())
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decimal
+
+import (
+	"encoding/json"
+	"testing"
+
+	goquery "github.com/google/go-querystring/query"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for s, want := range map[string]string{
+		"0":          "0",
+		"1.50":       "1.50",
+		"-0.001":     "-0.001",
+		"+3":         "3",
+		"1e-05":      "0.00001",
+		"1.5E3":      "1500",
+		"12.345e1":   "123.45",
+		"007.10":     "7.10",
+		"":           "0",
+		"0.00000001": "0.00000001",
+	} {
+		d, err := Parse(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, want, d.String(), s)
+	}
+
+	for _, s := range []string{"abc", "1.2.3", "-", "1e", "0x10", "1-2", "1e2147483647", "1e-2147483648", "1e1001", "1e-1001"} {
+		_, err := Parse(s)
+		assert.NotNil(t, err, s)
+	}
+
+	assert.True(t, MustParse("1e1000").Equal(New(1, -1000)))
+	assert.True(t, MustParse("1e-1000").Equal(New(1, 1000)))
+
+	assert.Equal(t, "1e-05", MustParse("1e-05").Raw())
+	assert.Equal(t, "0.00001", NewFromFloat(0.00001).String())
+	assert.Equal(t, "0.1", NewFromFloat(0.1).String())
+	assert.Equal(t, "-12.5", New(-125, 1).String())
+	assert.Equal(t, "1200", New(12, -2).String())
+}
+
+func TestArithmetic(t *testing.T) {
+	a, b := MustParse("0.1"), MustParse("0.2")
+
+	assert.Equal(t, "0.3", a.Add(b).String())
+	assert.Equal(t, "-0.1", a.Sub(b).String())
+	assert.Equal(t, "0.02", a.Mul(b).String())
+	assert.Equal(t, "0.5", a.Div(b, 1).String())
+	assert.Equal(t, "0.3333", NewFromInt(1).Div(NewFromInt(3), 4).String())
+	assert.Equal(t, "0.6667", NewFromInt(2).Div(NewFromInt(3), 4).String())
+	assert.Equal(t, "-0.6667", NewFromInt(-2).Div(NewFromInt(3), 4).String())
+	assert.Equal(t, "200", NewFromInt(2).Div(MustParse("0.01"), 0).String())
+	assert.True(t, MustParse("1.50").Equal(MustParse("1.5")))
+	assert.True(t, MustParse("-1").LessThan(Zero))
+	assert.Equal(t, "2.5", MustParse("-2.5").Abs().String())
+	assert.Equal(t, int64(-2), MustParse("-2.9").IntPart())
+	assert.Equal(t, 0.3, a.Add(b).Float64())
+	assert.Equal(t, "0.1", Min(b, a).String())
+	assert.Equal(t, "0.2", Max(a, b).String())
+}
+
+func TestRounding(t *testing.T) {
+	assert.Equal(t, "1.24", MustParse("1.235").Round(2).String())
+	assert.Equal(t, "-1.24", MustParse("-1.235").Round(2).String())
+	assert.Equal(t, "1.23", MustParse("1.239").Truncate(2).String())
+	assert.Equal(t, "1.239", MustParse("1.239").Round(5).String())
+	assert.Equal(t, "1.20", MustParse("1.2").StringFixed(2))
+
+	step := MustParse("0.05")
+	assert.Equal(t, "1.20", MustParse("1.2249").RoundStep(step).String())
+	assert.Equal(t, "1.25", MustParse("1.225").RoundStep(step).String())
+	assert.Equal(t, "1.20", MustParse("1.2499").FloorStep(step).String())
+	assert.Equal(t, "-1.25", MustParse("-1.2001").FloorStep(step).String())
+	assert.Equal(t, "1.25", MustParse("1.2001").CeilStep(step).String())
+	assert.Equal(t, "1.20", MustParse("1.2").CeilStep(step).String())
+	assert.True(t, MustParse("1.15").IsMultipleOf(step))
+	assert.False(t, MustParse("1.151").IsMultipleOf(step))
+	assert.True(t, MustParse("1.151").IsMultipleOf(Zero))
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Price  Decimal   `json:"price"`
+		Qty    Decimal   `json:"qty"`
+		Empty  Decimal   `json:"empty"`
+		Null   Decimal   `json:"null"`
+		Levels []Decimal `json:"levels"`
+	}
+
+	err := json.Unmarshal([]byte(`{"price":"0.00001","qty":1e-05,"empty":"","null":null,"levels":[1.5,"2"]}`), &v)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.00001", v.Price.String())
+	assert.Equal(t, "0.00001", v.Qty.String())
+	assert.Equal(t, "1e-05", v.Qty.Raw())
+	assert.True(t, v.Empty.IsZero())
+	assert.True(t, v.Null.IsZero())
+
+	b, err := json.Marshal(v)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"price":"0.00001","qty":0.00001,"empty":"0","null":0,"levels":[1.5,"2"]}`, string(b))
+
+	b, err = json.Marshal(NewFromFloat(0.00001))
+	assert.Nil(t, err)
+	assert.Equal(t, `0.00001`, string(b))
+
+	assert.NotNil(t, json.Unmarshal([]byte(`"abc"`), &v.Price))
+}
+
+func TestEncodeValues(t *testing.T) {
+	price := NewFromFloat(0.00001)
+
+	q, err := goquery.Values(struct {
+		Price    *Decimal `url:"price,omitempty"`
+		Quantity Decimal  `url:"quantity"`
+		Missing  *Decimal `url:"missing,omitempty"`
+		Zero     Decimal  `url:"zero,omitempty"`
+	}{
+		Price:    &price,
+		Quantity: MustParse("1e3"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "price=0.00001&quantity=1000", q.Encode())
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package indicators
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jl1/nexapi/utils/candle"
+	"github.com/jl1/nexapi/utils/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+const delta = 1e-9
+
+func assertSeries(t *testing.T, expected, actual []float64) {
+	t.Helper()
+
+	assert.Len(t, actual, len(expected))
+	for i := range expected {
+		if math.IsNaN(expected[i]) {
+			assert.True(t, math.IsNaN(actual[i]), "index %d: %v", i, actual[i])
+			continue
+		}
+		assert.InDelta(t, expected[i], actual[i], delta, "index %d", i)
+	}
+}
+
+// testCandles are daily candles of high, low, close and volume.
+func testCandles() []*candle.Candle {
+	var ret []*candle.Candle
+	for i, v := range [][4]string{
+		{"10", "8", "9", "1"},
+		{"11", "9", "10.5", "3"},
+		{"13", "10", "11", "2"},
+	} {
+		open := int64(i) * 24 * time.Hour.Milliseconds()
+		ret = append(ret, &candle.Candle{
+			OpenTime: open,
+			High:     decimal.MustParse(v[0]),
+			Low:      decimal.MustParse(v[1]),
+			Close:    decimal.MustParse(v[2]),
+			Volume:   decimal.MustParse(v[3]),
+		})
+	}
+	return ret
+}
+
+func TestMovingAverages(t *testing.T) {
+	nan := math.NaN()
+	values := []float64{1, 2, 3, 4, 5}
+
+	assertSeries(t, []float64{nan, nan, 2, 3, 4}, SMASeries(values, 3))
+	assertSeries(t, []float64{nan, nan, 2, 3, 4}, EMASeries(values, 3))
+	assertSeries(t, []float64{nan, nan, 14.0 / 6, 20.0 / 6, 26.0 / 6}, WMASeries(values, 3))
+
+	// the EMA weighs the latest value by 2/(3+1)
+	assertSeries(t, []float64{nan, nan, 2, 5}, EMASeries([]float64{1, 2, 3, 8}, 3))
+
+	sma := NewSMA(2)
+	assert.False(t, sma.Ready())
+	sma.Update(1)
+	assert.Equal(t, 1.5, sma.Update(2))
+	assert.Equal(t, 2.5, sma.Update(3))
+
+	assert.Panics(t, func() { NewSMA(0) })
+}
+
+func TestRSI(t *testing.T) {
+	nan := math.NaN()
+	assertSeries(t, []float64{nan, nan, 100, 50}, RSISeries([]float64{1, 2, 3, 2}, 2))
+	assertSeries(t, []float64{nan, nan, 50}, RSISeries([]float64{1, 1, 1}, 2))
+}
+
+func TestMACD(t *testing.T) {
+	out := MACDSeries([]float64{1, 2, 3, 4, 5}, 2, 3, 2)
+
+	assert.True(t, math.IsNaN(out[1].MACD))
+	assert.InDelta(t, 0.5, out[2].MACD, delta)
+	assert.True(t, math.IsNaN(out[2].Signal))
+	assert.InDelta(t, 0.5, out[3].Signal, delta)
+	assert.InDelta(t, 0, out[4].Histogram, delta)
+}
+
+func TestBollinger(t *testing.T) {
+	out := BollingerSeries([]float64{1, 2, 3}, 3, 2)
+
+	assert.True(t, math.IsNaN(out[1].Middle))
+	dev := 2 * math.Sqrt(2.0/3)
+	assert.InDelta(t, 2+dev, out[2].Upper, delta)
+	assert.InDelta(t, 2, out[2].Middle, delta)
+	assert.InDelta(t, 2-dev, out[2].Lower, delta)
+}
+
+func TestATR(t *testing.T) {
+	assertSeries(t, []float64{math.NaN(), 2, 2.5}, ATRSeries(testCandles(), 2))
+}
+
+func TestStochastic(t *testing.T) {
+	out := StochasticSeries(testCandles(), 2, 2)
+
+	assert.True(t, math.IsNaN(out[0].K))
+	assert.InDelta(t, 2.5/3*100, out[1].K, delta)
+	assert.True(t, math.IsNaN(out[1].D))
+	assert.InDelta(t, 50, out[2].K, delta)
+	assert.InDelta(t, (2.5/3*100+50)/2, out[2].D, delta)
+}
+
+func TestVolume(t *testing.T) {
+	candles := testCandles()
+
+	tp := []float64{9, 30.5 / 3, 34.0 / 3}
+	assertSeries(t, []float64{
+		tp[0],
+		(tp[0] + tp[1]*3) / 4,
+		(tp[0] + tp[1]*3 + tp[2]*2) / 6,
+	}, VWAPSeries(candles, nil))
+
+	// a daily session restarts with every candle
+	daily, err := candle.NewAligner(24*time.Hour, nil)
+	assert.Nil(t, err)
+	assertSeries(t, tp, VWAPSeries(candles, &daily))
+
+	assertSeries(t, []float64{0, 3, 5}, OBVSeries(candles))
+}
+
+func TestIncrementalMatchesSeries(t *testing.T) {
+	values := []float64{44, 44.3, 44.1, 43.6, 44.3, 44.8, 45.1, 45.4, 45.8, 46.1, 45.9, 46.2, 45.6, 46.3, 46.4}
+
+	rsi := NewRSI(14)
+	var last float64
+	for _, v := range values {
+		last = rsi.Update(v)
+	}
+
+	series := RSISeries(values, 14)
+	assert.Equal(t, series[len(series)-1], last)
+	assert.True(t, rsi.Ready())
+}
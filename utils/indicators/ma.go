@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package indicators computes technical indicators over candles, either incrementally
+// with the Update method of each indicator or over a whole series with the *Series
+// functions. Values are float64, and NaN until an indicator has seen enough data.
+// MEXC klines are converted to candles with history.FromSpotKlines and
+// history.FromContractKlines.
+package indicators
+
+import (
+	"math"
+
+	"github.com/jl1/nexapi/utils/candle"
+)
+
+// window keeps the last n values.
+type window struct {
+	values []float64
+	next   int
+	full   bool
+}
+
+func newWindow(n int) *window {
+	return &window{values: make([]float64, n)}
+}
+
+// push adds v and returns the value it evicted, zero while the window is filling.
+func (w *window) push(v float64) float64 {
+	old := w.values[w.next]
+	w.values[w.next] = v
+	w.next++
+	if w.next == len(w.values) {
+		w.next = 0
+		w.full = true
+	}
+
+	return old
+}
+
+// at returns the i-th oldest value.
+func (w *window) at(i int) float64 {
+	if !w.full {
+		return w.values[i]
+	}
+	return w.values[(w.next+i)%len(w.values)]
+}
+
+// SMA is the simple moving average.
+type SMA struct {
+	w   *window
+	sum float64
+}
+
+func NewSMA(period int) *SMA {
+	return &SMA{w: newWindow(checkPeriod(period))}
+}
+
+func (s *SMA) Update(v float64) float64 {
+	s.sum += v - s.w.push(v)
+	return s.Value()
+}
+
+func (s *SMA) Ready() bool {
+	return s.w.full
+}
+
+func (s *SMA) Value() float64 {
+	if !s.Ready() {
+		return math.NaN()
+	}
+	return s.sum / float64(len(s.w.values))
+}
+
+// EMA is the exponential moving average with a smoothing factor of 2/(period+1),
+// seeded with the simple average of the first period values.
+type EMA struct {
+	period int
+	alpha  float64
+	n      int
+	value  float64
+}
+
+func NewEMA(period int) *EMA {
+	return newEMA(checkPeriod(period), 2/float64(period+1))
+}
+
+// newEMA builds a moving average with an arbitrary smoothing factor, 1/period gives
+// the smoothing of Wilder used by RSI and ATR.
+func newEMA(period int, alpha float64) *EMA {
+	return &EMA{period: period, alpha: alpha}
+}
+
+func (e *EMA) Update(v float64) float64 {
+	e.n++
+	switch {
+	case e.n < e.period:
+		e.value += v
+	case e.n == e.period:
+		e.value = (e.value + v) / float64(e.period)
+	default:
+		e.value += e.alpha * (v - e.value)
+	}
+
+	return e.Value()
+}
+
+func (e *EMA) Ready() bool {
+	return e.n >= e.period
+}
+
+func (e *EMA) Value() float64 {
+	if !e.Ready() {
+		return math.NaN()
+	}
+	return e.value
+}
+
+// WMA is the linearly weighted moving average, the latest value weighs period and the
+// oldest one weighs 1.
+type WMA struct {
+	w *window
+}
+
+func NewWMA(period int) *WMA {
+	return &WMA{w: newWindow(checkPeriod(period))}
+}
+
+func (m *WMA) Update(v float64) float64 {
+	m.w.push(v)
+	return m.Value()
+}
+
+func (m *WMA) Ready() bool {
+	return m.w.full
+}
+
+func (m *WMA) Value() float64 {
+	if !m.Ready() {
+		return math.NaN()
+	}
+
+	n := len(m.w.values)
+	var sum float64
+	for i := 0; i < n; i++ {
+		sum += m.w.at(i) * float64(i+1)
+	}
+
+	return sum / float64(n*(n+1)/2)
+}
+
+func SMASeries(values []float64, period int) []float64 {
+	return series(values, NewSMA(period).Update)
+}
+
+func EMASeries(values []float64, period int) []float64 {
+	return series(values, NewEMA(period).Update)
+}
+
+func WMASeries(values []float64, period int) []float64 {
+	return series(values, NewWMA(period).Update)
+}
+
+func series(values []float64, update func(float64) float64) []float64 {
+	ret := make([]float64, len(values))
+	for i, v := range values {
+		ret[i] = update(v)
+	}
+	return ret
+}
+
+// Closes returns the close prices of candles.
+func Closes(candles []*candle.Candle) []float64 {
+	ret := make([]float64, len(candles))
+	for i, v := range candles {
+		ret[i] = v.Close.Float64()
+	}
+	return ret
+}
+
+// TypicalPrice returns (high + low + close) / 3.
+func TypicalPrice(c *candle.Candle) float64 {
+	return (c.High.Float64() + c.Low.Float64() + c.Close.Float64()) / 3
+}
+
+func checkPeriod(period int) int {
+	if period <= 0 {
+		panic("indicators: period must be positive")
+	}
+	return period
+}
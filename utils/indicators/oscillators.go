@@ -0,0 +1,199 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package indicators
+
+import (
+	"math"
+
+	"github.com/jl1/nexapi/utils/candle"
+)
+
+// RSI is the relative strength index of Wilder, between 0 and 100.
+type RSI struct {
+	gain *EMA
+	loss *EMA
+	prev float64
+	n    int
+}
+
+func NewRSI(period int) *RSI {
+	period = checkPeriod(period)
+	return &RSI{
+		gain: newEMA(period, 1/float64(period)),
+		loss: newEMA(period, 1/float64(period)),
+	}
+}
+
+func (r *RSI) Update(v float64) float64 {
+	r.n++
+	if r.n > 1 {
+		change := v - r.prev
+		r.gain.Update(math.Max(change, 0))
+		r.loss.Update(math.Max(-change, 0))
+	}
+	r.prev = v
+
+	return r.Value()
+}
+
+func (r *RSI) Ready() bool {
+	return r.gain.Ready()
+}
+
+func (r *RSI) Value() float64 {
+	if !r.Ready() {
+		return math.NaN()
+	}
+
+	gain, loss := r.gain.Value(), r.loss.Value()
+	if loss == 0 {
+		if gain == 0 {
+			return 50
+		}
+		return 100
+	}
+
+	return 100 - 100/(1+gain/loss)
+}
+
+func RSISeries(values []float64, period int) []float64 {
+	return series(values, NewRSI(period).Update)
+}
+
+type MACDValue struct {
+	MACD      float64
+	Signal    float64
+	Histogram float64
+}
+
+// MACD is the difference between a fast and a slow EMA, with an EMA of it as signal.
+type MACD struct {
+	fast   *EMA
+	slow   *EMA
+	signal *EMA
+}
+
+// NewMACD builds a MACD, the usual periods are 12, 26 and 9.
+func NewMACD(fast, slow, signal int) *MACD {
+	return &MACD{fast: NewEMA(fast), slow: NewEMA(slow), signal: NewEMA(signal)}
+}
+
+func (m *MACD) Update(v float64) MACDValue {
+	m.fast.Update(v)
+	m.slow.Update(v)
+
+	if m.fast.Ready() && m.slow.Ready() {
+		m.signal.Update(m.fast.Value() - m.slow.Value())
+	}
+
+	return m.Value()
+}
+
+// Ready reports whether the signal line is available, the MACD line is available
+// before it.
+func (m *MACD) Ready() bool {
+	return m.signal.Ready()
+}
+
+func (m *MACD) Value() MACDValue {
+	ret := MACDValue{MACD: math.NaN(), Signal: m.signal.Value(), Histogram: math.NaN()}
+
+	if m.fast.Ready() && m.slow.Ready() {
+		ret.MACD = m.fast.Value() - m.slow.Value()
+	}
+
+	if m.Ready() {
+		ret.Histogram = ret.MACD - ret.Signal
+	}
+
+	return ret
+}
+
+func MACDSeries(values []float64, fast, slow, signal int) []MACDValue {
+	m := NewMACD(fast, slow, signal)
+
+	ret := make([]MACDValue, len(values))
+	for i, v := range values {
+		ret[i] = m.Update(v)
+	}
+	return ret
+}
+
+type StochasticValue struct {
+	K float64
+	D float64
+}
+
+// Stochastic is the fast stochastic oscillator, %K is the position of the close in
+// the high-low range of the last kPeriod candles and %D its simple average over
+// dPeriod.
+type Stochastic struct {
+	highs *window
+	lows  *window
+	d     *SMA
+	k     float64
+}
+
+// NewStochastic builds a stochastic oscillator, the usual periods are 14 and 3.
+func NewStochastic(kPeriod, dPeriod int) *Stochastic {
+	return &Stochastic{
+		highs: newWindow(checkPeriod(kPeriod)),
+		lows:  newWindow(kPeriod),
+		d:     NewSMA(dPeriod),
+		k:     math.NaN(),
+	}
+}
+
+func (s *Stochastic) Update(c *candle.Candle) StochasticValue {
+	s.highs.push(c.High.Float64())
+	s.lows.push(c.Low.Float64())
+
+	if s.highs.full {
+		high, low := math.Inf(-1), math.Inf(1)
+		for i := range s.highs.values {
+			high = math.Max(high, s.highs.values[i])
+			low = math.Min(low, s.lows.values[i])
+		}
+
+		s.k = 50
+		if high > low {
+			s.k = (c.Close.Float64() - low) / (high - low) * 100
+		}
+		s.d.Update(s.k)
+	}
+
+	return s.Value()
+}
+
+func (s *Stochastic) Ready() bool {
+	return s.d.Ready()
+}
+
+func (s *Stochastic) Value() StochasticValue {
+	return StochasticValue{K: s.k, D: s.d.Value()}
+}
+
+func StochasticSeries(candles []*candle.Candle, kPeriod, dPeriod int) []StochasticValue {
+	s := NewStochastic(kPeriod, dPeriod)
+
+	ret := make([]StochasticValue, len(candles))
+	for i, v := range candles {
+		ret[i] = s.Update(v)
+	}
+	return ret
+}
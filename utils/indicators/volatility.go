@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package indicators
+
+import (
+	"math"
+
+	"github.com/jl1/nexapi/utils/candle"
+)
+
+type BandsValue struct {
+	Upper  float64
+	Middle float64
+	Lower  float64
+}
+
+// Bollinger bands are the simple average of the last period values plus and minus
+// k population standard deviations.
+type Bollinger struct {
+	sma *SMA
+	k   float64
+}
+
+// NewBollinger builds Bollinger bands, the usual parameters are 20 and 2.
+func NewBollinger(period int, k float64) *Bollinger {
+	return &Bollinger{sma: NewSMA(period), k: k}
+}
+
+func (b *Bollinger) Update(v float64) BandsValue {
+	b.sma.Update(v)
+	return b.Value()
+}
+
+func (b *Bollinger) Ready() bool {
+	return b.sma.Ready()
+}
+
+func (b *Bollinger) Value() BandsValue {
+	if !b.Ready() {
+		return BandsValue{Upper: math.NaN(), Middle: math.NaN(), Lower: math.NaN()}
+	}
+
+	mean := b.sma.Value()
+
+	// computed from the window rather than from running sums, which lose precision
+	var variance float64
+	for _, v := range b.sma.w.values {
+		variance += (v - mean) * (v - mean)
+	}
+	dev := b.k * math.Sqrt(variance/float64(len(b.sma.w.values)))
+
+	return BandsValue{Upper: mean + dev, Middle: mean, Lower: mean - dev}
+}
+
+func BollingerSeries(values []float64, period int, k float64) []BandsValue {
+	b := NewBollinger(period, k)
+
+	ret := make([]BandsValue, len(values))
+	for i, v := range values {
+		ret[i] = b.Update(v)
+	}
+	return ret
+}
+
+// ATR is the average true range smoothed as defined by Wilder. The true range of the
+// first candle is its high-low range.
+type ATR struct {
+	avg       *EMA
+	prevClose float64
+	n         int
+}
+
+func NewATR(period int) *ATR {
+	period = checkPeriod(period)
+	return &ATR{avg: newEMA(period, 1/float64(period))}
+}
+
+func (a *ATR) Update(c *candle.Candle) float64 {
+	high, low, closePrice := c.High.Float64(), c.Low.Float64(), c.Close.Float64()
+
+	tr := high - low
+	if a.n > 0 {
+		tr = math.Max(tr, math.Max(math.Abs(high-a.prevClose), math.Abs(low-a.prevClose)))
+	}
+	a.n++
+	a.prevClose = closePrice
+
+	return a.avg.Update(tr)
+}
+
+func (a *ATR) Ready() bool {
+	return a.avg.Ready()
+}
+
+func (a *ATR) Value() float64 {
+	return a.avg.Value()
+}
+
+func ATRSeries(candles []*candle.Candle, period int) []float64 {
+	a := NewATR(period)
+
+	ret := make([]float64, len(candles))
+	for i, v := range candles {
+		ret[i] = a.Update(v)
+	}
+	return ret
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2023, LinstoHu
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package indicators
+
+import (
+	"math"
+
+	"github.com/jl1/nexapi/utils/candle"
+)
+
+// VWAP is the volume weighted average of the typical price. With a session aligner
+// it restarts at the beginning of every session, e.g. every day, otherwise it
+// accumulates forever.
+type VWAP struct {
+	session *candle.Aligner
+	start   int64
+	pv      float64
+	volume  float64
+}
+
+// NewVWAP builds a VWAP, session is optional.
+func NewVWAP(session *candle.Aligner) *VWAP {
+	return &VWAP{session: session}
+}
+
+func (w *VWAP) Update(c *candle.Candle) float64 {
+	if w.session != nil {
+		if start := w.session.Start(c.OpenTime); start != w.start {
+			w.start, w.pv, w.volume = start, 0, 0
+		}
+	}
+
+	volume := c.Volume.Float64()
+	w.pv += TypicalPrice(c) * volume
+	w.volume += volume
+
+	return w.Value()
+}
+
+// Value is NaN until some volume was traded.
+func (w *VWAP) Value() float64 {
+	if w.volume == 0 {
+		return math.NaN()
+	}
+	return w.pv / w.volume
+}
+
+func VWAPSeries(candles []*candle.Candle, session *candle.Aligner) []float64 {
+	w := NewVWAP(session)
+
+	ret := make([]float64, len(candles))
+	for i, v := range candles {
+		ret[i] = w.Update(v)
+	}
+	return ret
+}
+
+// OBV is the on-balance volume, the volume of a candle is added when it closes above
+// the previous close and subtracted when it closes below. It starts at zero.
+type OBV struct {
+	prevClose float64
+	n         int
+	value     float64
+}
+
+func NewOBV() *OBV {
+	return &OBV{}
+}
+
+func (o *OBV) Update(c *candle.Candle) float64 {
+	closePrice := c.Close.Float64()
+
+	if o.n > 0 {
+		switch {
+		case closePrice > o.prevClose:
+			o.value += c.Volume.Float64()
+		case closePrice < o.prevClose:
+			o.value -= c.Volume.Float64()
+		}
+	}
+	o.n++
+	o.prevClose = closePrice
+
+	return o.value
+}
+
+func (o *OBV) Value() float64 {
+	return o.value
+}
+
+func OBVSeries(candles []*candle.Candle) []float64 {
+	o := NewOBV()
+
+	ret := make([]float64, len(candles))
+	for i, v := range candles {
+		ret[i] = o.Update(v)
+	}
+	return ret
+}